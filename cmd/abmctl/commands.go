@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/dao"
+	"github.com/ildomm/account-balance-manager/entity"
+)
+
+// DefaultTransactionsLimit is the number of transactions listed when no limit is given
+const DefaultTransactionsLimit = 20
+
+var errUsage = errors.New("invalid usage")
+
+// The formats of the reports
+const (
+	reportFormatTable = "table"
+	reportFormatJSON  = "json"
+	reportFormatCSV   = "csv"
+)
+
+var reportFormats = []string{reportFormatTable, reportFormatJSON, reportFormatCSV}
+
+// commands runs the account operations over the DAO, writing the results to out.
+type commands struct {
+	accountDAO dao.DAO
+	out        io.Writer
+}
+
+func newCommands(accountDAO dao.DAO, out io.Writer) *commands {
+	return &commands{accountDAO: accountDAO, out: out}
+}
+
+// run dispatches the account operation named by the first argument
+func (c *commands) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "user":
+		if len(args) < 2 {
+			return errUsage
+		}
+		switch args[1] {
+		case "create":
+			return c.createUser(ctx, args[2:])
+		case "show":
+			return c.showUser(ctx, args[2:])
+		}
+	case "transactions":
+		return c.listTransactions(ctx, args[1:])
+	case "adjust":
+		return c.adjust(ctx, args[1:])
+	case "bonus":
+		return c.grantBonus(ctx, args[1:])
+	case "ledger":
+		return c.verifyLedger(ctx, args[1:])
+	case "reconcile":
+		return c.reconcile(ctx, args[1:])
+	case "snapshot":
+		return c.snapshotLedger(ctx, args[1:])
+	case "apikey":
+		if len(args) < 2 {
+			return errUsage
+		}
+		switch args[1] {
+		case "create":
+			return c.createAPIKey(ctx, args[2:])
+		case "revoke":
+			return c.revokeAPIKey(ctx, args[2:])
+		}
+	}
+	return errUsage
+}
+
+// createUser handles `user create`
+func (c *commands) createUser(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	user, err := c.accountDAO.CreateUser(ctx)
+	if err != nil {
+		return err
+	}
+	return c.writeUser(user)
+}
+
+// showUser handles `user show <userId>`
+func (c *commands) showUser(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	userID, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+
+	user, err := c.accountDAO.RetrieveUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return c.writeUser(user)
+}
+
+// listTransactions handles `transactions [-limit N] <userId>`, newest-first
+func (c *commands) listTransactions(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("transactions", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	limit := fs.Int("limit", DefaultTransactionsLimit, "The maximum number of transactions to list")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	if *limit <= 0 {
+		return entity.ErrInvalidPageLimit
+	}
+	userID, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	page, err := c.accountDAO.ListGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: *limit})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tSOURCE\tTRANSACTION ID\tAMOUNT\tCURRENCY\tBALANCE AFTER\tCREATED AT\tREASON")
+	for _, gameResult := range page.GameResults {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			gameResult.ID,
+			gameResult.GameStatus,
+			gameResult.TransactionSource,
+			gameResult.TransactionID,
+			gameResult.Amount,
+			gameResult.Currency,
+			gameResult.BalanceAfter,
+			gameResult.CreatedAt.Format(time.RFC3339),
+			valueOrEmpty(gameResult.Reason))
+	}
+	return w.Flush()
+}
+
+// adjust handles `adjust -reason "..." [-currency CODE] <userId> <amount>`,
+// the amount being negative to debit the user
+func (c *commands) adjust(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("adjust", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	reason := fs.String("reason", "", "Why the balance is being adjusted")
+	currencyCode := fs.String("currency", string(entity.DefaultCurrency), "The currency of the adjusted wallet")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	userID, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	amount, err := entity.ParseMoney(fs.Arg(1))
+	if err != nil {
+		return entity.ErrInvalidAmount
+	}
+	currency, err := entity.ParseCurrency(*currencyCode)
+	if err != nil {
+		return err
+	}
+
+	gameResult, err := c.accountDAO.CreateAdjustment(ctx, userID, amount, currency, *reason)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSACTION ID\tSTATUS\tAMOUNT\tCURRENCY\tBALANCE AFTER")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+		gameResult.TransactionID,
+		gameResult.GameStatus,
+		gameResult.Amount,
+		gameResult.Currency,
+		gameResult.BalanceAfter)
+	return w.Flush()
+}
+
+// grantBonus handles `bonus -reason "..." [-currency CODE] [-wagering N] <userId> <amount>`,
+// the bonus having to be wagered N times before it turns into cash
+func (c *commands) grantBonus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bonus", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	reason := fs.String("reason", "", "Why the bonus is being granted")
+	currencyCode := fs.String("currency", string(entity.DefaultCurrency), "The currency of the credited wallet")
+	wagering := fs.Int("wagering", 0, "How many times the bonus must be wagered before it turns into cash")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	userID, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	amount, err := entity.ParseMoney(fs.Arg(1))
+	if err != nil {
+		return entity.ErrInvalidAmount
+	}
+	currency, err := entity.ParseCurrency(*currencyCode)
+	if err != nil {
+		return err
+	}
+
+	gameResult, err := c.accountDAO.GrantBonus(ctx, userID, amount, currency, *wagering, *reason)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TRANSACTION ID\tAMOUNT\tCURRENCY\tWAGERING REQUIREMENT\tBALANCE AFTER\tBONUS BALANCE AFTER")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+		gameResult.TransactionID,
+		gameResult.Amount,
+		gameResult.Currency,
+		gameResult.WageringRequirement,
+		gameResult.BalanceAfter,
+		gameResult.BonusBalanceAfter)
+	return w.Flush()
+}
+
+// verifyLedger handles `ledger <userId>`, listing the wallet balances which do not match the ledger
+// It returns entity.ErrLedgerMismatch when there are any
+func (c *commands) verifyLedger(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	userID, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+
+	mismatches, err := c.accountDAO.VerifyLedger(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		fmt.Fprintf(c.out, "The wallets of user %d match the ledger\n", userID)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ACCOUNT\tCURRENCY\tWALLET BALANCE\tLEDGER BALANCE")
+	for _, mismatch := range mismatches {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			mismatch.Account.Type,
+			mismatch.Account.Currency,
+			mismatch.WalletBalance,
+			mismatch.LedgerBalance)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return entity.ErrLedgerMismatch
+}
+
+// reconcile handles `reconcile [-format table|json|csv]`, reporting the wallets whose balance
+// differs from their transaction history
+// It returns entity.ErrBalanceDiscrepancies when there are any
+func (c *commands) reconcile(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("format", reportFormatTable, "The format of the report: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 0 || !slices.Contains(reportFormats, *format) {
+		return errUsage
+	}
+
+	reconciliation, err := c.accountDAO.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case reportFormatJSON:
+		err = writeReconciliationJSON(c.out, reconciliation)
+	case reportFormatCSV:
+		err = writeReconciliationCSV(c.out, reconciliation)
+	default:
+		err = writeReconciliationTable(c.out, reconciliation)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(reconciliation.Discrepancies) > 0 {
+		return entity.ErrBalanceDiscrepancies
+	}
+	return nil
+}
+
+// snapshotLedger handles `snapshot [-at RFC3339]`, snapshotting the balance of every ledger account as of
+// the time, by default the most recent one the ledger is settled at
+func (c *commands) snapshotLedger(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("snapshot", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	atValue := fs.String("at", "", "The RFC3339 time of the snapshots")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	takenAt := time.Now().Add(-dao.LedgerSnapshotDelay)
+	if *atValue != "" {
+		at, err := time.Parse(time.RFC3339, *atValue)
+		if err != nil {
+			return entity.ErrInvalidTimestamp
+		}
+		takenAt = at
+	}
+
+	snapshots, err := c.accountDAO.TakeLedgerSnapshots(ctx, takenAt)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.out, "Took %d ledger snapshots as of %s\n", snapshots, takenAt.UTC().Format(time.RFC3339))
+	return nil
+}
+
+// createAPIKey handles `apikey create -name "..." -sources game,payment [-users 1,2] [-admin]`,
+// writing the new key, which is only stored hashed and can not be shown again
+func (c *commands) createAPIKey(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	name := fs.String("name", "", "Who the key is handed to")
+	sourcesValue := fs.String("sources", "", "The comma separated transaction sources the key is authorized for")
+	usersValue := fs.String("users", "", "The comma separated users the key is restricted to, every user when empty")
+	admin := fs.Bool("admin", false, "Whether the key can create the users and change their status")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 0 {
+		return errUsage
+	}
+
+	var sources []entity.TransactionSource
+	for _, value := range splitList(*sourcesValue) {
+		source := entity.ParseTransactionSource(strings.ToLower(value))
+		if source == nil {
+			return entity.ErrInvalidTransactionSource
+		}
+		sources = append(sources, *source)
+	}
+	var userIDs []int
+	for _, value := range splitList(*usersValue) {
+		userID, err := parseUserID(value)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	key, apiKey, err := c.accountDAO.CreateAPIKey(ctx, *name, sources, userIDs, *admin)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSOURCES\tUSERS\tADMIN\tKEY")
+	fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%t\t%s\n", apiKey.ID, apiKey.Name, joinList(apiKey.Sources), joinList(apiKey.UserIDs), apiKey.Admin, key)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(c.out, "Hand the key over now, it can not be shown again")
+	return nil
+}
+
+// revokeAPIKey handles `apikey revoke <keyId>`
+func (c *commands) revokeAPIKey(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	keyID, err := strconv.Atoi(args[0])
+	if err != nil || keyID <= 0 {
+		return entity.ErrAPIKeyNotFound
+	}
+
+	apiKey, err := c.accountDAO.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tREVOKED AT")
+	fmt.Fprintf(w, "%d\t%s\t%s\n", apiKey.ID, apiKey.Name, apiKey.RevokedAt.Format(time.RFC3339))
+	return w.Flush()
+}
+
+func (c *commands) writeUser(user *entity.User) error {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tBALANCE\tBONUS\tWAGERING\tAVAILABLE\tSTATUS\tCREATED AT")
+	fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", user.ID, user.Balance, user.BonusBalance, user.WageringRequirement, user.AvailableBalance(), user.Status, user.CreatedAt.Format(time.RFC3339))
+	return w.Flush()
+}
+
+func parseUserID(value string) (int, error) {
+	userID, err := strconv.Atoi(value)
+	if err != nil || userID <= 0 {
+		return 0, entity.ErrInvalidUser
+	}
+	return userID, nil
+}
+
+// splitList splits a comma separated flag value, none when empty
+func splitList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	values := strings.Split(value, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
+
+// joinList formats the values of a list flag back, "all" when empty
+func joinList[T any](values []T) string {
+	if len(values) == 0 {
+		return "all"
+	}
+	formatted := make([]string, 0, len(values))
+	for _, value := range values {
+		formatted = append(formatted, fmt.Sprint(value))
+	}
+	return strings.Join(formatted, ",")
+}
+
+func valueOrEmpty(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandsOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	reason := "goodwill credit"
+
+	testCases := []struct {
+		name      string
+		args      []string
+		mockSetup func(daoMock *test_helpers.DAOMock)
+		expected  []string
+	}{
+		{
+			name: "Create user",
+			args: []string{"user", "create"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateUser", ctx).
+					Return(&entity.User{ID: 7, Status: entity.UserStatusActive, CreatedAt: createdAt}, nil)
+			},
+			expected: []string{"ID", "BALANCE", "STATUS", "7", "0.00", "active", "2024-05-01T10:00:00Z"},
+		},
+		{
+			name: "Show user",
+			args: []string{"user", "show", "7"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveUser", ctx, 7).
+					Return(&entity.User{ID: 7, Balance: 1050, BonusBalance: 200, WageringRequirement: 1000, HeldBalance: 300, Status: entity.UserStatusFrozen, CreatedAt: createdAt}, nil)
+			},
+			expected: []string{"AVAILABLE", "WAGERING", "7", "10.50", "2.00", "9.50", "frozen"},
+		},
+		{
+			name: "List transactions",
+			args: []string{"transactions", "-limit", "5", "7"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("ListGameResults", ctx, entity.GameResultFilter{UserID: 7, Limit: 5}).
+					Return(&entity.GameResultPage{GameResults: []entity.GameResult{
+						{ID: 2, UserID: 7, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceServer,
+							TransactionID: "adjustment-1", Amount: 500, BalanceAfter: 1550, Reason: &reason, CreatedAt: createdAt},
+						{ID: 1, UserID: 7, GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame,
+							TransactionID: "tx-1", Amount: 1050, BalanceAfter: 1050, CreatedAt: createdAt},
+					}}, nil)
+			},
+			expected: []string{"TRANSACTION ID", "adjustment-1", "15.50", "goodwill credit", "tx-1", "10.50"},
+		},
+		{
+			name: "List transactions with the default limit",
+			args: []string{"transactions", "7"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("ListGameResults", ctx, entity.GameResultFilter{UserID: 7, Limit: DefaultTransactionsLimit}).
+					Return(&entity.GameResultPage{}, nil)
+			},
+			expected: []string{"TRANSACTION ID"},
+		},
+		{
+			name: "Adjust with a debit",
+			args: []string{"adjust", "-reason", reason, "7", "-2.50"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateAdjustment", ctx, 7, entity.Money(-250), entity.DefaultCurrency, reason).
+					Return(&entity.GameResult{GameStatus: entity.GameStatusLose, TransactionID: "adjustment-1",
+						Amount: 250, Currency: entity.DefaultCurrency, BalanceAfter: 800}, nil)
+			},
+			expected: []string{"adjustment-1", "lose", "2.50", "EUR", "8.00"},
+		},
+		{
+			name: "Adjust in another currency",
+			args: []string{"adjust", "-reason", reason, "-currency", "jpy", "7", "500"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateAdjustment", ctx, 7, entity.Money(50000), entity.Currency("JPY"), reason).
+					Return(&entity.GameResult{GameStatus: entity.GameStatusWin, TransactionID: "adjustment-2",
+						Amount: 50000, Currency: "JPY", BalanceAfter: 50000}, nil)
+			},
+			expected: []string{"adjustment-2", "win", "500.00", "JPY"},
+		},
+		{
+			name: "Grant a bonus",
+			args: []string{"bonus", "-reason", "welcome offer", "-wagering", "5", "7", "20"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("GrantBonus", ctx, 7, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer").
+					Return(&entity.GameResult{GameStatus: entity.GameStatusWin, TransactionID: "bonus-1", Amount: 2000, Currency: entity.DefaultCurrency,
+						BonusAmount: 2000, WageringRequirement: 10000, BalanceAfter: 1050, BonusBalanceAfter: 2000}, nil)
+			},
+			expected: []string{"WAGERING REQUIREMENT", "bonus-1", "20.00", "EUR", "100.00", "10.50"},
+		},
+		{
+			name: "Ledger matching the wallets",
+			args: []string{"ledger", "7"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("VerifyLedger", ctx, 7).Return([]entity.LedgerMismatch{}, nil)
+			},
+			expected: []string{"The wallets of user 7 match the ledger"},
+		},
+		{
+			name: "Create an API key",
+			args: []string{"apikey", "create", "-name", "acme games", "-sources", "game, Server", "-users", "7,8"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateAPIKey", ctx, "acme games", []entity.TransactionSource{entity.TransactionSourceGame, entity.TransactionSourceServer}, []int{7, 8}, false).
+					Return("abm_s3cret", &entity.APIKey{ID: 3, Name: "acme games", Sources: []entity.TransactionSource{entity.TransactionSourceGame, entity.TransactionSourceServer}, UserIDs: []int{7, 8}}, nil)
+			},
+			expected: []string{"KEY", "3", "acme games", "game,server", "7,8", "false", "abm_s3cret", "can not be shown again"},
+		},
+		{
+			name: "Create an admin API key for every user",
+			args: []string{"apikey", "create", "-name", "backoffice", "-sources", "server", "-admin"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateAPIKey", ctx, "backoffice", []entity.TransactionSource{entity.TransactionSourceServer}, []int(nil), true).
+					Return("abm_4dmin", &entity.APIKey{ID: 4, Name: "backoffice", Sources: []entity.TransactionSource{entity.TransactionSourceServer}, Admin: true}, nil)
+			},
+			expected: []string{"backoffice", "all", "true", "abm_4dmin"},
+		},
+		{
+			name: "Revoke an API key",
+			args: []string{"apikey", "revoke", "3"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RevokeAPIKey", ctx, 3).
+					Return(&entity.APIKey{ID: 3, Name: "acme games", RevokedAt: &createdAt}, nil)
+			},
+			expected: []string{"REVOKED AT", "acme games", "2024-05-01T10:00:00Z"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			tc.mockSetup(daoMock)
+			out := &bytes.Buffer{}
+
+			err := newCommands(daoMock, out).run(ctx, tc.args)
+			require.NoError(t, err)
+
+			for _, expected := range tc.expected {
+				assert.Contains(t, out.String(), expected)
+			}
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCommandsOnErrors(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name      string
+		args      []string
+		mockSetup func(daoMock *test_helpers.DAOMock)
+		expected  error
+	}{
+		{name: "No command", args: []string{}, expected: errUsage},
+		{name: "Unknown command", args: []string{"delete"}, expected: errUsage},
+		{name: "Unknown user command", args: []string{"user", "delete"}, expected: errUsage},
+		{name: "Missing user id", args: []string{"user", "show"}, expected: errUsage},
+		{name: "Invalid user id", args: []string{"user", "show", "abc"}, expected: entity.ErrInvalidUser},
+		{name: "Invalid limit", args: []string{"transactions", "-limit", "0", "7"}, expected: entity.ErrInvalidPageLimit},
+		{name: "Unknown flag", args: []string{"transactions", "-page", "2", "7"}, expected: errUsage},
+		{name: "Missing amount", args: []string{"adjust", "-reason", "fix", "7"}, expected: errUsage},
+		{name: "Invalid amount", args: []string{"adjust", "-reason", "fix", "7", "1.234"}, expected: entity.ErrInvalidAmount},
+		{name: "Unknown currency", args: []string{"adjust", "-reason", "fix", "-currency", "XXX", "7", "10"}, expected: entity.ErrUnknownCurrency},
+		{name: "Missing bonus amount", args: []string{"bonus", "-reason", "offer", "7"}, expected: errUsage},
+		{name: "Invalid wagering", args: []string{"bonus", "-wagering", "x", "7", "10"}, expected: errUsage},
+		{name: "Missing ledger user id", args: []string{"ledger"}, expected: errUsage},
+		{name: "Unknown report format", args: []string{"reconcile", "-format", "xml"}, expected: errUsage},
+		{name: "Unknown api key command", args: []string{"apikey", "delete", "3"}, expected: errUsage},
+		{name: "Invalid api key source", args: []string{"apikey", "create", "-name", "acme", "-sources", "casino"}, expected: entity.ErrInvalidTransactionSource},
+		{name: "Invalid api key user", args: []string{"apikey", "create", "-name", "acme", "-sources", "game", "-users", "7,x"}, expected: entity.ErrInvalidUser},
+		{name: "Missing api key id", args: []string{"apikey", "revoke"}, expected: errUsage},
+		{name: "Invalid snapshot time", args: []string{"snapshot", "-at", "yesterday"}, expected: entity.ErrInvalidTimestamp},
+		{
+			name: "Snapshot too recent",
+			args: []string{"snapshot", "-at", "2999-01-01T00:00:00Z"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("TakeLedgerSnapshots", ctx, time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)).Return(0, entity.ErrSnapshotTooRecent)
+			},
+			expected: entity.ErrSnapshotTooRecent,
+		},
+		{
+			name: "User not found",
+			args: []string{"user", "show", "7"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveUser", ctx, 7).Return(nil, entity.ErrUserNotFound)
+			},
+			expected: entity.ErrUserNotFound,
+		},
+		{
+			name: "Missing reason",
+			args: []string{"adjust", "7", "10"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateAdjustment", ctx, 7, entity.Money(1000), entity.DefaultCurrency, "").Return(nil, entity.ErrMissingReason)
+			},
+			expected: entity.ErrMissingReason,
+		},
+		{
+			name: "Missing api key name",
+			args: []string{"apikey", "create", "-sources", "game"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateAPIKey", ctx, "", []entity.TransactionSource{entity.TransactionSourceGame}, []int(nil), false).Return("", nil, entity.ErrMissingAPIKeyName)
+			},
+			expected: entity.ErrMissingAPIKeyName,
+		},
+		{
+			name: "API key not found",
+			args: []string{"apikey", "revoke", "9"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RevokeAPIKey", ctx, 9).Return(nil, entity.ErrAPIKeyNotFound)
+			},
+			expected: entity.ErrAPIKeyNotFound,
+		},
+		{
+			name: "Wagering multiplier too high",
+			args: []string{"bonus", "-reason", "offer", "-wagering", "1000", "7", "10"},
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("GrantBonus", ctx, 7, entity.Money(1000), entity.DefaultCurrency, 1000, "offer").Return(nil, entity.ErrInvalidWageringMultiplier)
+			},
+			expected: entity.ErrInvalidWageringMultiplier,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+			out := &bytes.Buffer{}
+
+			err := newCommands(daoMock, out).run(ctx, tc.args)
+			assert.ErrorIs(t, err, tc.expected)
+			assert.Empty(t, out.String())
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLedgerCommandOnMismatch(t *testing.T) {
+	ctx := context.Background()
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("VerifyLedger", ctx, 7).Return([]entity.LedgerMismatch{
+		{Account: entity.UserCashAccount(7, entity.DefaultCurrency), WalletBalance: 1050, LedgerBalance: 1000},
+	}, nil)
+	out := &bytes.Buffer{}
+
+	err := newCommands(daoMock, out).run(ctx, []string{"ledger", "7"})
+	assert.ErrorIs(t, err, entity.ErrLedgerMismatch)
+	for _, expected := range []string{"LEDGER BALANCE", "user_cash", "EUR", "10.50", "10.00"} {
+		assert.Contains(t, out.String(), expected)
+	}
+	daoMock.AssertExpectations(t)
+}
+
+func TestReconcileCommand(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	reconciliation := &entity.Reconciliation{
+		StartedAt:      startedAt,
+		FinishedAt:     startedAt.Add(time.Second),
+		WalletsChecked: 42,
+		Discrepancies: []entity.BalanceDiscrepancy{
+			{UserID: 7, Currency: entity.DefaultCurrency, Balance: 1050, ExpectedBalance: 1000},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{
+			name:   "Table",
+			format: "table",
+			expected: "Checked 42 wallets, 1 discrepancies\n" +
+				"USER ID  CURRENCY  BALANCE  EXPECTED BALANCE  DIFFERENCE\n" +
+				"7        EUR       10.50    10.00             0.50\n",
+		},
+		{
+			name:   "JSON",
+			format: "json",
+			expected: `{
+  "startedAt": "2024-05-01T10:00:00Z",
+  "finishedAt": "2024-05-01T10:00:01Z",
+  "walletsChecked": 42,
+  "discrepancies": [
+    {
+      "userId": 7,
+      "currency": "EUR",
+      "balance": "10.50",
+      "expectedBalance": "10.00",
+      "difference": "0.50"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:     "CSV",
+			format:   "csv",
+			expected: "user_id,currency,balance,expected_balance,difference\n7,EUR,10.50,10.00,0.50\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			daoMock.On("Reconcile", ctx).Return(reconciliation, nil)
+			out := &bytes.Buffer{}
+
+			err := newCommands(daoMock, out).run(ctx, []string{"reconcile", "-format", tc.format})
+			assert.ErrorIs(t, err, entity.ErrBalanceDiscrepancies)
+			assert.Equal(t, tc.expected, out.String())
+			daoMock.AssertExpectations(t)
+		})
+	}
+
+	t.Run("No discrepancies", func(t *testing.T) {
+		daoMock := test_helpers.NewDAOMock()
+		daoMock.On("Reconcile", ctx).Return(&entity.Reconciliation{WalletsChecked: 42}, nil)
+		out := &bytes.Buffer{}
+
+		err := newCommands(daoMock, out).run(ctx, []string{"reconcile"})
+		require.NoError(t, err)
+		assert.Equal(t, "Checked 42 wallets, 0 discrepancies\n", out.String())
+	})
+}
+
+func TestSnapshotCommand(t *testing.T) {
+	ctx := context.Background()
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("TakeLedgerSnapshots", ctx, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)).Return(12, nil)
+	out := &bytes.Buffer{}
+
+	err := newCommands(daoMock, out).run(ctx, []string{"snapshot", "-at", "2024-05-01T00:00:00Z"})
+	require.NoError(t, err)
+	assert.Equal(t, "Took 12 ledger snapshots as of 2024-05-01T00:00:00Z\n", out.String())
+	daoMock.AssertExpectations(t)
+}
+
+func TestRunOnUsageErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		exitCode int
+		expected string
+	}{
+		{name: "No command", args: []string{"-db", "postgres://localhost/db"}, exitCode: 2, expected: "Usage: abmctl"},
+		{name: "Missing database URL", args: []string{"-db", "", "user", "create"}, exitCode: 1, expected: "missing database URL"},
+		{name: "Invalid migration version", args: []string{"-db", "postgres://localhost/db", "migrate", "up", "x"}, exitCode: 1, expected: "invalid migration version"},
+		{name: "Missing migration down version", args: []string{"-db", "postgres://localhost/db", "migrate", "down"}, exitCode: 2, expected: "Usage: abmctl"},
+		{name: "Invalid migration force version", args: []string{"-db", "postgres://localhost/db", "migrate", "force", "-2"}, exitCode: 1, expected: "invalid migration version"},
+		{name: "Unknown migrate command", args: []string{"-db", "postgres://localhost/db", "migrate", "steps", "1"}, exitCode: 2, expected: "Usage: abmctl"},
+		{name: "Help", args: []string{"-h"}, exitCode: 0, expected: "Usage: abmctl"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			exitCode := run(tc.args, stdout, stderr)
+			assert.Equal(t, tc.exitCode, exitCode)
+			assert.True(t, strings.Contains(stderr.String(), tc.expected), stderr.String())
+			assert.Empty(t, stdout.String())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+)
+
+// reconciliationReport is the JSON report of a reconciliation
+type reconciliationReport struct {
+	StartedAt      time.Time                  `json:"startedAt"`
+	FinishedAt     time.Time                  `json:"finishedAt"`
+	WalletsChecked int                        `json:"walletsChecked"`
+	Discrepancies  []balanceDiscrepancyReport `json:"discrepancies"`
+}
+
+type balanceDiscrepancyReport struct {
+	UserID          int             `json:"userId"`
+	Currency        entity.Currency `json:"currency"`
+	Balance         entity.Money    `json:"balance"`
+	ExpectedBalance entity.Money    `json:"expectedBalance"`
+	Difference      entity.Money    `json:"difference"`
+}
+
+func writeReconciliationJSON(w io.Writer, reconciliation *entity.Reconciliation) error {
+	report := reconciliationReport{
+		StartedAt:      reconciliation.StartedAt,
+		FinishedAt:     reconciliation.FinishedAt,
+		WalletsChecked: reconciliation.WalletsChecked,
+		Discrepancies:  make([]balanceDiscrepancyReport, 0, len(reconciliation.Discrepancies)),
+	}
+	for _, discrepancy := range reconciliation.Discrepancies {
+		report.Discrepancies = append(report.Discrepancies, balanceDiscrepancyReport{
+			UserID:          discrepancy.UserID,
+			Currency:        discrepancy.Currency,
+			Balance:         discrepancy.Balance,
+			ExpectedBalance: discrepancy.ExpectedBalance,
+			Difference:      discrepancy.Difference(),
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeReconciliationCSV writes one line per discrepancy, after a header line
+func writeReconciliationCSV(w io.Writer, reconciliation *entity.Reconciliation) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"user_id", "currency", "balance", "expected_balance", "difference"}) //nolint:all
+	for _, discrepancy := range reconciliation.Discrepancies {
+		writer.Write([]string{ //nolint:all
+			strconv.Itoa(discrepancy.UserID),
+			string(discrepancy.Currency),
+			discrepancy.Balance.String(),
+			discrepancy.ExpectedBalance.String(),
+			discrepancy.Difference().String(),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeReconciliationTable(w io.Writer, reconciliation *entity.Reconciliation) error {
+	fmt.Fprintf(w, "Checked %d wallets, %d discrepancies\n", reconciliation.WalletsChecked, len(reconciliation.Discrepancies))
+	if len(reconciliation.Discrepancies) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER ID\tCURRENCY\tBALANCE\tEXPECTED BALANCE\tDIFFERENCE")
+	for _, discrepancy := range reconciliation.Discrepancies {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n",
+			discrepancy.UserID,
+			discrepancy.Currency,
+			discrepancy.Balance,
+			discrepancy.ExpectedBalance,
+			discrepancy.Difference())
+	}
+	return tw.Flush()
+}
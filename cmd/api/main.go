@@ -3,13 +3,20 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
+	"github.com/ildomm/account-balance-manager/config"
 	"github.com/ildomm/account-balance-manager/dao"
 	"github.com/ildomm/account-balance-manager/database"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/metrics"
 	"github.com/ildomm/account-balance-manager/server"
 	"github.com/ildomm/account-balance-manager/shared"
-	"log"
+	"github.com/ildomm/account-balance-manager/tracing"
+	"log/slog"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 )
 
 var (
@@ -23,50 +30,130 @@ func main() {
 	ctx, shutdown := context.WithCancel(context.Background())
 	defer shutdown()
 
-	// Define standards
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-	log.SetPrefix("Server: ")
-	log.Printf("starting http server, Version %s, GIT sha %s", semVer, gitSha)
+	// Define standards, logging as JSON to the standard output
+	logging.Setup(os.Stdout)
+	slog.Info("starting http server", slog.String("version", semVer), slog.String("git_sha", gitSha))
 
 	// Set the timezone to UTC
 	shared.SetGlobalTimezoneUTC() //nolint:all
 
-	// Parse the command line options
-	dBConnURL, err := shared.ParseDBConnURL(os.Args[1:])
+	// Load the configuration from the file, the environment and the command line
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.Fatalf("parsing command line: %s", err)
+		fatal("loading the configuration", err)
 	}
-	httpServerPort, err := shared.ParseHTTPPort(os.Args[1:])
+	slog.Info("effective configuration", slog.Any("config", cfg))
+
+	// Set up the tracing, flushing the pending spans when shutting down
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter, semVer)
 	if err != nil {
-		log.Fatalf("parsing command line: %s", err)
+		fatal("error setting up the tracing", err)
 	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("error flushing the traces", logging.Error(err))
+		}
+	}()
 
-	// Set up the database connection and run migrations
-	log.Printf("connecting to database")
-	querier, err := database.NewPostgresQuerier(
+	// Set up the database connection, running the migrations unless disabled
+	slog.Info("connecting to database", slog.Bool("auto_migrate", cfg.Database.AutoMigrate))
+	querier, err := database.NewPostgresQuerierWithOptions(
 		ctx,
-		dBConnURL,
+		cfg.Database.URL,
+		cfg.DatabaseOptions(),
 	)
 	if err != nil {
-		log.Fatalf("error connecting to the database: %s", err)
+		fatal("error connecting to the database", err)
 	}
 	defer querier.Close()
 
+	// Initialize the metrics, along with the database connection pool statistics
+	var serviceMetrics *metrics.Metrics
+	if cfg.Features.Metrics {
+		serviceMetrics = metrics.NewMetrics()
+		serviceMetrics.MustRegister(metrics.NewDBStatsCollector(querier.Stats))
+	}
+
 	// Initialize manager
 	gameAccountManager := dao.NewAccountDAO(querier)
+	gameAccountManager.WithMetrics(serviceMetrics)
+	gameAccountManager.WithBalanceOrder(cfg.Bonus.ConsumptionOrder)
+
+	// Track the background jobs, so they can be stopped before the database connection gets closed
+	var jobs sync.WaitGroup
+
+	// Reconcile the balances with the transaction history in the background, when enabled
+	if cfg.Reconciliation.Interval > 0 {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			dao.ReconcilePeriodically(ctx, gameAccountManager, cfg.Reconciliation.Interval)
+		}()
+	}
+
+	// Take the ledger snapshots the past balances are computed from in the background, when enabled
+	if cfg.Ledger.SnapshotInterval > 0 {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			dao.SnapshotLedgerPeriodically(ctx, gameAccountManager, cfg.Ledger.SnapshotInterval)
+		}()
+	}
 
 	// Initialize the server
 	server := server.NewServer()
-	server.WithListenAddress(httpServerPort)
+	server.WithListenAddress(cfg.HTTP.Port)
+	server.WithReadHeaderTimeout(cfg.HTTP.ReadHeaderTimeout)
+	server.WithReadTimeout(cfg.HTTP.ReadTimeout)
+	server.WithWriteTimeout(cfg.HTTP.WriteTimeout)
+	server.WithIdleTimeout(cfg.HTTP.IdleTimeout)
+	server.WithReadyTimeout(cfg.HTTP.ReadyTimeout)
 	server.WithAccountManager(gameAccountManager)
+	server.WithHealthChecker(querier)
+	if cfg.Features.APIKeys {
+		server.WithAPIKeyStore(querier)
+	}
+	server.WithSigningSecrets(cfg.Security.SigningSecrets)
+	server.WithSignatureWindow(cfg.Security.SignatureWindow)
+	server.WithRateLimits(cfg.RateLimits.PerUser, cfg.RateLimits.PerClient)
+	server.WithMetrics(serviceMetrics)
+	server.WithVersion(semVer, gitSha)
 
-	log.Println("Starting server on", server.ListenAddress())
+	slog.Info("starting server", slog.Int("port", server.ListenAddress()))
 
-	if err := server.Run(); err != nil {
-		if !errors.Is(err, http.ErrServerClosed) {
-			log.Fatal("Could not start server on", server.ListenAddress())
-		} else {
-			log.Println("Server closed")
+	go func() {
+		if err := server.Run(); err != nil {
+			if !errors.Is(err, http.ErrServerClosed) {
+				fatal("could not start server", err)
+			} else {
+				slog.Info("server closed")
+			}
 		}
+	}()
+
+	// Wait for a signal to terminate, then drain the in-flight requests and stop
+	// the background jobs before the database connection gets closed
+	signal := shared.WaitForSignal()
+	slog.Info("shutting down", slog.String("signal", signal.String()), slog.Duration("timeout", cfg.HTTP.ShutdownTimeout))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("error draining in-flight requests", logging.Error(err))
 	}
+
+	shutdown()
+	jobs.Wait()
+}
+
+// fatal logs the error and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, logging.Error(err))
+	os.Exit(1)
 }
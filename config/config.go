@@ -0,0 +1,207 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/dao"
+	"github.com/ildomm/account-balance-manager/database"
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/ratelimit"
+	"github.com/ildomm/account-balance-manager/server"
+	"github.com/ildomm/account-balance-manager/tracing"
+)
+
+// Config holds every setting of the API, see Load
+type Config struct {
+	Database       Database
+	HTTP           HTTP
+	Tracing        Tracing
+	Security       Security
+	RateLimits     RateLimits
+	Bonus          Bonus
+	Reconciliation Reconciliation
+	Ledger         Ledger
+	Features       Features
+}
+
+type Database struct {
+	URL                string
+	MaxOpenConns       int
+	MaxIdleConns       int
+	ConnMaxLifetime    time.Duration
+	TransactionTimeout time.Duration
+	// AutoMigrate applies the pending migrations on startup, see database.Options
+	AutoMigrate bool
+}
+
+type HTTP struct {
+	Port              int
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ReadyTimeout      time.Duration
+	ShutdownTimeout   time.Duration
+}
+
+type Tracing struct {
+	Exporter string
+}
+
+type Security struct {
+	// SigningSecrets maps each game provider to its HMAC secret, none accepts unsigned transactions
+	SigningSecrets  map[string]string
+	SignatureWindow time.Duration
+}
+
+type RateLimits struct {
+	PerUser   ratelimit.Limit
+	PerClient ratelimit.Limit
+}
+
+type Bonus struct {
+	// ConsumptionOrder is the order in which the lose game results draw from the cash and bonus balances
+	ConsumptionOrder entity.BalanceOrder
+}
+
+type Reconciliation struct {
+	// Interval is how often the API reconciles the wallet balances with the transaction history, zero disabling it
+	Interval time.Duration
+}
+
+type Ledger struct {
+	// SnapshotInterval is how often the API takes the ledger snapshots the past balances are computed from, zero disabling it
+	SnapshotInterval time.Duration
+}
+
+// Features toggles the optional parts of the API
+type Features struct {
+	// APIKeys requires an API key on the user routes
+	APIKeys bool
+	// Metrics exposes the Prometheus metrics
+	Metrics bool
+}
+
+const (
+	DefaultShutdownTimeout = time.Second * 30
+	DefaultTracingExporter = tracing.ExporterNone
+	// DefaultSnapshotInterval takes the ledger snapshots at midnight UTC, the end of day balances
+	DefaultSnapshotInterval = 24 * time.Hour
+)
+
+var (
+	// Tracing exporters that can be configured
+	tracingExporters = []string{tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP}
+	// Bonus consumption orders that can be configured
+	balanceOrders = []entity.BalanceOrder{entity.BalanceOrderCashFirst, entity.BalanceOrderBonusFirst}
+)
+
+// Default returns the configuration used for anything left unset
+func Default() Config {
+	return Config{
+		Database: Database{
+			MaxOpenConns:       database.DefaultMaxOpenConns,
+			MaxIdleConns:       database.DefaultMaxIdleConns,
+			ConnMaxLifetime:    database.DefaultConnMaxLifetime,
+			TransactionTimeout: database.DefaultTransactionTimeout,
+			AutoMigrate:        database.DefaultAutoMigrate,
+		},
+		HTTP: HTTP{
+			Port:              server.DefaultListenAddress,
+			ReadHeaderTimeout: server.DefaultReadHeaderTimeout,
+			ReadTimeout:       server.DefaultReadTimeout,
+			WriteTimeout:      server.DefaultWriteTimeout,
+			IdleTimeout:       server.DefaultIdleTimeout,
+			ReadyTimeout:      server.DefaultReadyTimeout,
+			ShutdownTimeout:   DefaultShutdownTimeout,
+		},
+		Tracing: Tracing{
+			Exporter: DefaultTracingExporter,
+		},
+		Security: Security{
+			SigningSecrets:  map[string]string{},
+			SignatureWindow: server.DefaultSignatureWindow,
+		},
+		Bonus: Bonus{
+			ConsumptionOrder: entity.DefaultBalanceOrder,
+		},
+		Ledger: Ledger{
+			SnapshotInterval: DefaultSnapshotInterval,
+		},
+		Features: Features{
+			APIKeys: true,
+			Metrics: true,
+		},
+	}
+}
+
+// Validate reports every invalid setting at once
+func (c Config) Validate() error {
+	var errs []error
+	check := func(valid bool, format string, args ...interface{}) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Database.URL != "", "missing database.url")
+	if c.Database.URL != "" {
+		_, err := url.Parse(c.Database.URL)
+		check(err == nil, "database.url is not a valid URL")
+	}
+	check(c.Database.MaxOpenConns > 0, "database.maxOpenConns must be positive")
+	check(c.Database.MaxIdleConns >= 0, "database.maxIdleConns must not be negative")
+	check(c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "database.maxIdleConns must not exceed database.maxOpenConns")
+	check(c.Database.ConnMaxLifetime > 0, "database.connMaxLifetime must be positive")
+	check(c.Database.TransactionTimeout > 0, "database.transactionTimeout must be positive")
+	// The ledger snapshots would otherwise miss the postings of the transactions committed behind them
+	check(c.Database.TransactionTimeout < dao.LedgerSnapshotDelay, "database.transactionTimeout must be shorter than the ledger snapshot delay of %v", dao.LedgerSnapshotDelay)
+
+	check(c.HTTP.Port > 0 && c.HTTP.Port <= 65535, "http.port must be between 1 and 65535")
+	check(c.HTTP.ReadHeaderTimeout > 0, "http.readHeaderTimeout must be positive")
+	check(c.HTTP.ReadTimeout > 0, "http.readTimeout must be positive")
+	check(c.HTTP.WriteTimeout > 0, "http.writeTimeout must be positive")
+	check(c.HTTP.IdleTimeout > 0, "http.idleTimeout must be positive")
+	check(c.HTTP.ReadyTimeout > 0, "http.readyTimeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdownTimeout must be positive")
+
+	check(slices.Contains(tracingExporters, c.Tracing.Exporter), "tracing.exporter must be one of %v", tracingExporters)
+
+	check(c.Security.SignatureWindow > 0, "security.signatureWindow must be positive")
+
+	check(entity.ParseBalanceOrder(string(c.Bonus.ConsumptionOrder)) != nil, "bonus.consumptionOrder must be one of %v", balanceOrders)
+
+	check(c.Reconciliation.Interval >= 0, "reconciliation.interval must not be negative")
+	check(c.Ledger.SnapshotInterval >= 0, "ledger.snapshotInterval must not be negative")
+
+	return errors.Join(errs...)
+}
+
+// DatabaseOptions returns the options of the database querier
+func (c Config) DatabaseOptions() database.Options {
+	return database.Options{
+		MaxOpenConns:       c.Database.MaxOpenConns,
+		MaxIdleConns:       c.Database.MaxIdleConns,
+		ConnMaxLifetime:    c.Database.ConnMaxLifetime,
+		TransactionTimeout: c.Database.TransactionTimeout,
+		AutoMigrate:        c.Database.AutoMigrate,
+	}
+}
+
+// LogValue logs the effective configuration, keyed as in the configuration file, with the secrets redacted
+func (c Config) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(settings))
+	for _, s := range settings {
+		value := s.value(&c).String()
+		if s.redact != nil {
+			value = s.redact(value)
+		}
+		attrs = append(attrs, slog.String(s.key, value))
+	}
+	return slog.GroupValue(attrs...)
+}
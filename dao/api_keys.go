@@ -0,0 +1,81 @@
+package dao
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/tracing"
+)
+
+// CreateAPIKey generates a new API key authorized for the sources, and for the users only when given,
+// storing its hash only, see entity.HashAPIKey
+// It returns the key, to be handed to its bearer as it can not be retrieved later on, along with the stored API key
+// It returns an error if the name or the sources are missing, or if a user ID is invalid
+func (dm *accountDAO) CreateAPIKey(ctx context.Context, name string, sources []entity.TransactionSource, userIDs []int, admin bool) (_ string, _ *entity.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.CreateAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	if strings.TrimSpace(name) == "" {
+		return "", nil, entity.ErrMissingAPIKeyName
+	}
+	if len(sources) == 0 {
+		return "", nil, entity.ErrInvalidTransactionSource
+	}
+	for _, source := range sources {
+		if entity.ParseTransactionSource(string(source)) == nil {
+			return "", nil, entity.ErrInvalidTransactionSource
+		}
+	}
+	for _, userID := range userIDs {
+		if userID <= 0 {
+			return "", nil, entity.ErrInvalidUser
+		}
+	}
+
+	key, err := entity.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	apiKey := entity.APIKey{
+		Name:      name,
+		KeyHash:   entity.HashAPIKey(key),
+		Sources:   sources,
+		UserIDs:   userIDs,
+		Admin:     admin,
+		CreatedAt: time.Now(),
+	}
+
+	id, err := dm.querier.InsertAPIKey(ctx, apiKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating api key", logging.Error(err))
+		return "", nil, err
+	}
+	apiKey.ID = id
+
+	return key, &apiKey, nil
+}
+
+// RevokeAPIKey revokes the API key with the given ID, which can no longer be used from then on
+// Revoking a key already revoked keeps its original revocation time
+// It returns the revoked API key
+// It returns an error if the API key does not exist
+func (dm *accountDAO) RevokeAPIKey(ctx context.Context, keyID int) (_ *entity.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RevokeAPIKey")
+	defer func() { tracing.End(span, err) }()
+
+	apiKey, err := dm.querier.RevokeAPIKey(ctx, keyID, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "error revoking api key", logging.Error(err))
+		return nil, err
+	}
+	if apiKey == nil {
+		return nil, entity.ErrAPIKeyNotFound
+	}
+
+	return apiKey, nil
+}
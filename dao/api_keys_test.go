@@ -0,0 +1,86 @@
+package dao
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+)
+
+func TestCreateAPIKeyOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("InsertAPIKey", mock.Anything, mock.Anything)
+	instance := NewAccountDAO(databaseMock)
+
+	key, apiKey, err := instance.CreateAPIKey(ctx, "acme games", []entity.TransactionSource{entity.TransactionSourceGame}, []int{7}, false)
+	require.NoError(t, err)
+
+	assert.NotZero(t, apiKey.ID)
+	assert.Equal(t, "acme games", apiKey.Name)
+	assert.Equal(t, []entity.TransactionSource{entity.TransactionSourceGame}, apiKey.Sources)
+	assert.Equal(t, []int{7}, apiKey.UserIDs)
+	assert.False(t, apiKey.Admin)
+
+	// Only the hash of the key is stored, as the authentication looks it up
+	assert.Equal(t, entity.HashAPIKey(key), apiKey.KeyHash)
+	databaseMock.AssertCalled(t, "InsertAPIKey", mock.Anything, mock.MatchedBy(func(stored entity.APIKey) bool {
+		return stored.KeyHash == entity.HashAPIKey(key) && stored.KeyHash != key
+	}))
+}
+
+func TestCreateAPIKeyOnErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		keyName  string
+		sources  []entity.TransactionSource
+		userIDs  []int
+		expected error
+	}{
+		{"Missing name", " ", []entity.TransactionSource{entity.TransactionSourceGame}, nil, entity.ErrMissingAPIKeyName},
+		{"Missing sources", "acme", nil, nil, entity.ErrInvalidTransactionSource},
+		{"Unknown source", "acme", []entity.TransactionSource{"casino"}, nil, entity.ErrInvalidTransactionSource},
+		{"Invalid user", "acme", []entity.TransactionSource{entity.TransactionSourceGame}, []int{0}, entity.ErrInvalidUser},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			databaseMock := test_helpers.NewDatabaseMock()
+			instance := NewAccountDAO(databaseMock)
+
+			_, _, err := instance.CreateAPIKey(ctx, tc.keyName, tc.sources, tc.userIDs, false)
+			assert.ErrorIs(t, err, tc.expected)
+			databaseMock.AssertNotCalled(t, "InsertAPIKey", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("InsertAPIKey", mock.Anything, mock.Anything)
+	databaseMock.On("RevokeAPIKey", mock.Anything, mock.Anything, mock.Anything)
+	instance := NewAccountDAO(databaseMock)
+
+	_, apiKey, err := instance.CreateAPIKey(ctx, "acme games", []entity.TransactionSource{entity.TransactionSourceGame}, nil, false)
+	require.NoError(t, err)
+
+	revoked, err := instance.RevokeAPIKey(ctx, apiKey.ID)
+	require.NoError(t, err)
+	assert.True(t, revoked.Revoked())
+
+	// Revoking it again keeps the original revocation time
+	again, err := instance.RevokeAPIKey(ctx, apiKey.ID)
+	require.NoError(t, err)
+	assert.Equal(t, revoked.RevokedAt, again.RevokedAt)
+
+	_, err = instance.RevokeAPIKey(ctx, 999)
+	assert.ErrorIs(t, err, entity.ErrAPIKeyNotFound)
+}
@@ -0,0 +1,51 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/tracing"
+)
+
+// GrantBonus credits the bonus balance of the user in the currency with a promotion,
+// as a server game result carrying the reason
+// The bonus must be wagered the given number of times, by lose game results, before it turns into cash,
+// a zero multiplier turning it into cash right away
+func (dm *accountDAO) GrantBonus(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, wageringMultiplier int, reason string) (_ *entity.GameResult, err error) {
+	transactionID := entity.BonusTransactionID(uuid.NewString())
+
+	ctx, span := tracing.Start(ctx, "accountDAO.GrantBonus", tracing.UserID(userID), tracing.TransactionID(transactionID))
+	defer func() { tracing.End(span, err) }()
+
+	if amount <= 0 || amount > entity.MaxMoney {
+		return nil, entity.ErrInvalidAmount
+	}
+	if wageringMultiplier < 0 || wageringMultiplier > entity.MaxWageringMultiplier {
+		return nil, entity.ErrInvalidWageringMultiplier
+	}
+	// The requirement, as the wallet one it adds up to, must fit in its column
+	if amount*entity.Money(wageringMultiplier) > entity.MaxMoney {
+		return nil, entity.ErrWageringRequirementTooHigh
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, entity.ErrMissingReason
+	}
+
+	gameResult := entity.GameResult{
+		UserID:              userID,
+		GameStatus:          entity.GameStatusWin,
+		TransactionSource:   entity.TransactionSourceServer,
+		TransactionID:       transactionID,
+		Amount:              amount,
+		Currency:            currency,
+		Reason:              &reason,
+		BonusAmount:         amount,
+		WageringRequirement: amount * entity.Money(wageringMultiplier),
+		CreatedAt:           time.Now(),
+	}
+	return dm.createGameResult(ctx, metrics.OperationGrantBonus, gameResult, false)
+}
@@ -0,0 +1,207 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+)
+
+func TestGrantBonusOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	grant, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer")
+	require.NoError(t, err)
+	assert.Equal(t, entity.GameStatusWin, grant.GameStatus)
+	assert.Equal(t, entity.TransactionSourceServer, grant.TransactionSource)
+	assert.True(t, strings.HasPrefix(grant.TransactionID, "bonus-"))
+	assert.Equal(t, "welcome offer", *grant.Reason)
+	assert.Equal(t, entity.Money(2000), grant.BonusAmount)
+	assert.Equal(t, entity.Money(10000), grant.WageringRequirement)
+	assert.Equal(t, entity.Money(10000), grant.BalanceAfter)
+	assert.Equal(t, entity.Money(2000), grant.BonusBalanceAfter)
+
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), user.Balance)
+	assert.Equal(t, entity.Money(2000), user.BonusBalance)
+	assert.Equal(t, entity.Money(10000), user.WageringRequirement)
+	assert.Equal(t, entity.Money(12000), user.AvailableBalance())
+}
+
+func TestGrantBonusWithoutWagering(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	grant, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 0, "cashback")
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(12000), grant.BalanceAfter)
+	assert.Equal(t, entity.Money(0), grant.BonusBalanceAfter)
+}
+
+func TestGrantBonusOnInvalidInput(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	instance := NewAccountDAO(databaseMock)
+
+	tests := []struct {
+		name       string
+		amount     entity.Money
+		multiplier int
+		reason     string
+		expected   error
+	}{
+		{"Zero amount", entity.Money(0), 5, "welcome offer", entity.ErrInvalidAmount},
+		{"Negative amount", entity.Money(-100), 5, "welcome offer", entity.ErrInvalidAmount},
+		{"Negative multiplier", entity.Money(100), -1, "welcome offer", entity.ErrInvalidWageringMultiplier},
+		{"Multiplier too high", entity.Money(100), entity.MaxWageringMultiplier + 1, "welcome offer", entity.ErrInvalidWageringMultiplier},
+		{"Amount too high", entity.MaxMoney + 1, 0, "welcome offer", entity.ErrInvalidAmount},
+		{"Requirement too high", entity.Money(1_000_000_00), entity.MaxWageringMultiplier, "welcome offer", entity.ErrWageringRequirementTooHigh},
+		{"Missing reason", entity.Money(100), 5, " ", entity.ErrMissingReason},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := instance.GrantBonus(context.Background(), 1, tt.amount, entity.DefaultCurrency, tt.multiplier, tt.reason)
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+
+	databaseMock.AssertNotCalled(t, "WithTransaction", mock.Anything, mock.Anything)
+}
+
+func TestCreateGameResultConsumesBalancesInOrder(t *testing.T) {
+	tests := []struct {
+		order               entity.BalanceOrder
+		bonusAmount         entity.Money
+		balanceAfter        entity.Money
+		bonusBalanceAfter   entity.Money
+		wageringRequirement entity.Money
+	}{
+		{entity.BalanceOrderCashFirst, entity.Money(500), entity.Money(0), entity.Money(1500), entity.Money(8500)},
+		{entity.BalanceOrderBonusFirst, entity.Money(1500), entity.Money(1000), entity.Money(500), entity.Money(8500)},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			ctx := context.Background()
+			databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(1000))
+			databaseMock.On("SelectUser", mock.Anything, 1)
+			instance := NewAccountDAO(databaseMock)
+			instance.WithBalanceOrder(tt.order)
+
+			_, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer")
+			require.NoError(t, err)
+
+			lose, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1500), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-1", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.bonusAmount, lose.BonusAmount)
+			assert.Equal(t, tt.balanceAfter, lose.BalanceAfter)
+			assert.Equal(t, tt.bonusBalanceAfter, lose.BonusBalanceAfter)
+
+			user, err := instance.RetrieveUser(ctx, 1)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wageringRequirement, user.WageringRequirement)
+		})
+	}
+}
+
+func TestCreateGameResultMeetsWageringRequirement(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 2, "welcome offer")
+	require.NoError(t, err)
+
+	// The wins do not count towards the wagering requirement
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(5000), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-1", nil)
+	require.NoError(t, err)
+
+	lose, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(4000), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-2", nil)
+	require.NoError(t, err)
+
+	// The bonus left turned into cash
+	assert.Equal(t, entity.Money(0), lose.BonusAmount)
+	assert.Equal(t, entity.Money(13000), lose.BalanceAfter)
+	assert.Equal(t, entity.Money(0), lose.BonusBalanceAfter)
+
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(13000), user.Balance)
+	assert.Equal(t, entity.Money(0), user.BonusBalance)
+	assert.Equal(t, entity.Money(0), user.WageringRequirement)
+}
+
+func TestCreateAdjustmentLeavesBonusBalance(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(1000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+	instance.WithBalanceOrder(entity.BalanceOrderBonusFirst)
+
+	_, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer")
+	require.NoError(t, err)
+
+	debit, err := instance.CreateAdjustment(ctx, 1, entity.Money(-500), entity.DefaultCurrency, "duplicated payout")
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(0), debit.BonusAmount)
+	assert.Equal(t, entity.Money(500), debit.BalanceAfter)
+	assert.Equal(t, entity.Money(2000), debit.BonusBalanceAfter)
+
+	// The cash balance alone can not cover the adjustment
+	_, err = instance.CreateAdjustment(ctx, 1, entity.Money(-1000), entity.DefaultCurrency, "chargeback")
+	assert.ErrorIs(t, err, entity.ErrUserNegativeBalance)
+
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), user.WageringRequirement)
+}
+
+func TestReverseGameResultRestoresBonusBalance(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(1000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	grant, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer")
+	require.NoError(t, err)
+
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1500), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-1", nil)
+	require.NoError(t, err)
+
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(8500), user.WageringRequirement)
+
+	reversal, err := instance.ReverseGameResult(ctx, 1, "tx-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(500), reversal.BonusAmount)
+	assert.Equal(t, entity.Money(1000), reversal.BalanceAfter)
+	assert.Equal(t, entity.Money(2000), reversal.BonusBalanceAfter)
+
+	// The voided bet does not work off the wagering requirement
+	user, err = instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), user.WageringRequirement)
+
+	// Reversing the grant takes back the bonus along with its wagering requirement
+	_, err = instance.ReverseGameResult(ctx, 1, grant.TransactionID, entity.TransactionSourceServer)
+	require.NoError(t, err)
+
+	user, err = instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(1000), user.Balance)
+	assert.Equal(t, entity.Money(0), user.BonusBalance)
+	assert.Equal(t, entity.Money(0), user.WageringRequirement)
+}
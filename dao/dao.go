@@ -2,10 +2,31 @@ package dao
 
 import (
 	"context"
+	"time"
+
 	"github.com/ildomm/account-balance-manager/entity"
 )
 
 type DAO interface {
-	CreateGameResult(ctx context.Context, userID int, gameStatus entity.GameStatus, amount float64, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error)
+	CreateGameResult(ctx context.Context, userID int, gameStatus entity.GameStatus, amount entity.Money, currency entity.Currency, transactionSource entity.TransactionSource, transactionID string, roundID *string) (*entity.GameResult, error)
+	CreateAdjustment(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, reason string) (*entity.GameResult, error)
+	GrantBonus(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, wageringMultiplier int, reason string) (*entity.GameResult, error)
+	ReverseGameResult(ctx context.Context, userID int, transactionID string, transactionSource entity.TransactionSource) (*entity.GameResult, error)
+	CreateUser(ctx context.Context) (*entity.User, error)
 	RetrieveUser(ctx context.Context, userID int) (*entity.User, error)
+	RetrieveWallets(ctx context.Context, userID int) ([]entity.Wallet, error)
+	RetrieveWallet(ctx context.Context, userID int, currency entity.Currency) (*entity.Wallet, error)
+	UpdateUserStatus(ctx context.Context, userID int, status entity.UserStatus) (*entity.User, error)
+	ListGameResults(ctx context.Context, filter entity.GameResultFilter) (*entity.GameResultPage, error)
+	CreateHold(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, transactionSource entity.TransactionSource, expiresIn time.Duration) (*entity.Hold, error)
+	RetrieveHold(ctx context.Context, userID int, holdID int) (*entity.Hold, error)
+	CaptureHold(ctx context.Context, userID int, holdID int, transactionID string, transactionSource entity.TransactionSource) (*entity.GameResult, error)
+	ReleaseHold(ctx context.Context, userID int, holdID int, transactionSource entity.TransactionSource) (*entity.Hold, error)
+	RetrieveRound(ctx context.Context, userID int, roundID string) (*entity.Round, error)
+	VerifyLedger(ctx context.Context, userID int) ([]entity.LedgerMismatch, error)
+	Reconcile(ctx context.Context) (*entity.Reconciliation, error)
+	RetrieveBalancesAt(ctx context.Context, userID int, at time.Time) ([]entity.HistoricalBalance, error)
+	TakeLedgerSnapshots(ctx context.Context, takenAt time.Time) (int, error)
+	CreateAPIKey(ctx context.Context, name string, sources []entity.TransactionSource, userIDs []int, admin bool) (string, *entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) (*entity.APIKey, error)
 }
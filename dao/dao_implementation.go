@@ -2,40 +2,87 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
-	"sync"
+	"log/slog"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/ildomm/account-balance-manager/database"
 	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/tracing"
 	"github.com/jmoiron/sqlx"
 )
 
 type accountDAO struct {
-	querier database.Querier
-	lock    sync.Mutex
+	querier      database.Querier
+	metrics      *metrics.Metrics
+	balanceOrder entity.BalanceOrder
 }
 
 // NewAccountDAO creates a new game result DAO
 func NewAccountDAO(querier database.Querier) *accountDAO {
-	return &accountDAO{querier: querier}
+	return &accountDAO{querier: querier, balanceOrder: entity.DefaultBalanceOrder}
+}
+
+// WithBalanceOrder sets the order in which the lose game results draw from the cash and bonus balances
+func (dm *accountDAO) WithBalanceOrder(order entity.BalanceOrder) {
+	dm.balanceOrder = order
+}
+
+// WithMetrics counts the outcomes and amounts of the game result operations
+func (dm *accountDAO) WithMetrics(metrics *metrics.Metrics) {
+	dm.metrics = metrics
+}
+
+// gameResultErrors are the business errors returned as they are by CreateGameResult,
+// any other error is reported as entity.ErrCreatingGameResult
+var gameResultErrors = []error{
+	entity.ErrTransactionIdExists,
+	entity.ErrUserNotFound,
+	entity.ErrUserFrozen,
+	entity.ErrUserClosed,
+	entity.ErrUserNegativeBalance,
+	entity.ErrRoundNotFound,
+	entity.ErrRoundNotOpen,
+	entity.ErrUnknownCurrency,
+	entity.ErrCurrencyMismatch,
+	entity.ErrInvalidAmount,
+	entity.ErrWageringRequirementTooHigh,
+	entity.ErrBalanceTooHigh,
 }
 
 // CreateGameResult creates a new game result
-// It validates the transaction and updates the user balance
+// It validates the transaction and updates the user balance in the currency of the game result
 // It returns the created game result
 // It returns an error if the transaction is invalid or if there is an error creating the game result
-func (dm *accountDAO) CreateGameResult(ctx context.Context, userID int, gameStatus entity.GameStatus, amount float64, transactionSource entity.TransactionSource, transactionID string) (*entity.GameResult, error) {
-	dm.lock.Lock()
-	defer dm.lock.Unlock()
+//
+// The user row is locked for the whole db transaction, so concurrent game results of the same user
+// are serialized by the database, even across several running processes
+//
+// Retrying an already recorded transaction ID with the same payload returns the original game result,
+// along with the balance it resulted in, without changing the balance again
+// Retrying it with a different payload returns entity.ErrTransactionIdExists
+//
+// A lose game result draws from the cash and bonus balances in the balance order of the DAO, see WithBalanceOrder,
+// and counts towards the wagering requirement of the bonus balance
+//
+// A game result given a round ID belongs to that game round, see entity.Round
+// A lose game result opens the round or adds to its stake, and a win game result settles it
+// It returns an error if the round of a win game result was never opened, or if the round is already settled or voided
+func (dm *accountDAO) CreateGameResult(ctx context.Context, userID int, gameStatus entity.GameStatus, amount entity.Money, currency entity.Currency, transactionSource entity.TransactionSource, transactionID string, roundID *string) (_ *entity.GameResult, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.CreateGameResult", tracing.UserID(userID), tracing.TransactionID(transactionID))
+	defer func() { tracing.End(span, err) }()
 
-	// Check the transaction and its related user
-	balance := 0.0
-	if user, err := dm.validateTransaction(ctx, userID, gameStatus, amount, transactionID); err != nil {
-		return nil, err
-	} else {
-		balance = dm.calculateNewBalance(user.Balance, gameStatus, amount)
+	if roundID != nil {
+		span.SetAttributes(tracing.RoundID(*roundID))
+
+		if !entity.ValidRoundID(*roundID) {
+			return nil, entity.ErrInvalidRoundID
+		}
 	}
 
 	gameResult := entity.GameResult{
@@ -44,13 +91,78 @@ func (dm *accountDAO) CreateGameResult(ctx context.Context, userID int, gameStat
 		TransactionSource: transactionSource,
 		TransactionID:     transactionID,
 		Amount:            amount,
+		Currency:          currency,
+		RoundID:           roundID,
 		CreatedAt:         time.Now(),
 	}
+	return dm.createGameResult(ctx, metrics.OperationCreateGameResult, gameResult, true)
+}
+
+// CreateAdjustment records a manual adjustment of the user balance in the currency by an operator,
+// as a server game result carrying the reason, a win for a positive amount and a lose for a negative one
+// An adjustment only moves the cash balance, and does not count towards the wagering requirement
+func (dm *accountDAO) CreateAdjustment(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, reason string) (_ *entity.GameResult, err error) {
+	transactionID := entity.AdjustmentTransactionID(uuid.NewString())
+
+	ctx, span := tracing.Start(ctx, "accountDAO.CreateAdjustment", tracing.UserID(userID), tracing.TransactionID(transactionID))
+	defer func() { tracing.End(span, err) }()
+
+	if amount == 0 {
+		return nil, entity.ErrInvalidAmount
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, entity.ErrMissingReason
+	}
+
+	gameResult := entity.GameResult{
+		UserID:            userID,
+		GameStatus:        entity.GameStatusWin,
+		TransactionSource: entity.TransactionSourceServer,
+		TransactionID:     transactionID,
+		Amount:            amount,
+		Currency:          currency,
+		Reason:            &reason,
+		CreatedAt:         time.Now(),
+	}
+	if amount < 0 {
+		gameResult.GameStatus = entity.GameStatusLose
+		gameResult.Amount = -amount
+	}
+	return dm.createGameResult(ctx, metrics.OperationCreateAdjustment, gameResult, false)
+}
+
+// createGameResult records the game result within a db transaction, see CreateGameResult
+// A wagered lose game result counts towards the wagering requirement, see applyGameResult
+func (dm *accountDAO) createGameResult(ctx context.Context, operation string, gameResult entity.GameResult, wagered bool) (*entity.GameResult, error) {
+	var replayed *entity.GameResult
 
 	// Perform the whole operation inside a db transaction
-	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
-		if err := dm.persistGameResultTransaction(ctx, txn, userID, &gameResult, balance); err != nil {
-			log.Printf("error persisting game result: %v", err)
+	err := dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+
+		// A retried transaction is answered with its original game result
+		replayed, err = dm.replayedGameResult(ctx, txn, gameResult)
+		if err != nil || replayed != nil {
+			return err
+		}
+
+		// Check the transaction and its related user
+		wallet, err := dm.validateTransaction(ctx, txn, gameResult.UserID, gameResult.Currency, gameResult.GameStatus, gameResult.Amount)
+		if err != nil {
+			return err
+		}
+
+		// The user row lock also serializes the game results of the round
+		if err := dm.validateRound(ctx, txn, gameResult); err != nil {
+			return err
+		}
+		before := *wallet
+		if err := dm.applyGameResult(wallet, &gameResult, wagered); err != nil {
+			return err
+		}
+
+		counterparty := entity.CounterpartyAccount(gameResult.TransactionSource, gameResult.Currency)
+		if err := dm.persistGameResultTransaction(ctx, txn, &gameResult, before, *wallet, counterparty); err != nil {
+			slog.ErrorContext(ctx, "error persisting game result", logging.Error(err))
 			return err
 		}
 
@@ -58,73 +170,294 @@ func (dm *accountDAO) CreateGameResult(ctx context.Context, userID int, gameStat
 		// Success, continue with the transaction commit
 		return nil
 	})
+
+	// A concurrent request might have recorded the same transaction ID after it was looked up,
+	// look it up again, now that it is committed
+	if errors.Is(err, entity.ErrTransactionIdExists) {
+		err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+			replayed, err = dm.replayedGameResult(ctx, txn, gameResult)
+			return err
+		})
+	}
+
 	if err != nil {
-		log.Printf("error performing game result db transaction: %v", err)
+		dm.metrics.ObserveGameResultError(operation, err)
+
+		for _, gameResultErr := range gameResultErrors {
+			if errors.Is(err, gameResultErr) {
+				return nil, gameResultErr
+			}
+		}
+
+		slog.ErrorContext(ctx, "error performing game result db transaction", logging.Error(err))
 		return nil, entity.ErrCreatingGameResult
 	}
 
+	if replayed != nil {
+		dm.metrics.ObserveGameResultOutcome(operation, metrics.OutcomeReplayed)
+		return replayed, nil
+	}
+
+	dm.metrics.ObserveGameResultOutcome(operation, metrics.OutcomeSuccess)
+	dm.metrics.ObserveGameResultAmount(gameResult)
 	return &gameResult, nil
 }
 
-// validateTransaction validates the transaction
-// It returns the user if the transaction is valid
-func (dm *accountDAO) validateTransaction(ctx context.Context, userID int, gameStatus entity.GameStatus, amount float64, transactionID string) (*entity.User, error) {
-	exists, err := dm.querier.TransactionIDExist(ctx, transactionID)
+// replayedGameResult returns the game result already recorded with the same transaction ID, if any
+// It returns an error if that game result was recorded with a different payload
+func (dm *accountDAO) replayedGameResult(ctx context.Context, txn *sqlx.Tx, gameResult entity.GameResult) (*entity.GameResult, error) {
+	existing, err := dm.querier.SelectGameResultByTransactionID(ctx, *txn, gameResult.TransactionID)
 	if err != nil {
-		log.Printf("error locating transaction: %v", err)
+		slog.ErrorContext(ctx, "error locating transaction", logging.Error(err))
 		return nil, err
 	}
-	if exists {
+	if existing == nil {
+		return nil, nil
+	}
+
+	if !existing.IsReplayOf(gameResult) {
 		return nil, entity.ErrTransactionIdExists
 	}
+	return existing, nil
+}
 
-	user, err := dm.querier.SelectUser(ctx, userID)
+// reversalErrors are the business errors returned as they are by ReverseGameResult,
+// any other error is reported as entity.ErrCreatingGameResult
+var reversalErrors = []error{
+	entity.ErrTransactionNotFound,
+	entity.ErrTransactionNotReversible,
+	entity.ErrTransactionAlreadyReversed,
+	entity.ErrReversalSourceMismatch,
+	entity.ErrUserNotFound,
+	entity.ErrUserFrozen,
+	entity.ErrUserClosed,
+	entity.ErrUserNegativeBalance,
+	entity.ErrBalanceTooHigh,
+}
+
+// ReverseGameResult reverses a game result of the user
+// It records a compensating game result, referencing the original transaction, and restores the user balance
+// It returns the compensating game result
+// It returns an error if the transaction does not exist, was already reversed, is of another source,
+// or if the reversal would leave the user with a negative balance
+// The reversal belongs to the round of the reversed game result, if any
+func (dm *accountDAO) ReverseGameResult(ctx context.Context, userID int, transactionID string, transactionSource entity.TransactionSource) (_ *entity.GameResult, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.ReverseGameResult", tracing.UserID(userID), tracing.TransactionID(transactionID))
+	defer func() { tracing.End(span, err) }()
+
+	var reversal entity.GameResult
+
+	// Perform the whole operation inside a db transaction
+	err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		original, err := dm.querier.SelectGameResultByTransactionID(ctx, *txn, transactionID)
+		if err != nil {
+			slog.ErrorContext(ctx, "error locating transaction", logging.Error(err))
+			return err
+		}
+		if original == nil || original.UserID != userID {
+			return entity.ErrTransactionNotFound
+		}
+		if original.ReversesTransactionID != nil {
+			return entity.ErrTransactionNotReversible
+		}
+		// The API keys are authorized per source, so a source must not move back the balance of another one
+		if original.TransactionSource != transactionSource {
+			return entity.ErrReversalSourceMismatch
+		}
+
+		reversal = entity.GameResult{
+			UserID:                userID,
+			GameStatus:            original.GameStatus.Opposite(),
+			TransactionSource:     transactionSource,
+			TransactionID:         entity.ReversalTransactionID(original.TransactionID),
+			Amount:                original.Amount,
+			Currency:              original.Currency,
+			ReversesTransactionID: &original.TransactionID,
+			RoundID:               original.RoundID,
+			BonusAmount:           original.BonusAmount,
+			WageringRequirement:   original.WageringRequirement,
+			CreatedAt:             time.Now(),
+		}
+
+		// The reversal transaction ID is derived from the original one, and reserved to the service,
+		// so it exists only if already reversed
+		existing, err := dm.querier.SelectGameResultByTransactionID(ctx, *txn, reversal.TransactionID)
+		if err != nil {
+			slog.ErrorContext(ctx, "error locating transaction", logging.Error(err))
+			return err
+		}
+		if existing != nil {
+			return entity.ErrTransactionAlreadyReversed
+		}
+
+		// Check the reversal and its related user, as any other transaction
+		wallet, err := dm.validateTransaction(ctx, txn, userID, reversal.Currency, reversal.GameStatus, reversal.Amount)
+		if err != nil {
+			return err
+		}
+		// The reversal gives back the cash and bonus parts of the original, along with the wagering it worked off
+		before := *wallet
+		if err := dm.applyGameResult(wallet, &reversal, false); err != nil {
+			return err
+		}
+
+		// The reversal moves back the counterparty of the original
+		counterparty := entity.CounterpartyAccount(original.TransactionSource, original.Currency)
+		if err := dm.persistGameResultTransaction(ctx, txn, &reversal, before, *wallet, counterparty); err != nil {
+			slog.ErrorContext(ctx, "error persisting reversal", logging.Error(err))
+			return err
+		}
+
+		return nil
+	})
 	if err != nil {
-		log.Printf("error locating user: %v", err)
+		// A concurrent request reversed the same transaction in the meantime
+		if errors.Is(err, entity.ErrTransactionIdExists) {
+			err = entity.ErrTransactionAlreadyReversed
+		}
+		dm.metrics.ObserveGameResultError(metrics.OperationReverseGameResult, err)
+
+		for _, reversalErr := range reversalErrors {
+			if errors.Is(err, reversalErr) {
+				return nil, reversalErr
+			}
+		}
+
+		slog.ErrorContext(ctx, "error performing reversal db transaction", logging.Error(err))
+		return nil, entity.ErrCreatingGameResult
+	}
+
+	dm.metrics.ObserveGameResultOutcome(metrics.OperationReverseGameResult, metrics.OutcomeSuccess)
+	dm.metrics.ObserveGameResultAmount(reversal)
+	return &reversal, nil
+}
+
+// validateTransaction validates the transaction
+// It locks the user row until the end of the db transaction
+// It returns the user wallet in the currency if the transaction is valid
+// It returns an error if the currency is unknown or the amount does not fit its minor units
+func (dm *accountDAO) validateTransaction(ctx context.Context, txn *sqlx.Tx, userID int, currency entity.Currency, gameStatus entity.GameStatus, amount entity.Money) (*entity.Wallet, error) {
+	if !currency.Known() {
+		return nil, entity.ErrUnknownCurrency
+	}
+	if !currency.ValidAmount(amount) {
+		return nil, entity.ErrInvalidAmount
+	}
+
+	if _, err := dm.lockActiveUser(ctx, txn, userID); err != nil {
+		return nil, err
+	}
+	wallet, err := dm.lockWallet(ctx, txn, userID, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	// No negative balance allowed, the amounts reserved by the open holds can not be lost twice
+	if gameStatus == entity.GameStatusLose && wallet.AvailableBalance() < amount {
+		return nil, entity.ErrUserNegativeBalance
+	}
+
+	return wallet, nil
+}
+
+// lockWallet locks the wallet of the user in the currency until the end of the db transaction
+// A missing wallet is returned empty, it is stored along with its first balance update
+func (dm *accountDAO) lockWallet(ctx context.Context, txn *sqlx.Tx, userID int, currency entity.Currency) (*entity.Wallet, error) {
+	wallet, err := dm.querier.SelectWalletForUpdate(ctx, *txn, userID, currency)
+	if err != nil {
+		slog.ErrorContext(ctx, "error locking wallet", logging.Error(err))
+		return nil, err
+	}
+	if wallet == nil {
+		wallet = &entity.Wallet{UserID: userID, Currency: currency}
+	}
+	return wallet, nil
+}
+
+// lockActiveUser locks the user row until the end of the db transaction
+// It returns an error if the user does not exist or is not active
+func (dm *accountDAO) lockActiveUser(ctx context.Context, txn *sqlx.Tx, userID int) (*entity.User, error) {
+	user, err := dm.querier.SelectUserForUpdate(ctx, *txn, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error locking user", logging.Error(err))
 		return nil, err
 	}
 	if user == nil {
 		return nil, entity.ErrUserNotFound
 	}
 
-	// No negative balance allowed
-	if gameStatus == entity.GameStatusLose && user.Balance < amount {
-		return nil, entity.ErrUserNegativeBalance
+	// Only active users can have transactions
+	switch user.Status {
+	case entity.UserStatusFrozen:
+		return nil, entity.ErrUserFrozen
+	case entity.UserStatusClosed:
+		return nil, entity.ErrUserClosed
 	}
 
 	return user, nil
 }
 
-// calculateNewBalance calculates the new balance based on the game status
-func (dm *accountDAO) calculateNewBalance(currentBalance float64, gameStatus entity.GameStatus, amount float64) float64 {
-	if gameStatus == entity.GameStatusWin {
-		return currentBalance + amount
+// applyGameResult moves the wallet balances by the game result, keeping track of the resulting balances on it
+// A wagered lose game result draws from the cash and bonus balances in the balance order of the DAO,
+// and counts towards the wagering requirement
+func (dm *accountDAO) applyGameResult(wallet *entity.Wallet, gameResult *entity.GameResult, wagered bool) error {
+	if wagered && gameResult.GameStatus == entity.GameStatusLose {
+		gameResult.BonusAmount = wallet.BonusShare(gameResult.Amount, dm.balanceOrder)
 	}
-	return currentBalance - amount
+	return wallet.Apply(gameResult, wagered)
 }
 
-// persistGameResultTransaction persists the game result transaction
-func (dm *accountDAO) persistGameResultTransaction(ctx context.Context, txn *sqlx.Tx, userID int, gameResult *entity.GameResult, balance float64) error {
-
+// persistGameResultTransaction persists the game result transaction along with the wallet it resulted in,
+// and its ledger postings, moving the user wallet accounts from before to after against the counterparty account
+func (dm *accountDAO) persistGameResultTransaction(ctx context.Context, txn *sqlx.Tx, gameResult *entity.GameResult, before, after entity.Wallet, counterparty entity.LedgerAccount) error {
 	id, err := dm.querier.InsertGameResult(ctx, *txn, *gameResult)
 	if err != nil {
 		return fmt.Errorf("inserting game result: %w", err)
 	}
 	gameResult.ID = id
 
-	if err := dm.querier.UpdateUserBalance(ctx, *txn, userID, balance); err != nil {
-		return fmt.Errorf("updating user balance: %w", err)
+	postings := entity.LedgerPostings(*gameResult, before, after, counterparty)
+	if err := dm.querier.InsertLedgerPostings(ctx, *txn, postings); err != nil {
+		return fmt.Errorf("inserting ledger postings: %w", err)
+	}
+
+	if err := dm.querier.UpdateWallet(ctx, *txn, after); err != nil {
+		return fmt.Errorf("updating wallet: %w", err)
 	}
 
 	return nil
 }
 
+// CreateUser creates a new active user, with a zero balance
+// It returns the created user
+func (dm *accountDAO) CreateUser(ctx context.Context) (_ *entity.User, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.CreateUser")
+	defer func() { tracing.End(span, err) }()
+
+	user := entity.User{
+		Status:    entity.UserStatusActive,
+		CreatedAt: time.Now(),
+	}
+
+	id, err := dm.querier.InsertUser(ctx, user)
+	if err != nil {
+		slog.ErrorContext(ctx, "error creating user", logging.Error(err))
+		return nil, err
+	}
+	user.ID = id
+
+	return &user, nil
+}
+
 // RetrieveUser returns the user with the given ID
-func (dm *accountDAO) RetrieveUser(ctx context.Context, userID int) (*entity.User, error) {
+func (dm *accountDAO) RetrieveUser(ctx context.Context, userID int) (_ *entity.User, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RetrieveUser", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
 
 	user, err := dm.querier.SelectUser(ctx, userID)
 	if err != nil {
-		log.Printf("error locating user: %v", err)
+		slog.ErrorContext(ctx, "error locating user", logging.Error(err))
 		return nil, err
 	}
 	if user == nil {
@@ -133,3 +466,132 @@ func (dm *accountDAO) RetrieveUser(ctx context.Context, userID int) (*entity.Use
 
 	return user, nil
 }
+
+// RetrieveWallets returns the wallets of the user, ordered by currency
+// It returns an error if the user does not exist
+func (dm *accountDAO) RetrieveWallets(ctx context.Context, userID int) (_ []entity.Wallet, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RetrieveWallets", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := dm.RetrieveUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	wallets, err := dm.querier.SelectWallets(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing wallets", logging.Error(err))
+		return nil, err
+	}
+
+	return wallets, nil
+}
+
+// RetrieveWallet returns the wallet of the user in the currency, empty when the user has none yet
+// It returns an error if the currency is unknown or the user does not exist
+func (dm *accountDAO) RetrieveWallet(ctx context.Context, userID int, currency entity.Currency) (_ *entity.Wallet, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RetrieveWallet", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	if !currency.Known() {
+		return nil, entity.ErrUnknownCurrency
+	}
+
+	wallets, err := dm.RetrieveWallets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wallet := range wallets {
+		if wallet.Currency == currency {
+			return &wallet, nil
+		}
+	}
+	return &entity.Wallet{UserID: userID, Currency: currency}, nil
+}
+
+// ListGameResults returns a page of game results of the given user, newest-first
+// It returns an error if the user does not exist
+func (dm *accountDAO) ListGameResults(ctx context.Context, filter entity.GameResultFilter) (_ *entity.GameResultPage, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.ListGameResults", tracing.UserID(filter.UserID))
+	defer func() { tracing.End(span, err) }()
+
+	if filter.Limit <= 0 {
+		return nil, entity.ErrInvalidPageLimit
+	}
+
+	if _, err := dm.RetrieveUser(ctx, filter.UserID); err != nil {
+		return nil, err
+	}
+
+	// Fetch one extra game result to find out whether there is a next page
+	pageLimit := filter.Limit
+	filter.Limit++
+
+	gameResults, err := dm.querier.SelectGameResults(ctx, filter)
+	if err != nil {
+		slog.ErrorContext(ctx, "error listing game results", logging.Error(err))
+		return nil, err
+	}
+
+	page := entity.GameResultPage{GameResults: gameResults}
+	if len(gameResults) > pageLimit {
+		page.GameResults = gameResults[:pageLimit]
+
+		last := page.GameResults[pageLimit-1]
+		page.NextCursor = &entity.GameResultCursor{
+			CreatedAt: last.CreatedAt,
+			ID:        last.ID,
+		}
+	}
+
+	return &page, nil
+}
+
+// UpdateUserStatus moves the user to the given status
+// Setting the status the user already has changes nothing
+// It returns the updated user
+// It returns an error if the user does not exist or can not be moved to the given status
+func (dm *accountDAO) UpdateUserStatus(ctx context.Context, userID int, status entity.UserStatus) (_ *entity.User, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.UpdateUserStatus", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	var user *entity.User
+
+	// Lock the user row, so that no transaction is recorded while the status changes
+	err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+		user, err = dm.querier.SelectUserForUpdate(ctx, *txn, userID)
+		if err != nil {
+			slog.ErrorContext(ctx, "error locking user", logging.Error(err))
+			return err
+		}
+		if user == nil {
+			return entity.ErrUserNotFound
+		}
+
+		if user.Status == status {
+			return nil
+		}
+		if !user.Status.CanTransitionTo(status) {
+			return entity.ErrInvalidUserStatusTransition
+		}
+
+		if err := dm.querier.UpdateUserStatus(ctx, *txn, userID, status); err != nil {
+			return fmt.Errorf("updating user status: %w", err)
+		}
+		user.Status = status
+
+		return nil
+	})
+	if err != nil {
+		for _, userErr := range []error{entity.ErrUserNotFound, entity.ErrInvalidUserStatusTransition} {
+			if errors.Is(err, userErr) {
+				return nil, userErr
+			}
+		}
+
+		slog.ErrorContext(ctx, "error performing user status db transaction", logging.Error(err))
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -2,56 +2,63 @@ package dao
 
 import (
 	"context"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/ildomm/account-balance-manager/database"
 	"github.com/ildomm/account-balance-manager/entity"
 	"github.com/ildomm/account-balance-manager/test_helpers"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateGameResultConcurrentOnMock(t *testing.T) {
 	databaseMock := test_helpers.NewDatabaseMock()
 	ctx := context.Background()
 
-	instance := NewAccountDAO(databaseMock)
+	// Two DAO instances sharing the same database, as two running processes would
+	instances := []*accountDAO{NewAccountDAO(databaseMock), NewAccountDAO(databaseMock)}
 
 	userID := 1
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(10000) // 100.00
 	transactionSource := entity.TransactionSourceGame
 
 	// Mock successful interactions
-	databaseMock.On("TransactionIDExist", ctx, mock.Anything)
-	databaseMock.On("SelectUser", ctx, userID)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything)
 	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	databaseMock.On("InsertGameResult", ctx, mock.Anything, mock.Anything)
-	databaseMock.On("UpdateUserBalance", ctx, mock.Anything, userID, mock.Anything)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
 
 	// Give to the mock a user with a balance of 0
 	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error {
-		databaseMock.UpdateUserBalance(ctx, *txn, userID, 0)
+		databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency})
 		return nil
 	})
 
 	// Inject many game results
 	toInjectTotalEntries := [1000]int{} //nolint:all
 	totalInjected := len(toInjectTotalEntries)
-	expectedBalance := amount * float64(totalInjected)
+	expectedBalance := amount * entity.Money(totalInjected)
 
 	wg := sync.WaitGroup{}
-	for range toInjectTotalEntries {
+	for i := range toInjectTotalEntries {
 		wg.Add(1)
 
-		// A go routine for each game result
-		go func() {
+		// A go routine for each game result, spread over both instances
+		go func(instance *accountDAO) {
 			defer wg.Done()
-			_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, uuid.New().String())
+			_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, uuid.New().String(), nil)
 			assert.NoError(t, err)
-		}()
+		}(instances[i%len(instances)])
 	}
 
 	// Wait for all workers to complete processing
@@ -64,6 +71,7 @@ func TestCreateGameResultConcurrentOnMock(t *testing.T) {
 	assert.Equal(t, databaseMock.GameCount(), totalInjected)
 
 	// Compare the use balance
+	databaseMock.On("SelectUser", mock.Anything, userID)
 	user, err := databaseMock.SelectUser(ctx, userID)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBalance, user.Balance)
@@ -73,15 +81,16 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 	databaseMock := test_helpers.NewDatabaseMock()
 	ctx := context.Background()
 
-	instance := NewAccountDAO(databaseMock)
+	// Two DAO instances sharing the same database, as two running processes would
+	instances := []*accountDAO{NewAccountDAO(databaseMock), NewAccountDAO(databaseMock)}
 
 	// Mock parameters
 	userID := 1
-	amountPerIteration := 100.0
+	amountPerIteration := entity.Money(10000) // 100.00
 	transactionSource := entity.TransactionSourceGame
 
 	// The user must finish with a balance as it started
-	initialBalance := 10000.0
+	initialBalance := entity.Money(1000000) // 10000.00
 	finalBalance := initialBalance
 
 	// Total to inject of game results
@@ -90,44 +99,46 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 	totalInjected := len(toInjectTotalEntries)
 
 	// Mock successful interactions
-	databaseMock.On("TransactionIDExist", ctx, mock.Anything)
-	databaseMock.On("SelectUser", ctx, userID)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything)
 	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	databaseMock.On("InsertGameResult", ctx, mock.Anything, mock.Anything)
-	databaseMock.On("UpdateUserBalance", ctx, mock.Anything, userID, mock.Anything).Times(201) // ( toInjectTotalEntries * 2 ) + 1
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything).Times(201) // ( toInjectTotalEntries * 2 ) + 1
 
 	// Give to the mock a user with a balance of 1000
 	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error {
 
 		// Must start with some balance, unless the user will have a negative balance for the first
 		// entity.GameStatusLose hit
-		databaseMock.UpdateUserBalance(ctx, *txn, userID, initialBalance)
+		databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: initialBalance})
 		return nil
 	})
 
 	wg := sync.WaitGroup{}
-	for range toInjectTotalEntries {
+	for i := range toInjectTotalEntries {
 		wg.Add(1)
 
-		// A go routine for each game result
-		go func() {
+		// A go routine for each game result, spread over both instances
+		go func(instance *accountDAO) {
 			defer wg.Done()
 
-			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, amountPerIteration, transactionSource, uuid.New().String())
+			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, amountPerIteration, entity.DefaultCurrency, transactionSource, uuid.New().String(), nil)
 			assert.NoError(t, err)
-		}()
+		}(instances[i%len(instances)])
 	}
 
 	// Even if all LOST operations ran first, the balance will be 0, never negative
-	for range toInjectTotalEntries {
+	for i := range toInjectTotalEntries {
 		wg.Add(1)
 
-		// A go routine for each game result
-		go func() {
+		// A go routine for each game result, spread over both instances
+		go func(instance *accountDAO) {
 			defer wg.Done()
-			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusLose, amountPerIteration, transactionSource, uuid.New().String())
+			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusLose, amountPerIteration, entity.DefaultCurrency, transactionSource, uuid.New().String(), nil)
 			assert.NoError(t, err)
-		}()
+		}(instances[(i+1)%len(instances)])
 	}
 
 	// Wait for all workers to complete processing
@@ -140,7 +151,71 @@ func TestCreateGameResultConcurrentWinLostOnMock(t *testing.T) {
 	assert.Equal(t, databaseMock.GameCount(), totalInjected*2)
 
 	// Compare the use balance
+	databaseMock.On("SelectUser", mock.Anything, userID)
 	user, err := databaseMock.SelectUser(ctx, userID)
 	assert.NoError(t, err)
 	assert.Equal(t, finalBalance, user.Balance)
 }
+
+// TestCreateGameResultConcurrentOnDatabase runs two DAO instances, each one with its own connection pool,
+// against the same database, as two running processes would.
+func TestCreateGameResultConcurrentOnDatabase(t *testing.T) {
+	testDB := test_helpers.NewTestDatabase(t)
+	defer testDB.Close(t)
+
+	ctx := context.Background()
+	dbURL := testDB.ConnectionString(t) + "?sslmode=disable"
+
+	instances := []*accountDAO{}
+	for range 2 {
+		querier, err := database.NewPostgresQuerier(ctx, dbURL)
+		require.NoError(t, err)
+		defer querier.Close()
+
+		instances = append(instances, NewAccountDAO(querier))
+	}
+
+	// Mock parameters
+	userID := 1
+	amountPerIteration := entity.Money(1000) // 10.00
+	transactionSource := entity.TransactionSourceGame
+
+	// Total to inject of game results
+	// To perform 100 Wins and 100 Losses, the user starts with no balance
+	toInjectTotalEntries := [100]int{} //nolint:all
+	totalInjected := len(toInjectTotalEntries)
+
+	wg := sync.WaitGroup{}
+	negativeBalanceErrors := atomic.Int64{}
+	for i := range toInjectTotalEntries {
+		wg.Add(2)
+
+		go func(instance *accountDAO) {
+			defer wg.Done()
+			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, amountPerIteration, entity.DefaultCurrency, transactionSource, uuid.New().String(), nil)
+			assert.NoError(t, err)
+		}(instances[i%len(instances)])
+
+		// A loss might run before enough wins, then it is rejected instead of producing a negative balance
+		go func(instance *accountDAO) {
+			defer wg.Done()
+			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusLose, amountPerIteration, entity.DefaultCurrency, transactionSource, uuid.New().String(), nil)
+			if errors.Is(err, entity.ErrUserNegativeBalance) {
+				negativeBalanceErrors.Add(1)
+				return
+			}
+			assert.NoError(t, err)
+		}(instances[(i+1)%len(instances)])
+	}
+
+	// Wait for all workers to complete processing
+	wg.Wait()
+
+	// No update must be lost, whatever the instance that performed it
+	acceptedLosses := entity.Money(int64(totalInjected) - negativeBalanceErrors.Load())
+	expectedBalance := amountPerIteration*entity.Money(totalInjected) - amountPerIteration*acceptedLosses
+
+	user, err := instances[0].RetrieveUser(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, expectedBalance, user.Balance)
+}
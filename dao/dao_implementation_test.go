@@ -3,14 +3,25 @@ package dao
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/metrics"
 	"github.com/ildomm/account-balance-manager/test_helpers"
 )
 
@@ -21,37 +32,41 @@ func TestCreateGameResultOnSuccess(t *testing.T) {
 	// Mock data
 	userID := 1
 	gameStatus := entity.GameStatusWin
-	initialBalance := 100.0
-	amount := 100.0
+	initialBalance := entity.Money(10000) // 100.00
+	amount := entity.Money(10000)         // 100.00
 	finalBalance := initialBalance + amount
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	instance := NewAccountDAO(databaseMock)
 	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	databaseMock.On("UpdateUserBalance", ctx, mock.Anything, userID, mock.Anything)
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
 
 	// Create a fake user by forcing a balance over the Mock
 	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error {
-		databaseMock.UpdateUserBalance(ctx, *txn, 1, initialBalance)
+		databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: 1, Currency: entity.DefaultCurrency, Balance: initialBalance})
 
 		return nil
 	})
 
 	// Mock successful interactions
-	databaseMock.On("TransactionIDExist", ctx, transactionID)
-	databaseMock.On("SelectUser", ctx, userID)
-	databaseMock.On("InsertGameResult", ctx, mock.Anything, mock.Anything)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
 
-	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+	gameResult, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
 
 	assert.NoError(t, err, "CreateGameResult should not return an error")
+	assert.Equal(t, finalBalance, gameResult.BalanceAfter)
 	databaseMock.AssertExpectations(t)
 
 	// Count the game results
 	assert.Equal(t, databaseMock.GameCount(), 1, "There should be one game result")
 
 	// Check final balance
+	databaseMock.On("SelectUser", mock.Anything, userID)
 	user, err := databaseMock.SelectUser(ctx, userID)
 	assert.NoError(t, err)
 	assert.Equal(t, finalBalance, user.Balance)
@@ -65,19 +80,99 @@ func TestCreateGameResultOnTransactionIDExists(t *testing.T) {
 	ctx := context.Background()
 	userID := 1
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(10000) // 100.00
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "existing-transaction-id"
 
-	// Mock transaction ID already exists
-	databaseMock.On("TransactionIDExist", ctx, transactionID).Return(true, nil)
+	// Mock transaction ID already exists, with a different amount
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(&entity.GameResult{
+		ID:                1,
+		UserID:            userID,
+		GameStatus:        gameStatus,
+		TransactionSource: transactionSource,
+		TransactionID:     transactionID,
+		Amount:            amount + 1,
+	}, nil)
 
-	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
 
 	assert.EqualError(t, err, entity.ErrTransactionIdExists.Error(), "CreateGameResult should return ErrTransactionIdExists")
 	databaseMock.AssertExpectations(t)
 }
 
+func TestCreateGameResultOnReplay(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+
+	instance := NewAccountDAO(databaseMock)
+
+	ctx := context.Background()
+	userID := 1
+	gameStatus := entity.GameStatusLose
+	amount := entity.Money(10000) // 100.00
+	transactionSource := entity.TransactionSourceGame
+	transactionID := "retried-transaction-id"
+
+	// Mock transaction ID already recorded, with the same payload
+	original := &entity.GameResult{
+		ID:                1,
+		UserID:            userID,
+		GameStatus:        gameStatus,
+		TransactionSource: transactionSource,
+		TransactionID:     transactionID,
+		Amount:            amount,
+		Currency:          entity.DefaultCurrency,
+		BalanceAfter:      entity.Money(5000),
+	}
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(original, nil)
+
+	gameResult, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
+
+	// The original game result is returned, neither the balance nor the game results are touched
+	assert.NoError(t, err)
+	assert.Equal(t, original, gameResult)
+	assert.Equal(t, 0, databaseMock.GameCount())
+	databaseMock.AssertExpectations(t)
+}
+
+func TestCreateGameResultOnConcurrentReplay(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+
+	instance := NewAccountDAO(databaseMock)
+
+	ctx := context.Background()
+	userID := 1
+	gameStatus := entity.GameStatusWin
+	amount := entity.Money(10000) // 100.00
+	transactionSource := entity.TransactionSourceGame
+	transactionID := "retried-transaction-id"
+
+	// Mock transaction ID recorded by a concurrent request, between the lookup and the insert
+	original := &entity.GameResult{
+		ID:                1,
+		UserID:            userID,
+		GameStatus:        gameStatus,
+		TransactionSource: transactionSource,
+		TransactionID:     transactionID,
+		Amount:            amount,
+		Currency:          entity.DefaultCurrency,
+		BalanceAfter:      amount,
+	}
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil).Once()
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, entity.DefaultCurrency).Return(nil, nil)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrTransactionIdExists)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(original, nil).Once()
+
+	gameResult, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, original, gameResult)
+	databaseMock.AssertExpectations(t)
+}
+
 func TestCreateGameResultOnUserNotFound(t *testing.T) {
 	databaseMock := test_helpers.NewDatabaseMock()
 
@@ -86,15 +181,16 @@ func TestCreateGameResultOnUserNotFound(t *testing.T) {
 	ctx := context.Background()
 	userID := 1
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(10000) // 100.00
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock user not found
-	databaseMock.On("TransactionIDExist", ctx, transactionID).Return(false, nil)
-	databaseMock.On("SelectUser", ctx, userID).Return(nil, entity.ErrUserNotFound)
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(nil, nil)
 
-	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
 
 	assert.EqualError(t, err, entity.ErrUserNotFound.Error(), "CreateGameResult should return ErrUserNotFound")
 	databaseMock.AssertExpectations(t)
@@ -108,23 +204,87 @@ func TestCreateGameResultOnInsufficientBalance(t *testing.T) {
 	ctx := context.Background()
 	userID := 1
 	gameStatus := entity.GameStatusLose // Assuming this triggers the balance check
-	amount := 300.0                     // Assuming the user's balance is less than this amount
+	amount := entity.Money(30000)       // Assuming the user's balance is less than this amount
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock user with insufficient balance
-	databaseMock.On("TransactionIDExist", ctx, transactionID).Return(false, nil)
-	databaseMock.On("SelectUser", ctx, userID).Return(&entity.User{
-		ID:      userID,
-		Balance: 200.0,
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, entity.DefaultCurrency).Return(&entity.Wallet{
+		UserID:   userID,
+		Currency: entity.DefaultCurrency,
+		Balance:  entity.Money(20000),
 	}, nil)
 
-	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
 
 	assert.EqualError(t, err, entity.ErrUserNegativeBalance.Error(), "CreateGameResult should return ErrUserNegativeBalance")
 	databaseMock.AssertExpectations(t)
 }
 
+func TestCreateGameResultOnBalanceTooHigh(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+
+	instance := NewAccountDAO(databaseMock)
+
+	ctx := context.Background()
+	userID := 1
+	transactionID := "unique-transaction-id"
+
+	// Mock user whose balance the win would push over what its column can store
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, entity.DefaultCurrency).Return(&entity.Wallet{
+		UserID:   userID,
+		Currency: entity.DefaultCurrency,
+		Balance:  entity.MaxMoney - entity.Money(100),
+	}, nil)
+
+	_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, entity.Money(200), entity.DefaultCurrency, entity.TransactionSourceGame, transactionID, nil)
+
+	assert.ErrorIs(t, err, entity.ErrBalanceTooHigh)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestCreateGameResultOnInactiveUser(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      entity.UserStatus
+		expectedErr error
+	}{
+		{"Frozen", entity.UserStatusFrozen, entity.ErrUserFrozen},
+		{"Closed", entity.UserStatusClosed, entity.ErrUserClosed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			databaseMock := test_helpers.NewDatabaseMock()
+
+			instance := NewAccountDAO(databaseMock)
+
+			ctx := context.Background()
+			userID := 1
+			transactionID := "unique-transaction-id"
+
+			databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+			databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil)
+			databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{
+				ID:     userID,
+				Status: tc.status,
+			}, nil)
+
+			_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, entity.Money(100), entity.DefaultCurrency, entity.TransactionSourceGame, transactionID, nil)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			databaseMock.AssertNotCalled(t, "InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+			databaseMock.AssertExpectations(t)
+		})
+	}
+}
+
 func TestCreateGameResultOnDatabaseError(t *testing.T) {
 	databaseMock := test_helpers.NewDatabaseMock()
 
@@ -133,20 +293,22 @@ func TestCreateGameResultOnDatabaseError(t *testing.T) {
 	ctx := context.Background()
 	userID := 1
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(10000) // 100.00
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock successful interactions except for InsertGameResult
-	databaseMock.On("TransactionIDExist", ctx, transactionID).Return(false, nil)
-	databaseMock.On("SelectUser", ctx, userID).Return(&entity.User{
-		ID:      userID,
-		Balance: 200.0,
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, entity.DefaultCurrency).Return(&entity.Wallet{
+		UserID:   userID,
+		Currency: entity.DefaultCurrency,
+		Balance:  entity.Money(20000),
 	}, nil)
 	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	databaseMock.On("InsertGameResult", ctx, mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("database error"))
 
-	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+	_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
 
 	assert.EqualError(t, err, entity.ErrCreatingGameResult.Error(), "CreateGameResult should return ErrCreatingGameResult")
 	databaseMock.AssertExpectations(t)
@@ -160,31 +322,33 @@ func TestCreateGameResultOnSuccessMultiple(t *testing.T) {
 
 	userID := 1
 	gameStatus := entity.GameStatusWin
-	amount := 100.0
+	amount := entity.Money(10000) // 100.00
 	transactionSource := entity.TransactionSourceGame
 	transactionID := "unique-transaction-id"
 
 	// Mock successful interactions
-	databaseMock.On("TransactionIDExist", ctx, mock.Anything)
-	databaseMock.On("SelectUser", ctx, userID).Return() // no fake results
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return() // no fake results
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything)
 	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
-	databaseMock.On("InsertGameResult", ctx, mock.Anything, mock.Anything)
-	databaseMock.On("UpdateUserBalance", ctx, mock.Anything, userID, mock.Anything)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
 
 	// Give to the mock a user with a balance of 0
 	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error {
-		databaseMock.UpdateUserBalance(ctx, *txn, userID, 0)
+		databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency})
 		return nil
 	})
 
 	// Inject many game results
 	toInjectTotalEntries := [1000]int{} //nolint:all
 	totalInjected := len(toInjectTotalEntries)
-	expectedBalance := amount * float64(totalInjected)
+	expectedBalance := amount * entity.Money(totalInjected)
 
 	for range toInjectTotalEntries {
 		transactionID = uuid.New().String()
-		_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+		_, err := instance.CreateGameResult(ctx, userID, gameStatus, amount, entity.DefaultCurrency, transactionSource, transactionID, nil)
 		assert.NoError(t, err)
 	}
 
@@ -195,11 +359,71 @@ func TestCreateGameResultOnSuccessMultiple(t *testing.T) {
 	assert.Equal(t, databaseMock.GameCount(), totalInjected)
 
 	// Compare the use balance
+	databaseMock.On("SelectUser", mock.Anything, userID)
 	user, err := databaseMock.SelectUser(ctx, userID)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedBalance, user.Balance)
 }
 
+func TestCreateAdjustmentOnSuccess(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	userID := 1
+	initialBalance := entity.Money(10000) // 100.00
+
+	instance := NewAccountDAO(databaseMock)
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
+
+	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: initialBalance})
+		return nil
+	})
+
+	// A credit
+	credit, err := instance.CreateAdjustment(ctx, userID, entity.Money(2500), entity.DefaultCurrency, "goodwill gesture")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.GameStatusWin, credit.GameStatus)
+	assert.Equal(t, entity.TransactionSourceServer, credit.TransactionSource)
+	assert.Equal(t, entity.Money(2500), credit.Amount)
+	assert.Equal(t, "goodwill gesture", *credit.Reason)
+	assert.Equal(t, entity.Money(12500), credit.BalanceAfter)
+	assert.True(t, strings.HasPrefix(credit.TransactionID, "adjustment-"))
+
+	// A debit
+	debit, err := instance.CreateAdjustment(ctx, userID, entity.Money(-500), entity.DefaultCurrency, "duplicated payout")
+	assert.NoError(t, err)
+	assert.Equal(t, entity.GameStatusLose, debit.GameStatus)
+	assert.Equal(t, entity.Money(500), debit.Amount)
+	assert.Equal(t, entity.Money(12000), debit.BalanceAfter)
+	assert.NotEqual(t, credit.TransactionID, debit.TransactionID)
+
+	// Over the balance
+	_, err = instance.CreateAdjustment(ctx, userID, entity.Money(-20000), entity.DefaultCurrency, "chargeback")
+	assert.ErrorIs(t, err, entity.ErrUserNegativeBalance)
+
+	assert.Equal(t, 2, databaseMock.GameCount())
+}
+
+func TestCreateAdjustmentOnInvalidInput(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.CreateAdjustment(context.Background(), 1, entity.Money(0), entity.DefaultCurrency, "nothing")
+	assert.ErrorIs(t, err, entity.ErrInvalidAmount)
+
+	_, err = instance.CreateAdjustment(context.Background(), 1, entity.Money(100), entity.DefaultCurrency, "  ")
+	assert.ErrorIs(t, err, entity.ErrMissingReason)
+
+	databaseMock.AssertNotCalled(t, "WithTransaction", mock.Anything, mock.Anything)
+}
+
 func TestRetrieveUserOnSuccess(t *testing.T) {
 	databaseMock := test_helpers.NewDatabaseMock()
 	ctx := context.Background()
@@ -209,10 +433,10 @@ func TestRetrieveUserOnSuccess(t *testing.T) {
 	userID := 1
 	user := &entity.User{
 		ID:      userID,
-		Balance: 100.0,
+		Balance: entity.Money(10000),
 	}
 
-	databaseMock.On("SelectUser", ctx, userID).Return(user, nil)
+	databaseMock.On("SelectUser", mock.Anything, userID).Return(user, nil)
 
 	retrievedUser, err := instance.RetrieveUser(ctx, userID)
 
@@ -229,7 +453,7 @@ func TestRetrieveUserOnUserNotFound(t *testing.T) {
 
 	userID := 1
 
-	databaseMock.On("SelectUser", ctx, userID).Return(nil, entity.ErrUserNotFound)
+	databaseMock.On("SelectUser", mock.Anything, userID).Return(nil, entity.ErrUserNotFound)
 
 	_, err := instance.RetrieveUser(ctx, userID)
 
@@ -246,10 +470,543 @@ func TestRetrieveUserOnDatabaseError(t *testing.T) {
 	userID := 1
 	databaseError := errors.New("database error")
 
-	databaseMock.On("SelectUser", ctx, userID).Return(nil, databaseError)
+	databaseMock.On("SelectUser", mock.Anything, userID).Return(nil, databaseError)
 
 	_, err := instance.RetrieveUser(ctx, userID)
 
 	assert.EqualError(t, err, databaseError.Error())
 	databaseMock.AssertExpectations(t)
 }
+
+func TestRetrieveUserOnDatabaseErrorLogsRequestID(t *testing.T) {
+	logBuf, restoreLog := test_helpers.CaptureOutput()
+	defer restoreLog()
+
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+
+	instance := NewAccountDAO(databaseMock)
+
+	databaseMock.On("SelectUser", mock.Anything, 1).Return(nil, errors.New("database error"))
+
+	_, err := instance.RetrieveUser(ctx, 1)
+
+	assert.Error(t, err)
+	assert.Contains(t, logBuf.String(), `"level":"ERROR"`)
+	assert.Contains(t, logBuf.String(), `"error":"database error"`)
+	assert.Contains(t, logBuf.String(), `"request_id":"req-123"`)
+}
+
+func TestCreateUserOnSuccess(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	databaseMock.On("InsertUser", mock.Anything, mock.Anything).Return(10, nil)
+
+	user, err := instance.CreateUser(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, user.ID)
+	assert.Equal(t, entity.Money(0), user.Balance)
+	assert.Equal(t, entity.UserStatusActive, user.Status)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestCreateUserOnDatabaseError(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	databaseError := errors.New("database error")
+	databaseMock.On("InsertUser", mock.Anything, mock.Anything).Return(0, databaseError)
+
+	_, err := instance.CreateUser(ctx)
+
+	assert.ErrorIs(t, err, databaseError)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestUpdateUserStatusOnSuccess(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	userID := 1
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{
+		ID:      userID,
+		Balance: entity.Money(10000),
+		Status:  entity.UserStatusActive,
+	}, nil)
+	databaseMock.On("UpdateUserStatus", mock.Anything, mock.Anything, userID, entity.UserStatusFrozen).Return(nil)
+
+	user, err := instance.UpdateUserStatus(ctx, userID, entity.UserStatusFrozen)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.UserStatusFrozen, user.Status)
+	assert.Equal(t, entity.Money(10000), user.Balance)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestUpdateUserStatusOnSameStatus(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	userID := 1
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{
+		ID:     userID,
+		Status: entity.UserStatusFrozen,
+	}, nil)
+
+	user, err := instance.UpdateUserStatus(ctx, userID, entity.UserStatusFrozen)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.UserStatusFrozen, user.Status)
+	databaseMock.AssertNotCalled(t, "UpdateUserStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestUpdateUserStatusOnErrors(t *testing.T) {
+	databaseError := errors.New("database error")
+
+	tests := []struct {
+		name        string
+		user        *entity.User
+		selectErr   error
+		status      entity.UserStatus
+		expectedErr error
+	}{
+		{
+			name:        "User Not Found",
+			status:      entity.UserStatusFrozen,
+			expectedErr: entity.ErrUserNotFound,
+		},
+		{
+			name:        "Closed User Reopened",
+			user:        &entity.User{ID: 1, Status: entity.UserStatusClosed},
+			status:      entity.UserStatusActive,
+			expectedErr: entity.ErrInvalidUserStatusTransition,
+		},
+		{
+			name:        "Database Error",
+			selectErr:   databaseError,
+			status:      entity.UserStatusFrozen,
+			expectedErr: databaseError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			databaseMock := test_helpers.NewDatabaseMock()
+			ctx := context.Background()
+
+			instance := NewAccountDAO(databaseMock)
+
+			databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+			if tc.user != nil {
+				databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, 1).Return(tc.user, nil)
+			} else {
+				databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, 1).Return(nil, tc.selectErr)
+			}
+
+			_, err := instance.UpdateUserStatus(ctx, 1, tc.status)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			databaseMock.AssertNotCalled(t, "UpdateUserStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			databaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListGameResultsOnSuccess(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	userID := 1
+	createdAt := time.Now()
+	gameResults := []entity.GameResult{
+		{ID: 3, UserID: userID, GameStatus: entity.GameStatusWin, Amount: 10, CreatedAt: createdAt},
+		{ID: 2, UserID: userID, GameStatus: entity.GameStatusLose, Amount: 20, CreatedAt: createdAt.Add(-time.Minute)},
+		{ID: 1, UserID: userID, GameStatus: entity.GameStatusWin, Amount: 30, CreatedAt: createdAt.Add(-time.Hour)},
+	}
+
+	filter := entity.GameResultFilter{UserID: userID, Limit: 2}
+
+	// One extra game result is requested, to detect the next page
+	expectedFilter := filter
+	expectedFilter.Limit = 3
+
+	databaseMock.On("SelectUser", mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectGameResults", mock.Anything, expectedFilter).Return(gameResults, nil)
+
+	page, err := instance.ListGameResults(ctx, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, gameResults[:2], page.GameResults)
+	assert.Equal(t, &entity.GameResultCursor{CreatedAt: gameResults[1].CreatedAt, ID: gameResults[1].ID}, page.NextCursor)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestListGameResultsOnLastPage(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	userID := 1
+	gameResults := []entity.GameResult{
+		{ID: 1, UserID: userID, GameStatus: entity.GameStatusWin, Amount: 30, CreatedAt: time.Now()},
+	}
+
+	databaseMock.On("SelectUser", mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectGameResults", mock.Anything, mock.Anything).Return(gameResults, nil)
+
+	page, err := instance.ListGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, gameResults, page.GameResults)
+	assert.Nil(t, page.NextCursor)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestListGameResultsOnUserNotFound(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	userID := 1
+
+	databaseMock.On("SelectUser", mock.Anything, userID).Return(nil, nil)
+
+	_, err := instance.ListGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 2})
+
+	assert.EqualError(t, err, entity.ErrUserNotFound.Error())
+	databaseMock.AssertExpectations(t)
+}
+
+func TestListGameResultsOnInvalidLimit(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.ListGameResults(ctx, entity.GameResultFilter{UserID: 1, Limit: 0})
+
+	assert.EqualError(t, err, entity.ErrInvalidPageLimit.Error())
+	databaseMock.AssertExpectations(t)
+}
+
+func TestReverseGameResultOnSuccess(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	instance := NewAccountDAO(databaseMock)
+
+	userID := 1
+	transactionID := "lost-transaction-id"
+	initialBalance := entity.Money(5000) // 50.00
+	original := &entity.GameResult{
+		ID:                1,
+		UserID:            userID,
+		GameStatus:        entity.GameStatusLose,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     transactionID,
+		Amount:            entity.Money(2550), // 25.50
+		Currency:          entity.DefaultCurrency,
+	}
+
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(original, nil)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, entity.ReversalTransactionID(transactionID)).Return(nil, nil)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, entity.DefaultCurrency).Return(&entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: initialBalance}, nil)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.MatchedBy(func(wallet entity.Wallet) bool {
+		return wallet.UserID == userID && wallet.Currency == entity.DefaultCurrency && wallet.Balance == initialBalance+original.Amount
+	}))
+
+	reversal, err := instance.ReverseGameResult(ctx, userID, transactionID, entity.TransactionSourceGame)
+
+	assert.NoError(t, err)
+	assert.Equal(t, entity.GameStatusWin, reversal.GameStatus)
+	assert.Equal(t, original.Amount, reversal.Amount)
+	assert.Equal(t, entity.TransactionSourceGame, reversal.TransactionSource)
+	assert.Equal(t, entity.ReversalTransactionID(transactionID), reversal.TransactionID)
+	assert.Equal(t, &transactionID, reversal.ReversesTransactionID)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestReverseGameResultOnErrors(t *testing.T) {
+	userID := 1
+	transactionID := "won-transaction-id"
+	won := &entity.GameResult{
+		ID:                1,
+		UserID:            userID,
+		GameStatus:        entity.GameStatusWin,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     transactionID,
+		Amount:            entity.Money(10000), // 100.00
+		Currency:          entity.DefaultCurrency,
+	}
+
+	type testCase struct {
+		name          string
+		mockSetup     func(databaseMock *test_helpers.DatabaseMock)
+		expectedError error
+	}
+
+	testCases := []testCase{
+		{
+			name: "Transaction Not Found",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, nil)
+			},
+			expectedError: entity.ErrTransactionNotFound,
+		},
+		{
+			name: "Transaction Of Another User",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				other := *won
+				other.UserID = userID + 1
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(&other, nil)
+			},
+			expectedError: entity.ErrTransactionNotFound,
+		},
+		{
+			name: "Reversal Of A Reversal",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				reversal := *won
+				reversed := "another-transaction-id"
+				reversal.ReversesTransactionID = &reversed
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(&reversal, nil)
+			},
+			expectedError: entity.ErrTransactionNotReversible,
+		},
+		{
+			name: "Transaction Of Another Source",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				// A game key can not reverse a payment withdrawal to credit the balance
+				withdrawal := *won
+				withdrawal.GameStatus = entity.GameStatusLose
+				withdrawal.TransactionSource = entity.TransactionSourcePayment
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(&withdrawal, nil)
+			},
+			expectedError: entity.ErrReversalSourceMismatch,
+		},
+		{
+			name: "Already Reversed",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(won, nil)
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, entity.ReversalTransactionID(transactionID)).Return(&entity.GameResult{TransactionID: entity.ReversalTransactionID(transactionID)}, nil)
+			},
+			expectedError: entity.ErrTransactionAlreadyReversed,
+		},
+		{
+			name: "Negative Balance",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(won, nil)
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, entity.ReversalTransactionID(transactionID)).Return(nil, nil)
+				databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Return(&entity.User{ID: userID}, nil)
+				databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, entity.DefaultCurrency).Return(&entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: entity.Money(9999)}, nil)
+			},
+			expectedError: entity.ErrUserNegativeBalance,
+		},
+		{
+			name: "Database Error",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, transactionID).Return(nil, errors.New("database error"))
+			},
+			expectedError: entity.ErrCreatingGameResult,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			databaseMock := test_helpers.NewDatabaseMock()
+			databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+			tc.mockSetup(databaseMock)
+
+			instance := NewAccountDAO(databaseMock)
+
+			_, err := instance.ReverseGameResult(context.Background(), userID, transactionID, entity.TransactionSourceGame)
+
+			assert.EqualError(t, err, tc.expectedError.Error())
+			databaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateGameResultMetrics(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+
+	userID := 1
+	serviceMetrics := metrics.NewMetrics()
+	instance := NewAccountDAO(databaseMock)
+	instance.WithMetrics(serviceMetrics)
+
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything)
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything)
+
+	// Create a fake user by forcing a balance over the Mock
+	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+		return databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: entity.Money(1000)})
+	})
+
+	_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, entity.Money(250), entity.DefaultCurrency, entity.TransactionSourceGame, "win-1", nil)
+	assert.NoError(t, err)
+	_, err = instance.CreateGameResult(ctx, userID, entity.GameStatusWin, entity.Money(250), entity.DefaultCurrency, entity.TransactionSourceGame, "win-1", nil)
+	assert.NoError(t, err)
+	_, err = instance.CreateGameResult(ctx, userID, entity.GameStatusLose, entity.Money(5000), entity.DefaultCurrency, entity.TransactionSourceGame, "lose-1", nil)
+	assert.ErrorIs(t, err, entity.ErrUserNegativeBalance)
+
+	rr := httptest.NewRecorder()
+	serviceMetrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rr.Body.String()
+	assert.Contains(t, body, `abm_game_result_operations_total{operation="create_game_result",outcome="success"} 1`)
+	assert.Contains(t, body, `abm_game_result_operations_total{operation="create_game_result",outcome="replayed"} 1`)
+	assert.Contains(t, body, `abm_game_result_operations_total{operation="create_game_result",outcome="negative_balance"} 1`)
+	assert.Contains(t, body, `abm_game_result_amount_total{currency="EUR",source="game",state="win"} 2.5`)
+	assert.NotContains(t, body, `state="lose"`)
+}
+
+func TestCreateGameResultTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	databaseMock := test_helpers.NewDatabaseMock()
+	ctx := context.Background()
+	userID := 1
+
+	instance := NewAccountDAO(databaseMock)
+
+	// The querier must be called within the DAO span
+	withSpan := mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanFromContext(ctx).SpanContext().IsValid()
+	})
+	databaseMock.On("WithTransaction", withSpan, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("SelectGameResultByTransactionID", withSpan, mock.Anything, "unique-transaction-id").Return(nil, nil)
+	databaseMock.On("SelectUserForUpdate", withSpan, mock.Anything, userID).Return(nil, nil)
+
+	_, err := instance.CreateGameResult(ctx, userID, entity.GameStatusWin, entity.Money(100), entity.DefaultCurrency, entity.TransactionSourceGame, "unique-transaction-id", nil)
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "accountDAO.CreateGameResult", spans[0].Name())
+	assert.Len(t, spans[0].Events(), 1, "the error should be recorded")
+	databaseMock.AssertExpectations(t)
+}
+
+func TestCreateGameResultInCurrencies(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	databaseMock.On("SelectWallets", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	// Each currency has its own balance
+	win, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(150000), "JPY", entity.TransactionSourceGame, "win-jpy", nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Currency("JPY"), win.Currency)
+	assert.Equal(t, entity.Money(150000), win.BalanceAfter)
+
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(100), "USD", entity.TransactionSourceGame, "lose-usd", nil)
+	assert.ErrorIs(t, err, entity.ErrUserNegativeBalance)
+
+	lose, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(2500), entity.DefaultCurrency, entity.TransactionSourceGame, "lose-eur", nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(7500), lose.BalanceAfter)
+
+	wallets, err := instance.RetrieveWallets(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, wallets, 2)
+	assert.Equal(t, entity.DefaultCurrency, wallets[0].Currency)
+	assert.Equal(t, entity.Money(7500), wallets[0].Balance)
+	assert.Equal(t, entity.Currency("JPY"), wallets[1].Currency)
+	assert.Equal(t, entity.Money(150000), wallets[1].Balance)
+
+	// The user balance is the one in the default currency
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(7500), user.Balance)
+}
+
+func TestCreateGameResultOnInvalidCurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   entity.Money
+		currency entity.Currency
+		expected error
+	}{
+		{"Unknown currency", entity.Money(100), "XYZ", entity.ErrUnknownCurrency},
+		{"Missing currency", entity.Money(100), "", entity.ErrUnknownCurrency},
+		{"Cents of a currency without minor units", entity.Money(150), "JPY", entity.ErrInvalidAmount},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+			instance := NewAccountDAO(databaseMock)
+
+			_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, tc.amount, tc.currency, entity.TransactionSourceGame, "tx-1", nil)
+
+			assert.ErrorIs(t, err, tc.expected)
+			databaseMock.AssertNotCalled(t, "InsertGameResult", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRetrieveWalletOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	databaseMock.On("SelectWallets", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	wallet, err := instance.RetrieveWallet(ctx, 1, entity.DefaultCurrency)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), wallet.Balance)
+
+	// A missing wallet is empty
+	wallet, err = instance.RetrieveWallet(ctx, 1, "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, entity.Wallet{UserID: 1, Currency: "GBP"}, *wallet)
+}
+
+func TestRetrieveWalletOnErrors(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("SelectUser", mock.Anything, 1).Return(nil, nil)
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.RetrieveWallet(ctx, 1, "XYZ")
+	assert.ErrorIs(t, err, entity.ErrUnknownCurrency)
+
+	_, err = instance.RetrieveWallet(ctx, 1, entity.DefaultCurrency)
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+
+	_, err = instance.RetrieveWallets(ctx, 1)
+	assert.ErrorIs(t, err, entity.ErrUserNotFound)
+	databaseMock.AssertNotCalled(t, "SelectWallets", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,268 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/tracing"
+	"github.com/jmoiron/sqlx"
+)
+
+// holdErrors are the business errors returned as they are by the hold operations,
+// any other error is reported as entity.ErrCreatingHold
+var holdErrors = []error{
+	entity.ErrHoldNotFound,
+	entity.ErrHoldNotOpen,
+	entity.ErrHoldExpired,
+	entity.ErrHoldSourceMismatch,
+	entity.ErrTransactionIdExists,
+	entity.ErrUserNotFound,
+	entity.ErrUserFrozen,
+	entity.ErrUserClosed,
+	entity.ErrUserNegativeBalance,
+	entity.ErrBalanceTooHigh,
+	entity.ErrUnknownCurrency,
+	entity.ErrInvalidAmount,
+}
+
+// CreateHold reserves an amount of the user balance in the currency for an in-progress bet, until it expires
+// The amount is no longer available to lose game results, but the balance itself is unchanged
+// It returns the open hold
+// It returns an error if the user can not lose the amount
+func (dm *accountDAO) CreateHold(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, transactionSource entity.TransactionSource, expiresIn time.Duration) (_ *entity.Hold, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.CreateHold", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	if amount <= 0 || amount > entity.MaxMoney {
+		return nil, entity.ErrInvalidAmount
+	}
+	if expiresIn <= 0 || expiresIn > entity.MaxHoldExpiry {
+		return nil, entity.ErrInvalidHoldExpiry
+	}
+
+	now := time.Now()
+	hold := entity.Hold{
+		UserID:            userID,
+		Amount:            amount,
+		Currency:          currency,
+		Status:            entity.HoldStatusOpen,
+		TransactionSource: transactionSource,
+		ExpiresAt:         now.Add(expiresIn),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	// Perform the whole operation inside a db transaction
+	err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		// The user must be able to lose the amount, as if the hold was captured right away
+		if _, err := dm.validateTransaction(ctx, txn, userID, currency, entity.GameStatusLose, amount); err != nil {
+			return err
+		}
+
+		id, err := dm.querier.InsertHold(ctx, *txn, hold)
+		if err != nil {
+			return err
+		}
+		hold.ID = id
+
+		return nil
+	})
+	if err != nil {
+		return nil, dm.holdError(ctx, metrics.OperationCreateHold, err)
+	}
+
+	dm.metrics.ObserveGameResultOutcome(metrics.OperationCreateHold, metrics.OutcomeSuccess)
+	return &hold, nil
+}
+
+// RetrieveHold returns the hold of the user with the given ID, reporting it as expired once past its expiry
+func (dm *accountDAO) RetrieveHold(ctx context.Context, userID int, holdID int) (_ *entity.Hold, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RetrieveHold", tracing.UserID(userID), tracing.HoldID(holdID))
+	defer func() { tracing.End(span, err) }()
+
+	hold, err := dm.querier.SelectHold(ctx, holdID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error locating hold", logging.Error(err))
+		return nil, err
+	}
+	if hold == nil || hold.UserID != userID {
+		return nil, entity.ErrHoldNotFound
+	}
+
+	hold.Status = hold.StatusAt(time.Now())
+	return hold, nil
+}
+
+// CaptureHold turns the open hold into a lose game result of its amount, recorded under the given transaction ID
+// As any other lose game result, it draws from the cash and bonus balances and counts towards the wagering requirement
+// It returns the lose game result
+// Retrying the capture with the same transaction ID returns the original game result
+// It returns an error if the hold is not open anymore, or expired, or was created by another source
+func (dm *accountDAO) CaptureHold(ctx context.Context, userID int, holdID int, transactionID string, transactionSource entity.TransactionSource) (_ *entity.GameResult, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.CaptureHold", tracing.UserID(userID), tracing.HoldID(holdID), tracing.TransactionID(transactionID))
+	defer func() { tracing.End(span, err) }()
+
+	var gameResult, replayed *entity.GameResult
+
+	// Perform the whole operation inside a db transaction
+	err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+
+		// Lock the user first, as any other transaction, then the hold and its wallet
+		if _, err := dm.lockActiveUser(ctx, txn, userID); err != nil {
+			return err
+		}
+		hold, err := dm.lockHold(ctx, txn, userID, holdID, transactionSource)
+		if err != nil {
+			return err
+		}
+		wallet, err := dm.lockWallet(ctx, txn, userID, hold.Currency)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch hold.StatusAt(now) {
+		case entity.HoldStatusCaptured:
+			// A retried capture is answered with its original game result
+			if hold.TransactionID == nil || *hold.TransactionID != transactionID {
+				return entity.ErrHoldNotOpen
+			}
+			replayed, err = dm.querier.SelectGameResultByTransactionID(ctx, *txn, transactionID)
+			return err
+		case entity.HoldStatusReleased:
+			return entity.ErrHoldNotOpen
+		case entity.HoldStatusExpired:
+			return entity.ErrHoldExpired
+		}
+
+		// The held balance already includes this hold, so the balances cover its loss
+		// along with the other open holds as long as nothing more than them is held
+		if wallet.AvailableBalance() < 0 {
+			return entity.ErrUserNegativeBalance
+		}
+
+		hold.Status = entity.HoldStatusCaptured
+		hold.TransactionID = &transactionID
+		hold.UpdatedAt = now
+		if err := dm.querier.UpdateHold(ctx, *txn, *hold); err != nil {
+			return fmt.Errorf("updating hold: %w", err)
+		}
+
+		gameResult = &entity.GameResult{
+			UserID:            userID,
+			GameStatus:        entity.GameStatusLose,
+			TransactionSource: hold.TransactionSource,
+			TransactionID:     transactionID,
+			Amount:            hold.Amount,
+			Currency:          hold.Currency,
+			CreatedAt:         now,
+		}
+		before := *wallet
+		if err := dm.applyGameResult(wallet, gameResult, true); err != nil {
+			return err
+		}
+
+		counterparty := entity.CounterpartyAccount(gameResult.TransactionSource, gameResult.Currency)
+		if err := dm.persistGameResultTransaction(ctx, txn, gameResult, before, *wallet, counterparty); err != nil {
+			slog.ErrorContext(ctx, "error persisting captured hold", logging.Error(err))
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, dm.holdError(ctx, metrics.OperationCaptureHold, err)
+	}
+
+	if replayed != nil {
+		dm.metrics.ObserveGameResultOutcome(metrics.OperationCaptureHold, metrics.OutcomeReplayed)
+		return replayed, nil
+	}
+
+	dm.metrics.ObserveGameResultOutcome(metrics.OperationCaptureHold, metrics.OutcomeSuccess)
+	dm.metrics.ObserveGameResultAmount(*gameResult)
+	return gameResult, nil
+}
+
+// ReleaseHold gives the amount of the open hold back to the available balance
+// Releasing a hold already released or expired changes nothing
+// It returns the hold
+// It returns an error if the hold was captured, or was created by another source
+func (dm *accountDAO) ReleaseHold(ctx context.Context, userID int, holdID int, transactionSource entity.TransactionSource) (_ *entity.Hold, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.ReleaseHold", tracing.UserID(userID), tracing.HoldID(holdID))
+	defer func() { tracing.End(span, err) }()
+
+	var hold *entity.Hold
+
+	// Perform the whole operation inside a db transaction
+	err = dm.querier.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+		hold, err = dm.lockHold(ctx, txn, userID, holdID, transactionSource)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		hold.Status = hold.StatusAt(now)
+		switch hold.Status {
+		case entity.HoldStatusCaptured:
+			return entity.ErrHoldNotOpen
+		case entity.HoldStatusReleased, entity.HoldStatusExpired:
+			return nil
+		}
+
+		hold.Status = entity.HoldStatusReleased
+		hold.UpdatedAt = now
+		if err := dm.querier.UpdateHold(ctx, *txn, *hold); err != nil {
+			return fmt.Errorf("updating hold: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, dm.holdError(ctx, metrics.OperationReleaseHold, err)
+	}
+
+	dm.metrics.ObserveGameResultOutcome(metrics.OperationReleaseHold, metrics.OutcomeSuccess)
+	return hold, nil
+}
+
+// lockHold locks the hold row until the end of the db transaction
+// It returns an error if the hold does not exist or belongs to another user,
+// or if it was created by another source than the one settling it
+func (dm *accountDAO) lockHold(ctx context.Context, txn *sqlx.Tx, userID int, holdID int, transactionSource entity.TransactionSource) (*entity.Hold, error) {
+	hold, err := dm.querier.SelectHoldForUpdate(ctx, *txn, holdID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error locking hold", logging.Error(err))
+		return nil, err
+	}
+	if hold == nil || hold.UserID != userID {
+		return nil, entity.ErrHoldNotFound
+	}
+	// The API keys are authorized per source, so a source must not settle the holds of another one
+	if hold.TransactionSource != transactionSource {
+		return nil, entity.ErrHoldSourceMismatch
+	}
+	return hold, nil
+}
+
+// holdError counts the failed hold operation and returns its business error,
+// any other error is logged and reported as entity.ErrCreatingHold
+func (dm *accountDAO) holdError(ctx context.Context, operation string, err error) error {
+	dm.metrics.ObserveGameResultError(operation, err)
+
+	for _, holdErr := range holdErrors {
+		if errors.Is(err, holdErr) {
+			return holdErr
+		}
+	}
+
+	slog.ErrorContext(ctx, "error performing hold db transaction", logging.Error(err), slog.String("operation", operation))
+	return entity.ErrCreatingHold
+}
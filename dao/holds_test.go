@@ -0,0 +1,311 @@
+package dao
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+)
+
+// newHoldsDatabaseMock returns a database mock holding a user with the given balance,
+// answering every hold operation from memory
+func newHoldsDatabaseMock(ctx context.Context, userID int, balance entity.Money) *test_helpers.DatabaseMock {
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
+
+	// Create a fake user by forcing a balance over the Mock
+	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error { //nolint:all
+		return databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: balance})
+	})
+
+	databaseMock.On("SelectUser", mock.Anything, userID).Maybe()
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Maybe()
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything).Maybe()
+	databaseMock.On("InsertHold", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("SelectHold", mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("SelectHoldForUpdate", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("UpdateHold", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+	return databaseMock
+}
+
+func TestCreateHoldOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(2500), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	assert.NotZero(t, hold.ID)
+	assert.Equal(t, entity.HoldStatusOpen, hold.Status)
+	assert.Equal(t, entity.Money(2500), hold.Amount)
+	assert.Equal(t, time.Minute, hold.ExpiresAt.Sub(hold.CreatedAt))
+
+	// The balance is unchanged, but the held amount is no longer available
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), user.Balance)
+	assert.Equal(t, entity.Money(7500), user.AvailableBalance())
+}
+
+func TestCreateHoldOnErrors(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		amount    entity.Money
+		currency  entity.Currency
+		expiresIn time.Duration
+		expected  error
+	}{
+		{"Zero amount", entity.Money(0), entity.DefaultCurrency, time.Minute, entity.ErrInvalidAmount},
+		{"Negative amount", entity.Money(-100), entity.DefaultCurrency, time.Minute, entity.ErrInvalidAmount},
+		{"Amount too high", entity.MaxMoney + 1, entity.DefaultCurrency, time.Minute, entity.ErrInvalidAmount},
+		{"Zero expiry", entity.Money(100), entity.DefaultCurrency, 0, entity.ErrInvalidHoldExpiry},
+		{"Expiry too long", entity.Money(100), entity.DefaultCurrency, entity.MaxHoldExpiry + time.Second, entity.ErrInvalidHoldExpiry},
+		{"Amount over the available balance", entity.Money(7501), entity.DefaultCurrency, time.Minute, entity.ErrUserNegativeBalance},
+		{"Amount over the balance in another currency", entity.Money(100), "USD", time.Minute, entity.ErrUserNegativeBalance},
+		{"Unknown currency", entity.Money(100), "XYZ", time.Minute, entity.ErrUnknownCurrency},
+		{"Cents of a currency without minor units", entity.Money(150), "JPY", time.Minute, entity.ErrInvalidAmount},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+			instance := NewAccountDAO(databaseMock)
+
+			_, err := instance.CreateHold(ctx, 1, entity.Money(2500), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+			require.NoError(t, err)
+
+			_, err = instance.CreateHold(ctx, 1, tc.amount, tc.currency, entity.TransactionSourceGame, tc.expiresIn)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestCreateGameResultOnHeldBalance(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.CreateHold(ctx, 1, entity.Money(8000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	// Only the available balance can be lost
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(2001), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-1", nil)
+	assert.ErrorIs(t, err, entity.ErrUserNegativeBalance)
+
+	gameResult, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(2000), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-2", nil)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(8000), gameResult.BalanceAfter)
+}
+
+func TestCaptureHoldOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(10000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	gameResult, err := instance.CaptureHold(ctx, 1, hold.ID, "bet-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.GameStatusLose, gameResult.GameStatus)
+	assert.Equal(t, entity.TransactionSourceGame, gameResult.TransactionSource)
+	assert.Equal(t, "bet-1", gameResult.TransactionID)
+	assert.Equal(t, entity.Money(10000), gameResult.Amount)
+	assert.Equal(t, entity.Money(0), gameResult.BalanceAfter)
+
+	captured, err := instance.RetrieveHold(ctx, 1, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldStatusCaptured, captured.Status)
+	assert.Equal(t, "bet-1", *captured.TransactionID)
+
+	// The captured amount is no longer held
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(0), user.Balance)
+	assert.Equal(t, entity.Money(0), user.HeldBalance)
+
+	// A retried capture returns the original game result
+	replayed, err := instance.CaptureHold(ctx, 1, hold.ID, "bet-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, gameResult.ID, replayed.ID)
+	assert.Equal(t, 1, databaseMock.GameCount())
+
+	// But a capture under another transaction ID is rejected
+	_, err = instance.CaptureHold(ctx, 1, hold.ID, "bet-2", entity.TransactionSourceGame)
+	assert.ErrorIs(t, err, entity.ErrHoldNotOpen)
+}
+
+func TestCaptureHoldDrawsBonusBalance(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(1000))
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer")
+	require.NoError(t, err)
+
+	// The hold covers both the cash and bonus balances
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(2500), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	gameResult, err := instance.CaptureHold(ctx, 1, hold.ID, "bet-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(1500), gameResult.BonusAmount)
+	assert.Equal(t, entity.Money(0), gameResult.BalanceAfter)
+	assert.Equal(t, entity.Money(500), gameResult.BonusBalanceAfter)
+
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(7500), user.WageringRequirement)
+}
+
+func TestCaptureHoldOnErrors(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
+	databaseMock.UpdateWallet(ctx, sqlx.Tx{}, entity.Wallet{UserID: 2, Currency: entity.DefaultCurrency, Balance: entity.Money(10000)}) //nolint:all
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, 2)
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, 2, mock.Anything)
+	instance := NewAccountDAO(databaseMock)
+
+	released, err := instance.CreateHold(ctx, 1, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+	_, err = instance.ReleaseHold(ctx, 1, released.ID, entity.TransactionSourceGame)
+	require.NoError(t, err)
+
+	expired, err := instance.CreateHold(ctx, 1, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Nanosecond)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+
+	open, err := instance.CreateHold(ctx, 1, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		userID   int
+		holdID   int
+		source   entity.TransactionSource
+		expected error
+	}{
+		{"Unknown hold", 1, 999, entity.TransactionSourceGame, entity.ErrHoldNotFound},
+		{"Hold of another user", 2, open.ID, entity.TransactionSourceGame, entity.ErrHoldNotFound},
+		{"Hold of another source", 1, open.ID, entity.TransactionSourcePayment, entity.ErrHoldSourceMismatch},
+		{"Released hold", 1, released.ID, entity.TransactionSourceGame, entity.ErrHoldNotOpen},
+		{"Expired hold", 1, expired.ID, entity.TransactionSourceGame, entity.ErrHoldExpired},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := instance.CaptureHold(ctx, tc.userID, tc.holdID, "bet-1", tc.source)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+	assert.Equal(t, 0, databaseMock.GameCount())
+}
+
+func TestReleaseHoldOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(10000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	released, err := instance.ReleaseHold(ctx, 1, hold.ID, entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldStatusReleased, released.Status)
+
+	// The released amount is available again
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), user.AvailableBalance())
+
+	// Releasing it again changes nothing
+	released, err = instance.ReleaseHold(ctx, 1, hold.ID, entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldStatusReleased, released.Status)
+}
+
+func TestReleaseHoldOnExpiredHold(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(10000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Nanosecond)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+
+	// An expired hold no longer counts, without being released
+	user, err := instance.RetrieveUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(10000), user.AvailableBalance())
+
+	released, err := instance.ReleaseHold(ctx, 1, hold.ID, entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldStatusExpired, released.Status)
+	databaseMock.AssertNotCalled(t, "UpdateHold", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReleaseHoldOnCapturedHold(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+	_, err = instance.CaptureHold(ctx, 1, hold.ID, "bet-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+
+	_, err = instance.ReleaseHold(ctx, 1, hold.ID, entity.TransactionSourceGame)
+	assert.ErrorIs(t, err, entity.ErrHoldNotOpen)
+
+	_, err = instance.ReleaseHold(ctx, 2, hold.ID, entity.TransactionSourceGame)
+	assert.ErrorIs(t, err, entity.ErrHoldNotFound)
+}
+
+func TestReleaseHoldOfAnotherSource(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	_, err = instance.ReleaseHold(ctx, 1, hold.ID, entity.TransactionSourcePayment)
+	assert.ErrorIs(t, err, entity.ErrHoldSourceMismatch)
+
+	// The hold stays open
+	open, err := instance.RetrieveHold(ctx, 1, hold.ID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.HoldStatusOpen, open.Status)
+}
+
+func TestRetrieveHoldOnErrors(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+
+	_, err = instance.RetrieveHold(ctx, 2, hold.ID)
+	assert.ErrorIs(t, err, entity.ErrHoldNotFound)
+
+	_, err = instance.RetrieveHold(ctx, 1, 999)
+	assert.ErrorIs(t, err, entity.ErrHoldNotFound)
+}
@@ -0,0 +1,143 @@
+package dao
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/tracing"
+)
+
+// LedgerSnapshotDelay is how far in the past the ledger snapshots are taken at the earliest
+// The transactions are timestamped before they commit, within the database transaction timeout,
+// so a snapshot taken too close to now could miss postings committed after it, yet timestamped before it
+const LedgerSnapshotDelay = 5 * time.Minute
+
+// VerifyLedger compares the cash and bonus balances of the user wallets with the balances of their ledger accounts,
+// returning the ones which differ, none when the wallets match the ledger
+// A wallet or a ledger account missing on either side counts as a zero balance
+// It returns an error if the user does not exist
+func (dm *accountDAO) VerifyLedger(ctx context.Context, userID int) (_ []entity.LedgerMismatch, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.VerifyLedger", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	wallets, err := dm.RetrieveWallets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := dm.querier.SelectLedgerBalances(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error summing ledger balances", logging.Error(err))
+		return nil, err
+	}
+
+	ledger := make(map[entity.Currency]map[entity.LedgerAccountType]entity.Money)
+	var currencies []entity.Currency
+	for _, balance := range balances {
+		if _, ok := ledger[balance.Currency]; !ok {
+			ledger[balance.Currency] = make(map[entity.LedgerAccountType]entity.Money)
+			currencies = append(currencies, balance.Currency)
+		}
+		ledger[balance.Currency][balance.Type] = balance.Balance
+	}
+
+	walletBalances := make(map[entity.Currency]entity.Wallet)
+	for _, wallet := range wallets {
+		walletBalances[wallet.Currency] = wallet
+		if _, ok := ledger[wallet.Currency]; !ok {
+			currencies = append(currencies, wallet.Currency)
+		}
+	}
+
+	slices.Sort(currencies)
+
+	mismatches := []entity.LedgerMismatch{}
+	for _, currency := range currencies {
+		wallet := walletBalances[currency]
+		accounts := []struct {
+			account entity.LedgerAccount
+			balance entity.Money
+		}{
+			{entity.UserCashAccount(userID, currency), wallet.Balance},
+			{entity.UserBonusAccount(userID, currency), wallet.BonusBalance},
+		}
+
+		for _, account := range accounts {
+			if ledgerBalance := ledger[currency][account.account.Type]; ledgerBalance != account.balance {
+				mismatches = append(mismatches, entity.LedgerMismatch{
+					Account:       account.account,
+					WalletBalance: account.balance,
+					LedgerBalance: ledgerBalance,
+				})
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+// RetrieveBalancesAt returns the cash and bonus balances of the user as of the given time, computed from the ledger,
+// one per currency the user had transacted in by then, ordered by currency
+// It returns an error if the time is in the future or the user does not exist
+func (dm *accountDAO) RetrieveBalancesAt(ctx context.Context, userID int, at time.Time) (_ []entity.HistoricalBalance, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RetrieveBalancesAt", tracing.UserID(userID))
+	defer func() { tracing.End(span, err) }()
+
+	if at.After(time.Now()) {
+		return nil, entity.ErrFutureTimestamp
+	}
+
+	if _, err := dm.RetrieveUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	balances, err := dm.querier.SelectLedgerBalancesAt(ctx, userID, at.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "error summing ledger balances", logging.Error(err))
+		return nil, err
+	}
+
+	return entity.HistoricalBalances(userID, balances, at), nil
+}
+
+// TakeLedgerSnapshots records the balances of every ledger account as of the given time, which must be
+// LedgerSnapshotDelay in the past at least, so the later balance queries only sum the postings since then
+// Taking the snapshots again at the same time does nothing
+func (dm *accountDAO) TakeLedgerSnapshots(ctx context.Context, takenAt time.Time) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.TakeLedgerSnapshots")
+	defer func() { tracing.End(span, err) }()
+
+	if takenAt.After(time.Now().Add(-LedgerSnapshotDelay)) {
+		return 0, entity.ErrSnapshotTooRecent
+	}
+
+	count, err := dm.querier.InsertLedgerSnapshots(ctx, takenAt.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "error taking ledger snapshots", logging.Error(err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// SnapshotLedgerPeriodically takes the ledger snapshots right away, then every interval until the context is done,
+// at the start of the interval LedgerSnapshotDelay ago, eg: at midnight UTC for a 24h interval,
+// so every instance of the API takes the same snapshots
+func SnapshotLedgerPeriodically(ctx context.Context, accountDAO DAO, interval time.Duration) {
+	snapshot := func(ctx context.Context) {
+		takenAt := time.Now().Add(-LedgerSnapshotDelay).Truncate(interval)
+		count, err := accountDAO.TakeLedgerSnapshots(ctx, takenAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "error taking ledger snapshots", logging.Error(err))
+			return
+		}
+		slog.InfoContext(ctx, "ledger snapshots taken", slog.Time("taken_at", takenAt), slog.Int("snapshots", count))
+	}
+
+	snapshot(ctx)
+	every(ctx, interval, snapshot)
+}
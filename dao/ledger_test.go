@@ -0,0 +1,274 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+)
+
+// ledgerPostings returns the postings inserted into the database mock, one slice per game result
+func ledgerPostings(databaseMock *test_helpers.DatabaseMock) [][]entity.LedgerPosting {
+	var postings [][]entity.LedgerPosting
+	for _, call := range databaseMock.Calls {
+		if call.Method == "InsertLedgerPostings" {
+			postings = append(postings, call.Arguments.Get(2).([]entity.LedgerPosting))
+		}
+	}
+	return postings
+}
+
+func TestGameResultsPostToLedger(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(0))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	databaseMock.On("SelectWallets", mock.Anything, 1)
+	databaseMock.On("SelectLedgerBalances", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	deposit, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(5000), entity.DefaultCurrency, entity.TransactionSourcePayment, "deposit-1", nil)
+	require.NoError(t, err)
+	_, err = instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 1, "welcome offer")
+	require.NoError(t, err)
+	bet, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(3000), entity.DefaultCurrency, entity.TransactionSourceGame, "bet-1", nil)
+	require.NoError(t, err)
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourcePayment, "deposit-2", nil)
+	require.NoError(t, err)
+	reversal, err := instance.ReverseGameResult(ctx, 1, "deposit-2", entity.TransactionSourcePayment)
+	require.NoError(t, err)
+
+	postings := ledgerPostings(databaseMock)
+	require.Len(t, postings, 5)
+
+	// The deposit moves cash from the payment clearing account
+	assert.Equal(t, []entity.LedgerPosting{
+		{GameResultID: deposit.ID, Amount: 5000, LedgerAccount: entity.UserCashAccount(1, entity.DefaultCurrency)},
+		{GameResultID: deposit.ID, Amount: -5000, LedgerAccount: entity.CounterpartyAccount(entity.TransactionSourcePayment, entity.DefaultCurrency)},
+	}, withoutCreatedAt(postings[0]))
+
+	// The bet meets the wagering requirement, turning the bonus balance into cash
+	assert.Equal(t, []entity.LedgerPosting{
+		{GameResultID: bet.ID, Amount: -1000, LedgerAccount: entity.UserCashAccount(1, entity.DefaultCurrency)},
+		{GameResultID: bet.ID, Amount: -2000, LedgerAccount: entity.UserBonusAccount(1, entity.DefaultCurrency)},
+		{GameResultID: bet.ID, Amount: 3000, LedgerAccount: entity.CounterpartyAccount(entity.TransactionSourceGame, entity.DefaultCurrency)},
+	}, withoutCreatedAt(postings[2]))
+
+	// The reversal of the deposit goes back to the payment clearing account, even though it comes from the server
+	assert.Equal(t, []entity.LedgerPosting{
+		{GameResultID: reversal.ID, Amount: -1000, LedgerAccount: entity.UserCashAccount(1, entity.DefaultCurrency)},
+		{GameResultID: reversal.ID, Amount: 1000, LedgerAccount: entity.CounterpartyAccount(entity.TransactionSourcePayment, entity.DefaultCurrency)},
+	}, withoutCreatedAt(postings[4]))
+
+	for _, gameResultPostings := range postings {
+		sum := entity.Money(0)
+		for _, posting := range gameResultPostings {
+			sum += posting.Amount
+		}
+		assert.Equal(t, entity.Money(0), sum)
+	}
+
+	mismatches, err := instance.VerifyLedger(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
+
+func TestCaptureHoldPostsToLedger(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newHoldsDatabaseMock(ctx, 1, entity.Money(0))
+	databaseMock.On("SelectWallets", mock.Anything, 1)
+	databaseMock.On("SelectLedgerBalances", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(5000), entity.DefaultCurrency, entity.TransactionSourcePayment, "deposit-1", nil)
+	require.NoError(t, err)
+	hold, err := instance.CreateHold(ctx, 1, entity.Money(2500), entity.DefaultCurrency, entity.TransactionSourceGame, time.Minute)
+	require.NoError(t, err)
+	bet, err := instance.CaptureHold(ctx, 1, hold.ID, "bet-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+
+	postings := ledgerPostings(databaseMock)
+	require.Len(t, postings, 2)
+	assert.Equal(t, []entity.LedgerPosting{
+		{GameResultID: bet.ID, Amount: -2500, LedgerAccount: entity.UserCashAccount(1, entity.DefaultCurrency)},
+		{GameResultID: bet.ID, Amount: 2500, LedgerAccount: entity.CounterpartyAccount(entity.TransactionSourceGame, entity.DefaultCurrency)},
+	}, withoutCreatedAt(postings[1]))
+
+	mismatches, err := instance.VerifyLedger(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
+
+func TestVerifyLedgerOnMismatch(t *testing.T) {
+	ctx := context.Background()
+
+	// The seeded balance has no postings behind it
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	databaseMock.On("SelectWallets", mock.Anything, 1)
+	databaseMock.On("SelectLedgerBalances", mock.Anything, 1)
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(500), entity.DefaultCurrency, entity.TransactionSourceGame, "win-1", nil)
+	require.NoError(t, err)
+
+	mismatches, err := instance.VerifyLedger(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.LedgerMismatch{
+		{Account: entity.UserCashAccount(1, entity.DefaultCurrency), WalletBalance: 10500, LedgerBalance: 500},
+	}, mismatches)
+}
+
+func TestVerifyLedgerOnErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("user not found", func(t *testing.T) {
+		databaseMock := test_helpers.NewDatabaseMock()
+		databaseMock.On("SelectUser", mock.Anything, 1)
+		instance := NewAccountDAO(databaseMock)
+
+		_, err := instance.VerifyLedger(ctx, 1)
+		assert.ErrorIs(t, err, entity.ErrUserNotFound)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(0))
+		databaseMock.On("SelectUser", mock.Anything, 1)
+		databaseMock.On("SelectWallets", mock.Anything, 1)
+		databaseMock.On("SelectLedgerBalances", mock.Anything, 1).Return(nil, errors.New("database error"))
+		instance := NewAccountDAO(databaseMock)
+
+		_, err := instance.VerifyLedger(ctx, 1)
+		assert.Error(t, err)
+	})
+}
+
+// withoutCreatedAt clears the creation time of the postings, for comparing them
+func withoutCreatedAt(postings []entity.LedgerPosting) []entity.LedgerPosting {
+	cleared := make([]entity.LedgerPosting, len(postings))
+	for i, posting := range postings {
+		posting.CreatedAt = time.Time{}
+		cleared[i] = posting
+	}
+	return cleared
+}
+
+func TestRetrieveBalancesAtOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(0))
+	databaseMock.On("SelectUser", mock.Anything, 1)
+	databaseMock.On("SelectLedgerBalancesAt", mock.Anything, 1, mock.Anything)
+	instance := NewAccountDAO(databaseMock)
+
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(5000), entity.DefaultCurrency, entity.TransactionSourcePayment, "deposit-1", nil)
+	require.NoError(t, err)
+	_, err = instance.GrantBonus(ctx, 1, entity.Money(2000), entity.DefaultCurrency, 5, "welcome offer")
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond)
+	endOfDay := time.Now()
+	time.Sleep(time.Millisecond)
+
+	// Neither the later transactions nor their currencies count
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "bet-1", nil)
+	require.NoError(t, err)
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(700), "JPY", entity.TransactionSourcePayment, "deposit-2", nil)
+	require.NoError(t, err)
+
+	balances, err := instance.RetrieveBalancesAt(ctx, 1, endOfDay)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.HistoricalBalance{
+		{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(5000), BonusBalance: entity.Money(2000), At: endOfDay},
+	}, balances)
+
+	// Up to now, the balances are the current ones
+	balances, err = instance.RetrieveBalancesAt(ctx, 1, time.Now())
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	assert.Equal(t, entity.Money(4000), balances[0].Balance)
+	assert.Equal(t, entity.Money(2000), balances[0].BonusBalance)
+	assert.Equal(t, entity.Currency("JPY"), balances[1].Currency)
+	assert.Equal(t, entity.Money(700), balances[1].Balance)
+
+	// Before the first transaction, there is no balance
+	balances, err = instance.RetrieveBalancesAt(ctx, 1, endOfDay.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, balances)
+}
+
+func TestRetrieveBalancesAtOnErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("future timestamp", func(t *testing.T) {
+		instance := NewAccountDAO(test_helpers.NewDatabaseMock())
+
+		_, err := instance.RetrieveBalancesAt(ctx, 1, time.Now().Add(time.Hour))
+		assert.ErrorIs(t, err, entity.ErrFutureTimestamp)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		databaseMock := test_helpers.NewDatabaseMock()
+		databaseMock.On("SelectUser", mock.Anything, 1)
+		instance := NewAccountDAO(databaseMock)
+
+		_, err := instance.RetrieveBalancesAt(ctx, 1, time.Now())
+		assert.ErrorIs(t, err, entity.ErrUserNotFound)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(0))
+		databaseMock.On("SelectUser", mock.Anything, 1)
+		databaseMock.On("SelectLedgerBalancesAt", mock.Anything, 1, mock.Anything).Return(nil, errors.New("database error"))
+		instance := NewAccountDAO(databaseMock)
+
+		_, err := instance.RetrieveBalancesAt(ctx, 1, time.Now())
+		assert.Error(t, err)
+	})
+}
+
+func TestTakeLedgerSnapshots(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := test_helpers.NewDatabaseMock()
+	takenAt := time.Now().Add(-time.Hour)
+	databaseMock.On("InsertLedgerSnapshots", mock.Anything, takenAt.UTC()).Return(3, nil)
+	instance := NewAccountDAO(databaseMock)
+
+	count, err := instance.TakeLedgerSnapshots(ctx, takenAt)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Transactions timestamped before a recent time may still be committing
+	_, err = instance.TakeLedgerSnapshots(ctx, time.Now().Add(-time.Minute))
+	assert.ErrorIs(t, err, entity.ErrSnapshotTooRecent)
+	databaseMock.AssertExpectations(t)
+}
+
+func TestSnapshotLedgerPeriodically(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	daoMock := test_helpers.NewDAOMock()
+
+	// The snapshots are taken right away, at the start of the interval, then every interval
+	daoMock.On("TakeLedgerSnapshots", mock.Anything, mock.MatchedBy(func(takenAt time.Time) bool {
+		return takenAt.Equal(takenAt.Truncate(time.Millisecond)) && takenAt.Before(time.Now().Add(-LedgerSnapshotDelay))
+	})).Return(0, errors.New("database error")).Once()
+	daoMock.On("TakeLedgerSnapshots", mock.Anything, mock.Anything).Return(3, nil).Once().Run(func(mock.Arguments) { cancel() })
+
+	done := make(chan struct{})
+	go func() {
+		SnapshotLedgerPeriodically(ctx, daoMock, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the snapshots did not stop with the context")
+	}
+	daoMock.AssertExpectations(t)
+}
@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"context"
+	"time"
+)
+
+// every runs fn every interval until the context is done
+func every(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fn(ctx)
+		}
+	}
+}
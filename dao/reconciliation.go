@@ -0,0 +1,65 @@
+package dao
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/tracing"
+)
+
+// Reconcile compares the balance of every wallet with the one recomputed from its game results,
+// the wins minus the loses, reversals included, logging and reporting the wallets which differ
+func (dm *accountDAO) Reconcile(ctx context.Context) (_ *entity.Reconciliation, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.Reconcile")
+	defer func() { tracing.End(span, err) }()
+
+	defer func() {
+		if err != nil {
+			dm.metrics.ObserveReconciliationError()
+		}
+	}()
+
+	reconciliation := entity.Reconciliation{StartedAt: time.Now()}
+
+	reconciliation.WalletsChecked, err = dm.querier.CountWallets(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error counting wallets", logging.Error(err))
+		return nil, err
+	}
+
+	reconciliation.Discrepancies, err = dm.querier.SelectBalanceDiscrepancies(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error comparing balances", logging.Error(err))
+		return nil, err
+	}
+	reconciliation.FinishedAt = time.Now()
+
+	for _, discrepancy := range reconciliation.Discrepancies {
+		slog.WarnContext(ctx, "balance differs from the transaction history",
+			slog.Int("user_id", discrepancy.UserID),
+			slog.String("currency", string(discrepancy.Currency)),
+			slog.String("balance", discrepancy.Balance.String()),
+			slog.String("expected_balance", discrepancy.ExpectedBalance.String()))
+	}
+	dm.metrics.ObserveReconciliation(reconciliation)
+
+	return &reconciliation, nil
+}
+
+// ReconcilePeriodically reconciles the balances every interval until the context is done, see DAO.Reconcile
+func ReconcilePeriodically(ctx context.Context, accountDAO DAO, interval time.Duration) {
+	every(ctx, interval, func(ctx context.Context) {
+		reconciliation, err := accountDAO.Reconcile(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "error reconciling balances", logging.Error(err))
+			return
+		}
+		slog.InfoContext(ctx, "balances reconciled",
+			slog.Int("wallets_checked", reconciliation.WalletsChecked),
+			slog.Int("discrepancies", len(reconciliation.Discrepancies)),
+			slog.Duration("duration", reconciliation.FinishedAt.Sub(reconciliation.StartedAt)))
+	})
+}
@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+)
+
+func TestReconcileOnSuccess(t *testing.T) {
+	ctx := context.Background()
+
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(0))
+
+	// The forced balance has no game result behind it
+	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error { //nolint:all
+		return databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: 1, Currency: "JPY", Balance: entity.Money(300)})
+	})
+	databaseMock.On("CountWallets", mock.Anything)
+	databaseMock.On("SelectBalanceDiscrepancies", mock.Anything)
+	serviceMetrics := metrics.NewMetrics()
+	instance := NewAccountDAO(databaseMock)
+	instance.WithMetrics(serviceMetrics)
+
+	// The game results keep the wallet in line with its history
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(500), entity.DefaultCurrency, entity.TransactionSourcePayment, "deposit-1", nil)
+	require.NoError(t, err)
+
+	reconciliation, err := instance.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reconciliation.WalletsChecked)
+	assert.Equal(t, []entity.BalanceDiscrepancy{
+		{UserID: 1, Currency: "JPY", Balance: entity.Money(300), ExpectedBalance: entity.Money(0)},
+	}, reconciliation.Discrepancies)
+	assert.False(t, reconciliation.FinishedAt.Before(reconciliation.StartedAt))
+
+	rr := httptest.NewRecorder()
+	serviceMetrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rr.Body.String()
+	assert.Contains(t, body, `abm_reconciliation_runs_total{outcome="success"} 1`)
+	assert.Contains(t, body, `abm_reconciliation_discrepancies{currency="JPY"} 1`)
+}
+
+func TestReconcileOnDatabaseError(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("CountWallets", mock.Anything)
+	databaseMock.On("SelectBalanceDiscrepancies", mock.Anything).Return(nil, errors.New("database error"))
+	serviceMetrics := metrics.NewMetrics()
+	instance := NewAccountDAO(databaseMock)
+	instance.WithMetrics(serviceMetrics)
+
+	_, err := instance.Reconcile(ctx)
+	assert.Error(t, err)
+
+	rr := httptest.NewRecorder()
+	serviceMetrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), `abm_reconciliation_runs_total{outcome="error"} 1`)
+}
+
+func TestReconcilePeriodically(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	daoMock := test_helpers.NewDAOMock()
+
+	// Stop after the second reconciliation, the first one failing
+	daoMock.On("Reconcile", mock.Anything).Return(nil, errors.New("database error")).Once()
+	daoMock.On("Reconcile", mock.Anything).Return(&entity.Reconciliation{}, nil).Once().Run(func(mock.Arguments) { cancel() })
+
+	done := make(chan struct{})
+	go func() {
+		ReconcilePeriodically(ctx, daoMock, time.Millisecond)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the reconciliations did not stop with the context")
+	}
+	daoMock.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+package dao
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/tracing"
+	"github.com/jmoiron/sqlx"
+)
+
+// RetrieveRound returns the summary of the game round of the user, from its game results
+// It returns an error if the user has no game result in the round
+func (dm *accountDAO) RetrieveRound(ctx context.Context, userID int, roundID string) (_ *entity.Round, err error) {
+	ctx, span := tracing.Start(ctx, "accountDAO.RetrieveRound", tracing.UserID(userID), tracing.RoundID(roundID))
+	defer func() { tracing.End(span, err) }()
+
+	if !entity.ValidRoundID(roundID) {
+		return nil, entity.ErrRoundNotFound
+	}
+
+	gameResults, err := dm.querier.SelectRoundGameResults(ctx, userID, roundID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error locating round", logging.Error(err))
+		return nil, err
+	}
+	if len(gameResults) == 0 {
+		return nil, entity.ErrRoundNotFound
+	}
+
+	round := entity.NewRound(userID, roundID, gameResults)
+	return &round, nil
+}
+
+// validateRound checks that the game result can join its round, if it has any
+// A payout must reference an open round, no game result can join a settled or voided round,
+// and all the game results of a round are in the same currency
+func (dm *accountDAO) validateRound(ctx context.Context, txn *sqlx.Tx, gameResult entity.GameResult) error {
+	if gameResult.RoundID == nil {
+		return nil
+	}
+
+	gameResults, err := dm.querier.SelectGameResultsByRoundID(ctx, *txn, gameResult.UserID, *gameResult.RoundID)
+	if err != nil {
+		slog.ErrorContext(ctx, "error locating round", logging.Error(err))
+		return err
+	}
+
+	// Only a stake opens a round
+	if len(gameResults) == 0 && gameResult.GameStatus == entity.GameStatusWin {
+		return entity.ErrRoundNotFound
+	}
+	round := entity.NewRound(gameResult.UserID, *gameResult.RoundID, gameResults)
+	if round.Status != entity.RoundStatusOpen {
+		return entity.ErrRoundNotOpen
+	}
+	if len(gameResults) > 0 && round.Currency != gameResult.Currency {
+		return entity.ErrCurrencyMismatch
+	}
+
+	return nil
+}
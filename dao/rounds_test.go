@@ -0,0 +1,211 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
+)
+
+// newRoundsDatabaseMock returns a database mock holding a user with the given balance,
+// answering every game result operation from memory
+func newRoundsDatabaseMock(ctx context.Context, userID int, balance entity.Money) *test_helpers.DatabaseMock {
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("WithTransaction", mock.Anything, mock.AnythingOfType("func(*sqlx.Tx) error"))
+	databaseMock.On("UpdateWallet", mock.Anything, mock.Anything, mock.Anything)
+
+	// Create a fake user by forcing a balance over the Mock
+	databaseMock.WithTransaction(ctx, func(txn *sqlx.Tx) error { //nolint:all
+		return databaseMock.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: balance})
+	})
+
+	databaseMock.On("SelectUserForUpdate", mock.Anything, mock.Anything, userID).Maybe()
+	databaseMock.On("SelectWalletForUpdate", mock.Anything, mock.Anything, userID, mock.Anything).Maybe()
+	databaseMock.On("SelectGameResultByTransactionID", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("SelectGameResultsByRoundID", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("SelectRoundGameResults", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("InsertGameResult", mock.Anything, mock.Anything, mock.Anything).Maybe()
+	databaseMock.On("InsertLedgerPostings", mock.Anything, mock.Anything, mock.Anything).Maybe()
+
+	return databaseMock
+}
+
+func TestCreateGameResultInRoundOnSuccess(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+	roundID := "round-1"
+
+	stake, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &roundID)
+	require.NoError(t, err)
+	assert.Equal(t, &roundID, stake.RoundID)
+
+	// Further stakes join the open round
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(500), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-2", &roundID)
+	require.NoError(t, err)
+
+	payout, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(4000), entity.DefaultCurrency, entity.TransactionSourceGame, "payout-1", &roundID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(12500), payout.BalanceAfter)
+
+	// A retried payout is replayed, even though the round is settled
+	replayed, err := instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(4000), entity.DefaultCurrency, entity.TransactionSourceGame, "payout-1", &roundID)
+	require.NoError(t, err)
+	assert.Equal(t, payout.ID, replayed.ID)
+
+	round, err := instance.RetrieveRound(ctx, 1, roundID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoundStatusSettled, round.Status)
+	assert.Equal(t, entity.Money(1500), round.Stake)
+	assert.Equal(t, entity.Money(4000), round.Payout)
+	assert.Equal(t, entity.Money(2500), round.Net())
+	assert.Len(t, round.GameResults, 3)
+}
+
+func TestCreateGameResultInRoundOnErrors(t *testing.T) {
+	ctx := context.Background()
+	roundID, settledRoundID := "round-1", "round-2"
+	tooLongRoundID := strings.Repeat("r", entity.MaxRoundIDLength+1)
+	emptyRoundID := ""
+
+	tests := []struct {
+		name       string
+		gameStatus entity.GameStatus
+		roundID    *string
+		expected   error
+	}{
+		{"Empty round ID", entity.GameStatusLose, &emptyRoundID, entity.ErrInvalidRoundID},
+		{"Round ID too long", entity.GameStatusLose, &tooLongRoundID, entity.ErrInvalidRoundID},
+		{"Payout of an unknown round", entity.GameStatusWin, &roundID, entity.ErrRoundNotFound},
+		{"Payout of a settled round", entity.GameStatusWin, &settledRoundID, entity.ErrRoundNotOpen},
+		{"Stake of a settled round", entity.GameStatusLose, &settledRoundID, entity.ErrRoundNotOpen},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+			instance := NewAccountDAO(databaseMock)
+
+			_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &settledRoundID)
+			require.NoError(t, err)
+			_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(2000), entity.DefaultCurrency, entity.TransactionSourceGame, "payout-1", &settledRoundID)
+			require.NoError(t, err)
+
+			_, err = instance.CreateGameResult(ctx, 1, tc.gameStatus, entity.Money(100), entity.DefaultCurrency, entity.TransactionSourceGame, "tx-1", tc.roundID)
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestReverseGameResultInRound(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+	roundID := "round-1"
+
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &roundID)
+	require.NoError(t, err)
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(3000), entity.DefaultCurrency, entity.TransactionSourceGame, "payout-1", &roundID)
+	require.NoError(t, err)
+
+	// Reversing the payout opens the round again
+	reversal, err := instance.ReverseGameResult(ctx, 1, "payout-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+	assert.Equal(t, &roundID, reversal.RoundID)
+
+	round, err := instance.RetrieveRound(ctx, 1, roundID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoundStatusOpen, round.Status)
+	assert.Equal(t, entity.Money(-1000), round.Net())
+
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(1500), entity.DefaultCurrency, entity.TransactionSourceGame, "payout-2", &roundID)
+	require.NoError(t, err)
+}
+
+func TestCreateGameResultInVoidedRound(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+	roundID := "round-1"
+
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &roundID)
+	require.NoError(t, err)
+
+	// Reversing the only stake voids the round
+	_, err = instance.ReverseGameResult(ctx, 1, "stake-1", entity.TransactionSourceGame)
+	require.NoError(t, err)
+
+	round, err := instance.RetrieveRound(ctx, 1, roundID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.RoundStatusVoided, round.Status)
+
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(1500), entity.DefaultCurrency, entity.TransactionSourceGame, "payout-1", &roundID)
+	assert.ErrorIs(t, err, entity.ErrRoundNotOpen)
+
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(500), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-2", &roundID)
+	assert.ErrorIs(t, err, entity.ErrRoundNotOpen)
+
+	round, err = instance.RetrieveRound(ctx, 1, roundID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.Money(0), round.Payout)
+	assert.Len(t, round.GameResults, 2)
+}
+
+func TestRetrieveRoundOnErrors(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Round not found", func(t *testing.T) {
+		databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+		instance := NewAccountDAO(databaseMock)
+
+		_, err := instance.RetrieveRound(ctx, 1, "round-1")
+		assert.ErrorIs(t, err, entity.ErrRoundNotFound)
+	})
+
+	t.Run("Round of another user", func(t *testing.T) {
+		databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+		instance := NewAccountDAO(databaseMock)
+		roundID := "round-1"
+
+		_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &roundID)
+		require.NoError(t, err)
+
+		_, err = instance.RetrieveRound(ctx, 2, roundID)
+		assert.ErrorIs(t, err, entity.ErrRoundNotFound)
+	})
+
+	t.Run("Database error", func(t *testing.T) {
+		databaseMock := test_helpers.NewDatabaseMock()
+		databaseMock.On("SelectRoundGameResults", mock.Anything, 1, "round-1").Return(nil, errors.New("connection lost"))
+		instance := NewAccountDAO(databaseMock)
+
+		_, err := instance.RetrieveRound(ctx, 1, "round-1")
+		assert.EqualError(t, err, "connection lost")
+	})
+}
+
+func TestCreateGameResultInRoundOnCurrencyMismatch(t *testing.T) {
+	ctx := context.Background()
+	databaseMock := newRoundsDatabaseMock(ctx, 1, entity.Money(10000))
+	instance := NewAccountDAO(databaseMock)
+	roundID := "round-1"
+
+	_, err := instance.CreateGameResult(ctx, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &roundID)
+	require.NoError(t, err)
+
+	_, err = instance.CreateGameResult(ctx, 1, entity.GameStatusWin, entity.Money(2000), "USD", entity.TransactionSourceGame, "payout-1", &roundID)
+	assert.ErrorIs(t, err, entity.ErrCurrencyMismatch)
+
+	round, err := instance.RetrieveRound(ctx, 1, roundID)
+	require.NoError(t, err)
+	assert.Equal(t, entity.DefaultCurrency, round.Currency)
+	assert.Equal(t, entity.RoundStatusOpen, round.Status)
+}
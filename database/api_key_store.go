@@ -0,0 +1,12 @@
+package database
+
+import (
+	"context"
+
+	"github.com/ildomm/account-balance-manager/entity"
+)
+
+type APIKeyStore interface {
+	// SelectAPIKeyByHash returns nil when no key has the given hash, see entity.HashAPIKey
+	SelectAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+}
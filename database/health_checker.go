@@ -0,0 +1,14 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/ildomm/account-balance-manager/entity"
+)
+
+type HealthChecker interface {
+	Ping(ctx context.Context) error
+	MigrationStatus(ctx context.Context) (*entity.MigrationStatus, error)
+	Stats() sql.DBStats
+}
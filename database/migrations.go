@@ -0,0 +1,170 @@
+package database
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+var (
+	//go:embed migrations/*.sql
+	fs embed.FS
+)
+
+const (
+	CustomMigrationParam = "x-migrations-table"
+	CustomMigrationValue = "schema_migrations"
+)
+
+// MigrateUp applies all the pending migrations
+func MigrateUp(dbURL string) error {
+	return withMigrate(dbURL, func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+}
+
+// MigrateUpTo applies the pending migrations up to the given version, included
+func MigrateUpTo(dbURL string, version uint) error {
+	return withMigrate(dbURL, func(m *migrate.Migrate) error {
+		current, _, err := currentVersion(m)
+		if err != nil {
+			return err
+		}
+		if version < current {
+			return fmt.Errorf("version %d is below the current version %d, migrate down instead", version, current)
+		}
+		return m.Migrate(version)
+	})
+}
+
+// MigrateDownTo reverts the migrations above the given version, 0 reverting all of them
+func MigrateDownTo(dbURL string, version uint) error {
+	return withMigrate(dbURL, func(m *migrate.Migrate) error {
+		current, _, err := currentVersion(m)
+		if err != nil {
+			return err
+		}
+		if version > current {
+			return fmt.Errorf("version %d is above the current version %d, migrate up instead", version, current)
+		}
+		return migrateTo(m, version)
+	})
+}
+
+// MigrateGoto migrates up or down to the given version, 0 reverting all the migrations
+func MigrateGoto(dbURL string, version uint) error {
+	return withMigrate(dbURL, func(m *migrate.Migrate) error {
+		return migrateTo(m, version)
+	})
+}
+
+// MigrationVersion returns the applied version, 0 when none is, and whether its migration failed halfway
+func MigrationVersion(dbURL string) (version uint, dirty bool, err error) {
+	err = withMigrate(dbURL, func(m *migrate.Migrate) error {
+		version, dirty, err = currentVersion(m)
+		return err
+	})
+	return version, dirty, err
+}
+
+// MigrateForce records the given version as applied and clean, without running any migration.
+// It recovers from a migration which failed halfway, once the schema has been fixed by hand.
+// A version of -1 records that no migration is applied.
+func MigrateForce(dbURL string, version int) error {
+	return withMigrate(dbURL, func(m *migrate.Migrate) error {
+		return m.Force(version)
+	})
+}
+
+// SchemaVersion returns the version of the latest embedded migration, the one this binary expects
+func SchemaVersion() (uint, error) {
+	d, err := iofs.New(fs, "migrations")
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+
+	version, err := d.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := d.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+func migrateTo(m *migrate.Migrate, version uint) error {
+	if version == 0 {
+		return m.Down()
+	}
+	return m.Migrate(version)
+}
+
+// withMigrate runs the operation over the embedded migrations,
+// tracking their state in the CustomMigrationValue table.
+// Having nothing to migrate is not an error.
+func withMigrate(dbURL string, operation func(m *migrate.Migrate) error) error {
+
+	// Amend the database URl with custom parameter so that we can specify the
+	// table name to be used to hold database migration state
+	migrationsURL, err := migrationsURL(dbURL)
+	if err != nil {
+		return err
+	}
+
+	// Load the migrations from our embedded resources
+	d, err := iofs.New(fs, "migrations")
+	if err != nil {
+		return err
+	}
+
+	// Use a custom table name for schema migrations
+	m, err := migrate.NewWithSourceInstance("iofs", d, migrationsURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err = operation(m); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// currentVersion returns the applied version, 0 when none is
+func currentVersion(m *migrate.Migrate) (uint, bool, error) {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func migrationsURL(dbURL string) (string, error) {
+	url, err := url.Parse(dbURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Add the new Query parameter that specifies the table name for the migrations
+	values := url.Query()
+	values.Add(CustomMigrationParam, CustomMigrationValue)
+
+	// Replace the Query parameters in the original URL & return
+	url.RawQuery = values.Encode()
+	return url.String(), nil
+}
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS game_results_pxt_user_id_created_at_id;
@@ -0,0 +1,2 @@
+-- Supports listing a user's game results newest-first with keyset pagination
+CREATE INDEX IF NOT EXISTS game_results_pxt_user_id_created_at_id ON game_results (user_id, created_at DESC, id DESC);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS game_results_reverses_transaction_id_key;
+
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS reverses_transaction_id;
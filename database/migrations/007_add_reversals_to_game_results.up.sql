@@ -0,0 +1,6 @@
+-- A reversal is a compensating game result, referencing the transaction it reverses
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS reverses_transaction_id VARCHAR NULL;
+
+-- A transaction can be reversed only once
+CREATE UNIQUE INDEX IF NOT EXISTS game_results_reverses_transaction_id_key ON game_results (reverses_transaction_id);
@@ -0,0 +1,17 @@
+-- The user balance right after the game result, returned when a transaction is replayed
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS balance_after DECIMAL(10,2) NULL;
+
+-- Backfill the existing game results with the running balance of their user
+UPDATE game_results
+SET balance_after = running.balance
+FROM (
+    SELECT id,
+           SUM(CASE WHEN game_status = 'win' THEN amount ELSE -amount END)
+               OVER (PARTITION BY user_id ORDER BY created_at, id) AS balance
+    FROM game_results
+) AS running
+WHERE game_results.id = running.id;
+
+ALTER TABLE game_results
+    ALTER COLUMN balance_after SET NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS status;
+
+DROP TYPE IF EXISTS user_statuses;
@@ -0,0 +1,8 @@
+DROP TYPE IF EXISTS user_statuses;
+CREATE TYPE user_statuses AS ENUM ('active', 'frozen', 'closed');
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS status user_statuses NOT NULL DEFAULT 'active';
+
+-- The seeded users were inserted with explicit ids, move the sequence past them
+SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM users;
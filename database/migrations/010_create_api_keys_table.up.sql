@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id          BIGSERIAL PRIMARY KEY,
+    name        VARCHAR NOT NULL,
+    key_hash    CHAR(64) NOT NULL UNIQUE, /* hex encoded SHA-256 of the key, the key itself is never stored */
+    sources     transaction_sources[] NOT NULL,
+    user_ids    BIGINT[], /* NULL allows every user */
+    revoked_at  TIMESTAMP(6) WITHOUT TIME ZONE,
+    created_at  TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
@@ -0,0 +1,2 @@
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS reason;
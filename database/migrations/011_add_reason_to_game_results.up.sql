@@ -0,0 +1,3 @@
+-- Why an operator adjusted the balance by hand, NULL for the game results
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS reason TEXT;
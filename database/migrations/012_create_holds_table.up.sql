@@ -0,0 +1,19 @@
+DROP TYPE IF EXISTS hold_statuses;
+CREATE TYPE hold_statuses AS ENUM ('open', 'captured', 'released');
+
+-- Amounts reserved on the user balances for the in-progress bets,
+-- an open hold stops counting once expires_at is reached
+CREATE TABLE IF NOT EXISTS holds (
+    id                   BIGSERIAL PRIMARY KEY,
+    user_id              BIGINT NOT NULL,
+    amount               DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    status               hold_statuses NOT NULL DEFAULT 'open',
+    transaction_source   transaction_sources NOT NULL,
+    transaction_id       VARCHAR,
+    expires_at           TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    created_at           TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    updated_at           TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL
+);
+
+-- Sums the open holds of a user on every balance check
+CREATE INDEX IF NOT EXISTS holds_open_user_id_idx ON holds (user_id, expires_at) WHERE status = 'open';
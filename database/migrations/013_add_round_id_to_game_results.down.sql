@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS game_results_user_id_round_id_idx;
+
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS round_id;
@@ -0,0 +1,6 @@
+-- The game round a game result belongs to, a lose being its stake and a win its payout, NULL outside of rounds
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS round_id VARCHAR(255) NULL;
+
+-- Gathers the game results of a user's round, oldest-first
+CREATE INDEX IF NOT EXISTS game_results_user_id_round_id_idx ON game_results (user_id, round_id, created_at, id) WHERE round_id IS NOT NULL;
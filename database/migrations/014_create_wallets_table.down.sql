@@ -0,0 +1,16 @@
+ALTER TABLE holds
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS balance DECIMAL(10,2) NOT NULL DEFAULT 0.00 CHECK (balance >= 0);
+
+-- Only the balances in the default currency can be kept
+UPDATE users
+SET balance = wallets.balance
+FROM wallets
+WHERE wallets.user_id = users.id AND wallets.currency = 'EUR';
+
+DROP TABLE IF EXISTS wallets;
@@ -0,0 +1,28 @@
+-- The balances of the users, one per currency, a missing wallet being an empty one
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id      BIGINT NOT NULL,
+    currency     CHAR(3) NOT NULL,
+    balance      DECIMAL(10,2) NOT NULL DEFAULT 0.00 CHECK (balance >= 0),
+    created_at   TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    updated_at   TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, currency)
+);
+
+-- The existing balances are in the default currency
+INSERT INTO wallets (user_id, currency, balance, created_at, updated_at)
+SELECT id, 'EUR', balance, created_at, created_at
+FROM users
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS balance;
+
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+ALTER TABLE game_results
+    ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE holds
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'EUR';
+ALTER TABLE holds
+    ALTER COLUMN currency DROP DEFAULT;
@@ -0,0 +1,12 @@
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS bonus_balance_after;
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS wagering_requirement;
+ALTER TABLE game_results
+    DROP COLUMN IF EXISTS bonus_amount;
+
+-- The bonus balances are not withdrawable, they are dropped along with their wagering requirements
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS wagering_requirement;
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS bonus_balance;
@@ -0,0 +1,14 @@
+-- The bonus money granted by the promotions, withdrawable once wagered, along with the amount still to be wagered
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS bonus_balance DECIMAL(10,2) NOT NULL DEFAULT 0.00 CHECK (bonus_balance >= 0);
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS wagering_requirement DECIMAL(10,2) NOT NULL DEFAULT 0.00 CHECK (wagering_requirement >= 0);
+
+-- The part of a game result drawn from or credited to the bonus balance, the wagering requirement granted with it,
+-- and the bonus balance right after it
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS bonus_amount DECIMAL(10,2) NOT NULL DEFAULT 0.00 CHECK (bonus_amount >= 0);
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS wagering_requirement DECIMAL(10,2) NOT NULL DEFAULT 0.00 CHECK (wagering_requirement >= 0);
+ALTER TABLE game_results
+    ADD COLUMN IF NOT EXISTS bonus_balance_after DECIMAL(10,2) NOT NULL DEFAULT 0.00;
@@ -0,0 +1,79 @@
+DROP TYPE IF EXISTS ledger_account_types;
+CREATE TYPE ledger_account_types AS ENUM ('user_cash', 'user_bonus', 'house', 'payment_clearing');
+
+-- The accounts of the double-entry ledger, one per type and currency, and also per user for the user wallets
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id             BIGSERIAL PRIMARY KEY,
+    account_type   ledger_account_types NOT NULL,
+    user_id        BIGINT NULL,
+    currency       CHAR(3) NOT NULL,
+    created_at     TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    UNIQUE NULLS NOT DISTINCT (account_type, user_id, currency),
+    CHECK ((user_id IS NOT NULL) = (account_type IN ('user_cash', 'user_bonus')))
+);
+
+-- The movements of the ledger accounts, positive crediting and negative debiting the account,
+-- the postings of a game result summing to zero
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id               BIGSERIAL PRIMARY KEY,
+    game_result_id   BIGINT NOT NULL,
+    account_id       BIGINT NOT NULL,
+    amount           DECIMAL(10,2) NOT NULL CHECK (amount <> 0),
+    created_at       TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL
+);
+
+-- Sums the postings of an account, and of a game result
+CREATE INDEX IF NOT EXISTS ledger_postings_account_id_idx ON ledger_postings (account_id, created_at);
+CREATE INDEX IF NOT EXISTS ledger_postings_game_result_id_idx ON ledger_postings (game_result_id);
+
+-- Backfill the ledger from the existing game results, the moves of the cash and bonus balances
+-- being the differences between the balances right after each game result of the wallet
+CREATE TEMPORARY TABLE ledger_moves AS
+SELECT id AS game_result_id,
+       user_id,
+       currency,
+       CASE WHEN transaction_source = 'payment' THEN 'payment_clearing' ELSE 'house' END::ledger_account_types AS counterparty,
+       balance_after - LAG(balance_after, 1, 0.00) OVER wallet AS cash,
+       bonus_balance_after - LAG(bonus_balance_after, 1, 0.00) OVER wallet AS bonus,
+       created_at
+FROM game_results
+WINDOW wallet AS (PARTITION BY user_id, currency ORDER BY created_at, id);
+
+INSERT INTO ledger_accounts (account_type, user_id, currency, created_at)
+SELECT 'user_cash', user_id, currency, MIN(created_at) FROM ledger_moves GROUP BY user_id, currency
+UNION ALL
+SELECT 'user_bonus', user_id, currency, MIN(created_at) FROM ledger_moves GROUP BY user_id, currency
+UNION ALL
+SELECT counterparty, NULL, currency, MIN(created_at) FROM ledger_moves GROUP BY counterparty, currency
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_postings (game_result_id, account_id, amount, created_at)
+SELECT moves.game_result_id, ledger_accounts.id, moves.amount, moves.created_at
+FROM (
+    SELECT game_result_id, 'user_cash'::ledger_account_types AS account_type, user_id, currency, cash AS amount, created_at FROM ledger_moves
+    UNION ALL
+    SELECT game_result_id, 'user_bonus', user_id, currency, bonus, created_at FROM ledger_moves
+    UNION ALL
+    SELECT game_result_id, counterparty, NULL, currency, -(cash + bonus), created_at FROM ledger_moves
+) AS moves
+JOIN ledger_accounts ON ledger_accounts.account_type = moves.account_type
+    AND ledger_accounts.user_id IS NOT DISTINCT FROM moves.user_id
+    AND ledger_accounts.currency = moves.currency
+WHERE moves.amount <> 0;
+
+DROP TABLE ledger_moves;
+
+-- Reject, on commit, the game results whose postings do not sum to zero
+CREATE OR REPLACE FUNCTION check_ledger_postings_balanced() RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_postings WHERE game_result_id = NEW.game_result_id) <> 0 THEN
+        RAISE EXCEPTION 'unbalanced ledger postings for game result %', NEW.game_result_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_ledger_postings_balanced();
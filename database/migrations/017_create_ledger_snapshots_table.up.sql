@@ -0,0 +1,9 @@
+-- The balances of the ledger accounts at given times, so that the balances as of a past time
+-- only sum the postings since the latest snapshot before it.
+-- Wider than the postings, as the house accounts sum the postings of every user
+CREATE TABLE IF NOT EXISTS ledger_snapshots (
+    account_id   BIGINT NOT NULL,
+    balance      DECIMAL(20,2) NOT NULL,
+    taken_at     TIMESTAMP(6) WITHOUT TIME ZONE NOT NULL,
+    PRIMARY KEY (account_id, taken_at)
+);
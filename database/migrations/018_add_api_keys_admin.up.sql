@@ -0,0 +1,3 @@
+-- Only the admin keys can create the users and change their status, the existing keys are not admin ones
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,35 @@
+package database
+
+import (
+	"time"
+)
+
+const (
+	DefaultMaxOpenConns       = 25
+	DefaultMaxIdleConns       = 5
+	DefaultConnMaxLifetime    = time.Minute * 5
+	DefaultTransactionTimeout = time.Second * 30
+	DefaultAutoMigrate        = true
+)
+
+// Options tunes the connection pool and the transactions of the PostgresQuerier
+type Options struct {
+	MaxOpenConns       int
+	MaxIdleConns       int
+	ConnMaxLifetime    time.Duration
+	TransactionTimeout time.Duration
+	// AutoMigrate applies the pending migrations when connecting,
+	// otherwise they are left to the `abmctl migrate` commands
+	AutoMigrate bool
+}
+
+// DefaultOptions returns the options used by NewPostgresQuerier
+func DefaultOptions() Options {
+	return Options{
+		MaxOpenConns:       DefaultMaxOpenConns,
+		MaxIdleConns:       DefaultMaxIdleConns,
+		ConnMaxLifetime:    DefaultConnMaxLifetime,
+		TransactionTimeout: DefaultTransactionTimeout,
+		AutoMigrate:        DefaultAutoMigrate,
+	}
+}
@@ -3,33 +3,38 @@ package database
 import (
 	"context"
 	"database/sql"
-	"embed"
 	"errors"
 	"fmt"
-	"log"
-	"net/url"
+	"log/slog"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/tracing"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 
 	"time"
 )
 
 type PostgresQuerier struct {
-	dbURL  string
-	dbConn *sqlx.DB
-	ctx    context.Context
+	dbURL   string
+	dbConn  *sqlx.DB
+	ctx     context.Context
+	options Options
 }
 
+// NewPostgresQuerier connects to the database and migrates it, with the DefaultOptions
 func NewPostgresQuerier(ctx context.Context, url string) (*PostgresQuerier, error) {
-	querier := PostgresQuerier{dbURL: url, ctx: ctx}
+	return NewPostgresQuerierWithOptions(ctx, url, DefaultOptions())
+}
+
+// NewPostgresQuerierWithOptions connects to the database, migrating it when Options.AutoMigrate is set.
+// It fails when the schema is not at the SchemaVersion expected by this binary.
+func NewPostgresQuerierWithOptions(ctx context.Context, url string, options Options) (*PostgresQuerier, error) {
+	querier := PostgresQuerier{dbURL: url, ctx: ctx, options: options}
 
 	_, err := pgx.ParseConfig(url)
 	if err != nil {
@@ -42,14 +47,14 @@ func NewPostgresQuerier(ctx context.Context, url string) (*PostgresQuerier, erro
 		return nil, fmt.Errorf("opening database connection: %w", err)
 	}
 
-	// Set reasonable pool limits for better concurrency handling
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	// Set the pool limits for better concurrency handling
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	db.SetMaxIdleConns(options.MaxIdleConns)
+	db.SetConnMaxLifetime(options.ConnMaxLifetime)
 
 	querier.dbConn = db
 
-	log.Print("opened database connection")
+	slog.Info("opened database connection")
 
 	// Ping the database to check that the connection is actually working
 	err = querier.dbConn.Ping()
@@ -58,71 +63,49 @@ func NewPostgresQuerier(ctx context.Context, url string) (*PostgresQuerier, erro
 	}
 
 	// Migrate the database
-	err = querier.migrate()
+	if options.AutoMigrate {
+		err = MigrateUp(url)
+		if err != nil {
+			return &querier, err
+		}
+		slog.Info("database migration complete")
+	}
+
+	// Refuse to run against a schema this binary was not built for
+	err = querier.checkSchemaVersion(ctx)
 	if err != nil {
 		return &querier, err
 	}
-	log.Print("database migration complete")
 
 	return &querier, nil
 }
 
-func (q *PostgresQuerier) Close() {
-	q.dbConn.Close()
-	log.Print("closed database connection")
-}
-
-var (
-	//go:embed migrations/*.sql
-	fs embed.FS
-)
-
-func (q *PostgresQuerier) migrate() error {
-
-	// Amend the database URl with custom parameter so that we can specify the
-	// table name to be used to hold database migration state
-	migrationsURL, err := q.migrationsURL()
+// checkSchemaVersion verifies that the schema is cleanly migrated to the SchemaVersion
+func (q *PostgresQuerier) checkSchemaVersion(ctx context.Context) error {
+	expected, err := SchemaVersion()
 	if err != nil {
 		return err
 	}
 
-	// Load the migrations from our embedded resources
-	d, err := iofs.New(fs, "migrations")
+	status, err := q.MigrationStatus(ctx)
 	if err != nil {
 		return err
 	}
-
-	// Use a custom table name for schema migrations
-	m, err := migrate.NewWithSourceInstance("iofs", d, migrationsURL)
-	if err != nil {
-		return err
+	if status == nil {
+		return fmt.Errorf("%w: not migrated, expected version %d", entity.ErrSchemaVersionMismatch, expected)
 	}
-
-	// Migrate all the way up ...
-	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return err
+	if status.Dirty {
+		return fmt.Errorf("%w: dirty at version %d, expected version %d", entity.ErrSchemaVersionMismatch, status.Version, expected)
+	}
+	if status.Version != expected {
+		return fmt.Errorf("%w: at version %d, expected version %d", entity.ErrSchemaVersionMismatch, status.Version, expected)
 	}
 	return nil
 }
 
-const (
-	CustomMigrationParam = "x-migrations-table"
-	CustomMigrationValue = "schema_migrations"
-)
-
-func (q *PostgresQuerier) migrationsURL() (string, error) {
-	url, err := url.Parse(q.dbURL)
-	if err != nil {
-		return "", err
-	}
-
-	// Add the new Query parameter that specifies the table name for the migrations
-	values := url.Query()
-	values.Add(CustomMigrationParam, CustomMigrationValue)
-
-	// Replace the Query parameters in the original URL & return
-	url.RawQuery = values.Encode()
-	return url.String(), nil
+func (q *PostgresQuerier) Close() {
+	q.dbConn.Close()
+	slog.Info("closed database connection")
 }
 
 ////////////////////////////////// Database Querier standard operations /////////////////////////////////////////////////////////
@@ -130,8 +113,13 @@ func (q *PostgresQuerier) migrationsURL() (string, error) {
 // WithTransaction creates a new transaction and handles rollback/commit based on the
 // error object returned by the `TxFn`
 func (q *PostgresQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx) error) (err error) {
+	// The span covers the whole transaction, from begin to commit or rollback.
+	// The statements of fn are traced as its siblings, as fn holds its own context
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.WithTransaction", "BEGIN")
+	defer func() { tracing.End(span, err) }()
+
 	// Create a context with timeout for the transaction
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, q.options.TransactionTimeout)
 	defer cancel()
 
 	tx, err := q.dbConn.BeginTxx(ctx, nil)
@@ -144,11 +132,12 @@ func (q *PostgresQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx)
 			// a panic occurred, rollback and re-panic
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
-				log.Printf("error rolling back after panic: %v", rollbackErr)
+				slog.ErrorContext(ctx, "error rolling back after panic", logging.Error(rollbackErr))
 			}
 			panic(p)
 		} else if err != nil {
 			// something went wrong, rollback
+			span.AddEvent("rollback")
 			rollbackErr := tx.Rollback()
 			if rollbackErr != nil {
 				err = fmt.Errorf("error rolling back: %v, original error: %w", rollbackErr, err)
@@ -156,6 +145,7 @@ func (q *PostgresQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx)
 			}
 		} else {
 			// all good, commit
+			span.AddEvent("commit")
 			if commitErr := tx.Commit(); commitErr != nil {
 				err = fmt.Errorf("error committing transaction: %w", commitErr)
 			}
@@ -169,17 +159,65 @@ func (q *PostgresQuerier) WithTransaction(ctx context.Context, fn func(*sqlx.Tx)
 	return nil
 }
 
+// uniqueViolationCode is the Postgres error code raised when a unique constraint is violated
+const uniqueViolationCode = "23505"
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// undefinedTableCode is the Postgres error code raised when a table does not exist
+const undefinedTableCode = "42P01"
+
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == undefinedTableCode
+}
+
+////////////////////////////////// Database Health Checker operations /////////////////////////////////////////////////////////
+
+// Ping checks that the database connection is actually working
+func (q *PostgresQuerier) Ping(ctx context.Context) error {
+	return q.dbConn.PingContext(ctx)
+}
+
+const selectMigrationStatusSQL = `SELECT version, dirty FROM ` + CustomMigrationValue + ` LIMIT 1`
+
+// MigrationStatus returns the current schema version and whether its migration failed halfway
+// It returns nil if no migration was ever applied
+func (q *PostgresQuerier) MigrationStatus(ctx context.Context) (*entity.MigrationStatus, error) {
+	var status entity.MigrationStatus
+
+	err := q.dbConn.QueryRowContext(ctx, selectMigrationStatusSQL).Scan(&status.Version, &status.Dirty)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) || isUndefinedTable(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("selecting migration status: %w", err)
+	}
+	return &status, nil
+}
+
+// Stats returns the connection pool statistics
+func (q *PostgresQuerier) Stats() sql.DBStats {
+	return q.dbConn.Stats()
+}
+
 ////////////////////////////////// Database Querier domain operations /////////////////////////////////////////////////////////
 
 const insertGameResultSQL = `
-	INSERT INTO game_results ( user_id, game_status, transaction_source, transaction_id, amount, created_at)
-	VALUES                   ( $1,      $2,          $3,                 $4,             $5,     $6)
+	INSERT INTO game_results ( user_id, game_status, transaction_source, transaction_id, amount, currency, reverses_transaction_id, reason, balance_after, round_id, bonus_amount, wagering_requirement, bonus_balance_after, created_at)
+	VALUES                   ( $1,      $2,          $3,                 $4,             $5,     $6,       $7,                      $8,     $9,            $10,      $11,          $12,                  $13,                 $14)
 	RETURNING id`
 
-func (q *PostgresQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (int, error) {
+func (q *PostgresQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (_ int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.InsertGameResult", insertGameResultSQL)
+	defer func() { tracing.End(span, err) }()
+
 	var id int
 
-	err := txn.GetContext(
+	err = txn.GetContext(
 		ctx,
 		&id,
 		insertGameResultSQL,
@@ -188,21 +226,103 @@ func (q *PostgresQuerier) InsertGameResult(ctx context.Context, txn sqlx.Tx, gam
 		gameResult.TransactionSource,
 		gameResult.TransactionID,
 		gameResult.Amount,
+		gameResult.Currency,
+		gameResult.ReversesTransactionID,
+		gameResult.Reason,
+		gameResult.BalanceAfter,
+		gameResult.RoundID,
+		gameResult.BonusAmount,
+		gameResult.WageringRequirement,
+		gameResult.BonusBalanceAfter,
 		gameResult.CreatedAt)
 
+	// A concurrent transaction might have recorded the same transaction ID,
+	// or reversed the same transaction, in the meantime
+	if isUniqueViolation(err) {
+		return 0, entity.ErrTransactionIdExists
+	}
+
 	return id, err
 }
 
-const selectUserSQL = `SELECT * FROM users WHERE id = $1`
+const insertUserSQL = `
+	INSERT INTO users ( status, created_at)
+	VALUES            ( $1,     $2)
+	RETURNING id`
+
+func (q *PostgresQuerier) InsertUser(ctx context.Context, user entity.User) (_ int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.InsertUser", insertUserSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var id int
+
+	err = q.dbConn.GetContext(
+		ctx,
+		&id,
+		insertUserSQL,
+		user.Status,
+		user.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("inserting user: %w", err)
+	}
+
+	return id, nil
+}
+
+// userColumns selects the user along with its wallet in the currency $3, empty when missing,
+// and the sum of its open holds in that currency, not yet expired at $2
+const userColumns = `users.id, users.status, users.created_at, COALESCE(wallets.balance, 0) AS balance,
+	COALESCE(wallets.bonus_balance, 0) AS bonus_balance, COALESCE(wallets.wagering_requirement, 0) AS wagering_requirement, (
+		SELECT COALESCE(SUM(holds.amount), 0)
+		FROM holds
+		WHERE holds.user_id = users.id AND holds.currency = $3 AND holds.status = 'open' AND holds.expires_at > $2
+	) AS held_balance
+	FROM users
+	LEFT JOIN wallets ON wallets.user_id = users.id AND wallets.currency = $3`
+
+const selectUserSQL = `SELECT ` + userColumns + ` WHERE users.id = $1`
+
+func (q *PostgresQuerier) SelectUser(ctx context.Context, userID int) (_ *entity.User, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectUser", selectUserSQL)
+	defer func() { tracing.End(span, err) }()
 
-func (q *PostgresQuerier) SelectUser(ctx context.Context, userID int) (*entity.User, error) {
 	var user entity.User
 
-	err := q.dbConn.GetContext(
+	err = q.dbConn.GetContext(
 		ctx,
 		&user,
 		selectUserSQL,
-		userID)
+		userID,
+		time.Now(),
+		entity.DefaultCurrency)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
+
+const selectUserForUpdateSQL = `SELECT ` + userColumns + ` WHERE users.id = $1 FOR UPDATE OF users`
+
+// SelectUserForUpdate locks the user row until the end of the given transaction,
+// any other transaction locking the same user will wait for it
+func (q *PostgresQuerier) SelectUserForUpdate(ctx context.Context, txn sqlx.Tx, userID int) (_ *entity.User, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectUserForUpdate", selectUserForUpdateSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var user entity.User
+
+	err = txn.GetContext(
+		ctx,
+		&user,
+		selectUserForUpdateSQL,
+		userID,
+		time.Now(),
+		entity.DefaultCurrency)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -216,30 +336,114 @@ func (q *PostgresQuerier) SelectUser(ctx context.Context, userID int) (*entity.U
 
 const selectCheckTransactionSQL = `SELECT count(*) FROM game_results WHERE transaction_id = $1`
 
-func (q *PostgresQuerier) TransactionIDExist(ctx context.Context, transactionID string) (bool, error) {
+func (q *PostgresQuerier) TransactionIDExist(ctx context.Context, transactionID string) (_ bool, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.TransactionIDExist", selectCheckTransactionSQL)
+	defer func() { tracing.End(span, err) }()
+
 	var count int64
-	err := q.dbConn.QueryRowContext(ctx, selectCheckTransactionSQL, transactionID).Scan(&count)
+	err = q.dbConn.QueryRowContext(ctx, selectCheckTransactionSQL, transactionID).Scan(&count)
 	if err != nil {
 		return false, fmt.Errorf("checking transaction existence: %w", err)
 	}
 	return count > 0, nil
 }
 
-const updateUserSQL = `
+// walletColumns selects the wallet along with the sum of its open holds, not yet expired at $2
+const walletColumns = `user_id, currency, balance, bonus_balance, wagering_requirement, created_at, updated_at, (
+		SELECT COALESCE(SUM(holds.amount), 0)
+		FROM holds
+		WHERE holds.user_id = wallets.user_id AND holds.currency = wallets.currency AND holds.status = 'open' AND holds.expires_at > $2
+	) AS held_balance`
+
+const selectWalletsSQL = `SELECT ` + walletColumns + ` FROM wallets WHERE user_id = $1 ORDER BY currency`
+
+func (q *PostgresQuerier) SelectWallets(ctx context.Context, userID int) (_ []entity.Wallet, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectWallets", selectWalletsSQL)
+	defer func() { tracing.End(span, err) }()
+
+	wallets := []entity.Wallet{}
+	err = q.dbConn.SelectContext(ctx, &wallets, selectWalletsSQL, userID, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("selecting wallets: %w", err)
+	}
+	return wallets, nil
+}
+
+const selectWalletForUpdateSQL = `SELECT ` + walletColumns + ` FROM wallets WHERE user_id = $1 AND currency = $3 FOR UPDATE`
+
+// SelectWalletForUpdate locks the wallet row until the end of the given transaction
+// It returns nil when the user has no wallet in the currency yet
+func (q *PostgresQuerier) SelectWalletForUpdate(ctx context.Context, txn sqlx.Tx, userID int, currency entity.Currency) (_ *entity.Wallet, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectWalletForUpdate", selectWalletForUpdateSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var wallet entity.Wallet
+
+	err = txn.GetContext(
+		ctx,
+		&wallet,
+		selectWalletForUpdateSQL,
+		userID,
+		time.Now(),
+		currency)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &wallet, nil
+}
+
+const upsertWalletSQL = `
+	INSERT INTO wallets ( user_id, currency, balance, bonus_balance, wagering_requirement, created_at, updated_at)
+	VALUES              ( $1,      $2,       $3,      $4,            $5,                   $6,         $6)
+	ON CONFLICT (user_id, currency) DO UPDATE
+	SET
+		balance = EXCLUDED.balance,
+		bonus_balance = EXCLUDED.bonus_balance,
+		wagering_requirement = EXCLUDED.wagering_requirement,
+		updated_at = EXCLUDED.updated_at`
+
+// UpdateWallet stores the balances of the wallet, opening it if missing
+func (q *PostgresQuerier) UpdateWallet(ctx context.Context, txn sqlx.Tx, wallet entity.Wallet) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.UpdateWallet", upsertWalletSQL)
+	defer func() { tracing.End(span, err) }()
+
+	_, err = txn.ExecContext(ctx, upsertWalletSQL,
+		wallet.UserID,
+		wallet.Currency,
+		wallet.Balance,
+		wallet.BonusBalance,
+		wallet.WageringRequirement,
+		time.Now())
+	if err != nil {
+		return fmt.Errorf("updating wallet: %w", err)
+	}
+
+	return nil
+}
+
+const updateUserStatusSQL = `
 	UPDATE users
 	SET 
-		balance = :balance
+		status = :status
 	WHERE id = :id`
 
-func (q *PostgresQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userID int, balance float64) error {
+func (q *PostgresQuerier) UpdateUserStatus(ctx context.Context, txn sqlx.Tx, userID int, status entity.UserStatus) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.UpdateUserStatus", updateUserStatusSQL)
+	defer func() { tracing.End(span, err) }()
+
 	user := entity.User{
-		ID:      userID,
-		Balance: balance,
+		ID:     userID,
+		Status: status,
 	}
 
-	result, err := txn.NamedExecContext(ctx, updateUserSQL, user)
+	result, err := txn.NamedExecContext(ctx, updateUserStatusSQL, user)
 	if err != nil {
-		return fmt.Errorf("updating user balance: %w", err)
+		return fmt.Errorf("updating user status: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -253,3 +457,528 @@ func (q *PostgresQuerier) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, us
 
 	return nil
 }
+
+const gameResultColumns = `id, user_id, game_status, transaction_source, transaction_id, amount, currency, reverses_transaction_id, reason, balance_after, round_id, bonus_amount, wagering_requirement, bonus_balance_after, created_at`
+
+const selectGameResultsSQL = `
+	SELECT ` + gameResultColumns + `
+	FROM game_results
+	WHERE user_id = $1`
+
+func (q *PostgresQuerier) SelectGameResults(ctx context.Context, filter entity.GameResultFilter) (_ []entity.GameResult, err error) {
+	query := selectGameResultsSQL
+	args := []interface{}{filter.UserID}
+
+	// Append an optional condition, binding its value to the next positional parameter
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filter.GameStatus != nil {
+		where("game_status = $%d", *filter.GameStatus)
+	}
+	if filter.TransactionSource != nil {
+		where("transaction_source = $%d", *filter.TransactionSource)
+	}
+	if filter.CreatedFrom != nil {
+		where("created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where("created_at < $%d", *filter.CreatedTo)
+	}
+	if filter.Cursor != nil {
+		// Keyset pagination, newest-first
+		args = append(args, filter.Cursor.CreatedAt, filter.Cursor.ID)
+		query += fmt.Sprintf(" AND (created_at, id) < ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", len(args))
+
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectGameResults", query)
+	defer func() { tracing.End(span, err) }()
+
+	gameResults := []entity.GameResult{}
+	err = q.dbConn.SelectContext(ctx, &gameResults, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("selecting game results: %w", err)
+	}
+	return gameResults, nil
+}
+
+const selectGameResultByTransactionIDSQL = `
+	SELECT ` + gameResultColumns + `
+	FROM game_results
+	WHERE transaction_id = $1`
+
+func (q *PostgresQuerier) SelectGameResultByTransactionID(ctx context.Context, txn sqlx.Tx, transactionID string) (_ *entity.GameResult, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectGameResultByTransactionID", selectGameResultByTransactionIDSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var gameResult entity.GameResult
+
+	err = txn.GetContext(
+		ctx,
+		&gameResult,
+		selectGameResultByTransactionIDSQL,
+		transactionID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &gameResult, nil
+}
+
+const selectGameResultsByRoundIDSQL = `
+	SELECT ` + gameResultColumns + `
+	FROM game_results
+	WHERE user_id = $1 AND round_id = $2
+	ORDER BY created_at, id`
+
+func (q *PostgresQuerier) SelectGameResultsByRoundID(ctx context.Context, txn sqlx.Tx, userID int, roundID string) (_ []entity.GameResult, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectGameResultsByRoundID", selectGameResultsByRoundIDSQL)
+	defer func() { tracing.End(span, err) }()
+
+	gameResults := []entity.GameResult{}
+	err = txn.SelectContext(ctx, &gameResults, selectGameResultsByRoundIDSQL, userID, roundID)
+	if err != nil {
+		return nil, fmt.Errorf("selecting round game results: %w", err)
+	}
+	return gameResults, nil
+}
+
+// SelectRoundGameResults is SelectGameResultsByRoundID outside of any db transaction, for the reads
+func (q *PostgresQuerier) SelectRoundGameResults(ctx context.Context, userID int, roundID string) (_ []entity.GameResult, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectRoundGameResults", selectGameResultsByRoundIDSQL)
+	defer func() { tracing.End(span, err) }()
+
+	gameResults := []entity.GameResult{}
+	err = q.dbConn.SelectContext(ctx, &gameResults, selectGameResultsByRoundIDSQL, userID, roundID)
+	if err != nil {
+		return nil, fmt.Errorf("selecting round game results: %w", err)
+	}
+	return gameResults, nil
+}
+
+// apiKeyRow maps the api_keys columns, the arrays can not be scanned into the entity directly
+type apiKeyRow struct {
+	ID        int            `db:"id"`
+	Name      string         `db:"name"`
+	KeyHash   string         `db:"key_hash"`
+	Sources   pq.StringArray `db:"sources"`
+	UserIDs   pq.Int64Array  `db:"user_ids"`
+	Admin     bool           `db:"admin"`
+	RevokedAt *time.Time     `db:"revoked_at"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+func (r apiKeyRow) toEntity() *entity.APIKey {
+	apiKey := entity.APIKey{
+		ID:        r.ID,
+		Name:      r.Name,
+		KeyHash:   r.KeyHash,
+		Admin:     r.Admin,
+		RevokedAt: r.RevokedAt,
+		CreatedAt: r.CreatedAt,
+	}
+	for _, source := range r.Sources {
+		apiKey.Sources = append(apiKey.Sources, entity.TransactionSource(source))
+	}
+	for _, userID := range r.UserIDs {
+		apiKey.UserIDs = append(apiKey.UserIDs, int(userID))
+	}
+	return &apiKey
+}
+
+const selectAPIKeyByHashSQL = `
+	SELECT id, name, key_hash, sources, user_ids, admin, revoked_at, created_at
+	FROM api_keys
+	WHERE key_hash = $1`
+
+func (q *PostgresQuerier) SelectAPIKeyByHash(ctx context.Context, keyHash string) (_ *entity.APIKey, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectAPIKeyByHash", selectAPIKeyByHashSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var row apiKeyRow
+
+	err = q.dbConn.GetContext(
+		ctx,
+		&row,
+		selectAPIKeyByHashSQL,
+		keyHash)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return row.toEntity(), nil
+}
+
+const insertAPIKeySQL = `
+	INSERT INTO api_keys ( name, key_hash, sources,                   user_ids, admin, created_at)
+	VALUES               ( $1,   $2,       $3::transaction_sources[], $4,       $5,    $6)
+	RETURNING id`
+
+func (q *PostgresQuerier) InsertAPIKey(ctx context.Context, apiKey entity.APIKey) (_ int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.InsertAPIKey", insertAPIKeySQL)
+	defer func() { tracing.End(span, err) }()
+
+	sources := pq.StringArray{}
+	for _, source := range apiKey.Sources {
+		sources = append(sources, string(source))
+	}
+	// No user IDs are stored as NULL, allowing every user
+	var userIDs pq.Int64Array
+	for _, userID := range apiKey.UserIDs {
+		userIDs = append(userIDs, int64(userID))
+	}
+
+	var id int
+
+	err = q.dbConn.GetContext(
+		ctx,
+		&id,
+		insertAPIKeySQL,
+		apiKey.Name,
+		apiKey.KeyHash,
+		sources,
+		userIDs,
+		apiKey.Admin,
+		apiKey.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("inserting api key: %w", err)
+	}
+
+	return id, nil
+}
+
+// revokeAPIKeySQL keeps the revocation time of a key already revoked
+const revokeAPIKeySQL = `
+	UPDATE api_keys
+	SET revoked_at = COALESCE(revoked_at, $2)
+	WHERE id = $1
+	RETURNING id, name, key_hash, sources, user_ids, admin, revoked_at, created_at`
+
+func (q *PostgresQuerier) RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) (_ *entity.APIKey, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.RevokeAPIKey", revokeAPIKeySQL)
+	defer func() { tracing.End(span, err) }()
+
+	var row apiKeyRow
+
+	err = q.dbConn.GetContext(
+		ctx,
+		&row,
+		revokeAPIKeySQL,
+		keyID,
+		revokedAt)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, fmt.Errorf("revoking api key: %w", err)
+		}
+	}
+	return row.toEntity(), nil
+}
+
+const insertHoldSQL = `
+	INSERT INTO holds ( user_id, amount, currency, status, transaction_source, expires_at, created_at, updated_at)
+	VALUES            ( $1,      $2,     $3,       $4,     $5,                 $6,         $7,         $8)
+	RETURNING id`
+
+func (q *PostgresQuerier) InsertHold(ctx context.Context, txn sqlx.Tx, hold entity.Hold) (_ int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.InsertHold", insertHoldSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var id int
+
+	err = txn.GetContext(
+		ctx,
+		&id,
+		insertHoldSQL,
+		hold.UserID,
+		hold.Amount,
+		hold.Currency,
+		hold.Status,
+		hold.TransactionSource,
+		hold.ExpiresAt,
+		hold.CreatedAt,
+		hold.UpdatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("inserting hold: %w", err)
+	}
+
+	return id, nil
+}
+
+const holdColumns = `id, user_id, amount, currency, status, transaction_source, transaction_id, expires_at, created_at, updated_at`
+
+const selectHoldSQL = `SELECT ` + holdColumns + ` FROM holds WHERE id = $1`
+
+func (q *PostgresQuerier) SelectHold(ctx context.Context, holdID int) (_ *entity.Hold, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectHold", selectHoldSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var hold entity.Hold
+
+	err = q.dbConn.GetContext(
+		ctx,
+		&hold,
+		selectHoldSQL,
+		holdID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &hold, nil
+}
+
+const selectHoldForUpdateSQL = `SELECT ` + holdColumns + ` FROM holds WHERE id = $1 FOR UPDATE`
+
+// SelectHoldForUpdate locks the hold row until the end of the given transaction
+func (q *PostgresQuerier) SelectHoldForUpdate(ctx context.Context, txn sqlx.Tx, holdID int) (_ *entity.Hold, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectHoldForUpdate", selectHoldForUpdateSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var hold entity.Hold
+
+	err = txn.GetContext(
+		ctx,
+		&hold,
+		selectHoldForUpdateSQL,
+		holdID)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		} else {
+			return nil, err
+		}
+	}
+	return &hold, nil
+}
+
+const updateHoldSQL = `
+	UPDATE holds
+	SET 
+		status = :status,
+		transaction_id = :transaction_id,
+		updated_at = :updated_at
+	WHERE id = :id`
+
+// UpdateHold stores the status of the hold, along with the transaction it was captured into
+func (q *PostgresQuerier) UpdateHold(ctx context.Context, txn sqlx.Tx, hold entity.Hold) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.UpdateHold", updateHoldSQL)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := txn.NamedExecContext(ctx, updateHoldSQL, hold)
+	if err != nil {
+		return fmt.Errorf("updating hold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no hold found with ID: %d", hold.ID)
+	}
+
+	return nil
+}
+
+const upsertLedgerAccountSQL = `
+	WITH inserted AS (
+		INSERT INTO ledger_accounts ( account_type, user_id, currency, created_at)
+		VALUES                      ( $1,           $2,      $3,       $4)
+		ON CONFLICT DO NOTHING
+		RETURNING id
+	)
+	SELECT id FROM inserted
+	UNION ALL
+	SELECT id FROM ledger_accounts WHERE account_type = $1 AND user_id IS NOT DISTINCT FROM $2 AND currency = $3`
+
+const selectLedgerAccountIDSQL = `SELECT id FROM ledger_accounts WHERE account_type = $1 AND user_id IS NOT DISTINCT FROM $2 AND currency = $3`
+
+const insertLedgerPostingSQL = `
+	INSERT INTO ledger_postings ( game_result_id, account_id, amount, created_at)
+	VALUES                      ( $1,             $2,         $3,     $4)`
+
+// InsertLedgerPostings records the postings of a game result, opening their ledger accounts if missing
+// The database rejects the transaction on commit if the postings of a game result do not sum to zero
+func (q *PostgresQuerier) InsertLedgerPostings(ctx context.Context, txn sqlx.Tx, postings []entity.LedgerPosting) (err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.InsertLedgerPostings", insertLedgerPostingSQL)
+	defer func() { tracing.End(span, err) }()
+
+	for _, posting := range postings {
+		accountID, err := q.ledgerAccountID(ctx, txn, posting.LedgerAccount, posting.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("opening ledger account: %w", err)
+		}
+
+		_, err = txn.ExecContext(ctx, insertLedgerPostingSQL, posting.GameResultID, accountID, posting.Amount, posting.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("inserting ledger posting: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ledgerAccountID returns the ID of the ledger account, opening it if missing
+// The ledger accounts are never locked, so the house accounts shared by every user do not serialize their transactions
+func (q *PostgresQuerier) ledgerAccountID(ctx context.Context, txn sqlx.Tx, account entity.LedgerAccount, createdAt time.Time) (int, error) {
+	var id int
+
+	err := txn.GetContext(ctx, &id, upsertLedgerAccountSQL, account.Type, account.UserID, account.Currency, createdAt)
+
+	// A concurrent transaction opened the same account in the meantime, visible once committed
+	if errors.Is(err, sql.ErrNoRows) {
+		err = txn.GetContext(ctx, &id, selectLedgerAccountIDSQL, account.Type, account.UserID, account.Currency)
+	}
+
+	return id, err
+}
+
+const selectLedgerBalancesSQL = `
+	SELECT ledger_accounts.account_type, ledger_accounts.user_id, ledger_accounts.currency, COALESCE(SUM(ledger_postings.amount), 0) AS balance
+	FROM ledger_accounts
+	LEFT JOIN ledger_postings ON ledger_postings.account_id = ledger_accounts.id
+	WHERE ledger_accounts.user_id = $1
+	GROUP BY ledger_accounts.id
+	ORDER BY ledger_accounts.currency, ledger_accounts.account_type`
+
+// SelectLedgerBalances returns the balances of the ledger accounts of the user wallets, the sums of their postings
+func (q *PostgresQuerier) SelectLedgerBalances(ctx context.Context, userID int) (_ []entity.LedgerBalance, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectLedgerBalances", selectLedgerBalancesSQL)
+	defer func() { tracing.End(span, err) }()
+
+	balances := []entity.LedgerBalance{}
+	err = q.dbConn.SelectContext(ctx, &balances, selectLedgerBalancesSQL, userID)
+	if err != nil {
+		return nil, fmt.Errorf("selecting ledger balances: %w", err)
+	}
+	return balances, nil
+}
+
+// latestLedgerSnapshotSQL joins the latest snapshot of each ledger account taken at or before $1
+const latestLedgerSnapshotSQL = `
+	LEFT JOIN LATERAL (
+	    SELECT ledger_snapshots.balance, ledger_snapshots.taken_at
+	    FROM ledger_snapshots
+	    WHERE ledger_snapshots.account_id = ledger_accounts.id AND ledger_snapshots.taken_at <= $1
+	    ORDER BY ledger_snapshots.taken_at DESC
+	    LIMIT 1
+	) AS latest ON true`
+
+// ledgerBalanceAtSQL is the balance of the ledger account as of $1,
+// its latest snapshot along with the postings since then
+const ledgerBalanceAtSQL = `
+	COALESCE(latest.balance, 0) + COALESCE((
+	    SELECT SUM(ledger_postings.amount)
+	    FROM ledger_postings
+	    WHERE ledger_postings.account_id = ledger_accounts.id
+	      AND ledger_postings.created_at <= $1
+	      AND ledger_postings.created_at > COALESCE(latest.taken_at, '-infinity')
+	), 0)`
+
+const selectLedgerBalancesAtSQL = `
+	SELECT ledger_accounts.account_type, ledger_accounts.user_id, ledger_accounts.currency,` + ledgerBalanceAtSQL + ` AS balance
+	FROM ledger_accounts` + latestLedgerSnapshotSQL + `
+	WHERE ledger_accounts.user_id = $2
+	  AND EXISTS (SELECT 1 FROM ledger_postings WHERE ledger_postings.account_id = ledger_accounts.id AND ledger_postings.created_at <= $1)
+	ORDER BY ledger_accounts.currency, ledger_accounts.account_type`
+
+// SelectLedgerBalancesAt returns the balances of the ledger accounts of the user wallets as of the given time,
+// leaving out the accounts without postings by then
+func (q *PostgresQuerier) SelectLedgerBalancesAt(ctx context.Context, userID int, at time.Time) (_ []entity.LedgerBalance, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectLedgerBalancesAt", selectLedgerBalancesAtSQL)
+	defer func() { tracing.End(span, err) }()
+
+	balances := []entity.LedgerBalance{}
+	err = q.dbConn.SelectContext(ctx, &balances, selectLedgerBalancesAtSQL, at, userID)
+	if err != nil {
+		return nil, fmt.Errorf("selecting ledger balances: %w", err)
+	}
+	return balances, nil
+}
+
+const insertLedgerSnapshotsSQL = `
+	INSERT INTO ledger_snapshots (account_id, balance, taken_at)
+	SELECT ledger_accounts.id,` + ledgerBalanceAtSQL + `, $1
+	FROM ledger_accounts` + latestLedgerSnapshotSQL + `
+	ON CONFLICT (account_id, taken_at) DO NOTHING`
+
+// InsertLedgerSnapshots records the balances of every ledger account as of the given time,
+// returning the number of snapshots taken, none when they were already taken at that time
+func (q *PostgresQuerier) InsertLedgerSnapshots(ctx context.Context, takenAt time.Time) (_ int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.InsertLedgerSnapshots", insertLedgerSnapshotsSQL)
+	defer func() { tracing.End(span, err) }()
+
+	result, err := q.dbConn.ExecContext(ctx, insertLedgerSnapshotsSQL, takenAt)
+	if err != nil {
+		return 0, fmt.Errorf("inserting ledger snapshots: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("counting ledger snapshots: %w", err)
+	}
+	return int(count), nil
+}
+
+const countWalletsSQL = `SELECT COUNT(*) FROM wallets`
+
+func (q *PostgresQuerier) CountWallets(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.CountWallets", countWalletsSQL)
+	defer func() { tracing.End(span, err) }()
+
+	var count int
+	err = q.dbConn.GetContext(ctx, &count, countWalletsSQL)
+	if err != nil {
+		return 0, fmt.Errorf("counting wallets: %w", err)
+	}
+	return count, nil
+}
+
+const selectBalanceDiscrepanciesSQL = `
+	SELECT COALESCE(wallets.user_id, history.user_id) AS user_id,
+	       COALESCE(wallets.currency, history.currency) AS currency,
+	       COALESCE(wallets.balance + wallets.bonus_balance, 0) AS balance,
+	       COALESCE(history.balance, 0) AS expected_balance
+	FROM wallets
+	FULL OUTER JOIN (
+	    SELECT user_id, currency, SUM(CASE WHEN game_status = 'win' THEN amount ELSE -amount END) AS balance
+	    FROM game_results
+	    GROUP BY user_id, currency
+	) AS history ON history.user_id = wallets.user_id AND history.currency = wallets.currency
+	WHERE COALESCE(wallets.balance + wallets.bonus_balance, 0) <> COALESCE(history.balance, 0)
+	ORDER BY 1, 2`
+
+// SelectBalanceDiscrepancies returns the wallets whose cash and bonus balance differs from the sum of their game results,
+// the wallets without game results, and the game results without wallet, ordered by user and currency
+func (q *PostgresQuerier) SelectBalanceDiscrepancies(ctx context.Context) (_ []entity.BalanceDiscrepancy, err error) {
+	ctx, span := tracing.StartDBSpan(ctx, "PostgresQuerier.SelectBalanceDiscrepancies", selectBalanceDiscrepanciesSQL)
+	defer func() { tracing.End(span, err) }()
+
+	discrepancies := []entity.BalanceDiscrepancy{}
+	err = q.dbConn.SelectContext(ctx, &discrepancies, selectBalanceDiscrepanciesSQL)
+	if err != nil {
+		return nil, fmt.Errorf("selecting balance discrepancies: %w", err)
+	}
+	return discrepancies, nil
+}
@@ -50,6 +50,24 @@ func TestPostgresQuerier(t *testing.T) {
 		assert.Equal(t, expectedNumMigrations, versionInt, fmt.Sprintf("Number of migrations should match the version in the database. Expected: %d, Actual: %d", expectedNumMigrations, versionInt))
 	})
 
+	t.Run("HealthChecker_Success", func(t *testing.T) {
+		querier, err := NewPostgresQuerier(ctx, dbURL)
+		require.NoError(t, err)
+		defer querier.Close()
+
+		require.NoError(t, querier.Ping(ctx))
+
+		migrationFiles, err := fs.ReadDir("migrations")
+		require.NoError(t, err)
+
+		status, err := querier.MigrationStatus(ctx)
+		require.NoError(t, err)
+		require.Equal(t, uint(len(migrationFiles)/2), status.Version)
+		require.False(t, status.Dirty)
+
+		require.Equal(t, 25, querier.Stats().MaxOpenConnections)
+	})
+
 	t.Run("NewPostgresQuerier_InvalidURL", func(t *testing.T) {
 		_, err := NewPostgresQuerier(ctx, "invalid-url")
 		require.Error(t, err)
@@ -77,7 +95,8 @@ func TestDatabaseWithTransaction(t *testing.T) {
 			GameStatus:        entity.GameStatusWin,
 			TransactionSource: entity.TransactionSourceServer,
 			TransactionID:     "anything",
-			Amount:            10,
+			Amount:            entity.Money(1000),
+			Currency:          entity.DefaultCurrency,
 			CreatedAt:         time.Now(),
 		}
 
@@ -95,12 +114,12 @@ func TestDatabaseWithTransaction(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	t.Run("UpdateUserBalance_Success", func(t *testing.T) {
+	t.Run("UpdateWallet_Success", func(t *testing.T) {
 
 		// Start a transaction that is expected to WORK
 		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
 
-			err := q.UpdateUserBalance(ctx, *txn, 1, 100)
+			err := q.UpdateWallet(ctx, *txn, entity.Wallet{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(10055)})
 			require.NoError(t, err)
 
 			// No error, then the db commit() will happen
@@ -111,7 +130,7 @@ func TestDatabaseWithTransaction(t *testing.T) {
 		// Check the new balance
 		user, err := q.SelectUser(ctx, 1)
 		require.NoError(t, err)
-		require.Equal(t, 100.0, user.Balance)
+		require.Equal(t, entity.Money(10055), user.Balance)
 	})
 }
 
@@ -139,7 +158,8 @@ func TestDatabaseBasicOperations(t *testing.T) {
 			GameStatus:        entity.GameStatusWin,
 			TransactionSource: entity.TransactionSourceServer,
 			TransactionID:     "anything",
-			Amount:            10,
+			Amount:            entity.Money(1000),
+			Currency:          entity.DefaultCurrency,
 			CreatedAt:         time.Now(),
 		}
 
@@ -160,4 +180,653 @@ func TestDatabaseBasicOperations(t *testing.T) {
 		require.NoError(t, err)
 		require.True(t, exist)
 	})
+
+	t.Run("InsertGameResult_DuplicatedTransactionID", func(t *testing.T) {
+		gameResult := entity.GameResult{
+			UserID:            1,
+			GameStatus:        entity.GameStatusWin,
+			TransactionSource: entity.TransactionSourceServer,
+			TransactionID:     "anything",
+			Amount:            entity.Money(1000),
+			Currency:          entity.DefaultCurrency,
+			CreatedAt:         time.Now(),
+		}
+
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, gameResult)
+			return err
+		})
+		require.ErrorIs(t, err, entity.ErrTransactionIdExists)
+	})
+
+	t.Run("SelectGameResultByTransactionID_Success", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			gameResult, err := q.SelectGameResultByTransactionID(ctx, *txn, "anything")
+			require.NoError(t, err)
+			require.Equal(t, "anything", gameResult.TransactionID)
+			require.Nil(t, gameResult.ReversesTransactionID)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("SelectGameResultByTransactionID_NotFound", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			gameResult, err := q.SelectGameResultByTransactionID(ctx, *txn, "nothing")
+			require.NoError(t, err)
+			require.Nil(t, gameResult)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("InsertGameResult_DuplicatedReversal", func(t *testing.T) {
+		reversed := "anything"
+
+		for i := range 2 {
+			reversal := entity.GameResult{
+				UserID:                1,
+				GameStatus:            entity.GameStatusLose,
+				TransactionSource:     entity.TransactionSourceServer,
+				TransactionID:         fmt.Sprintf("reversal-attempt-%d", i),
+				Amount:                entity.Money(1000),
+				Currency:              entity.DefaultCurrency,
+				ReversesTransactionID: &reversed,
+				CreatedAt:             time.Now(),
+			}
+
+			err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+				_, err := q.InsertGameResult(ctx, *txn, reversal)
+				return err
+			})
+
+			// Only the first reversal is recorded
+			if i == 0 {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, entity.ErrTransactionIdExists)
+			}
+		}
+	})
+
+	t.Run("SelectUserForUpdate_Success", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			user, err := q.SelectUserForUpdate(ctx, *txn, userID)
+			require.NoError(t, err)
+			require.Equal(t, userID, user.ID)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("SelectUserForUpdate_NotFound", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			user, err := q.SelectUserForUpdate(ctx, *txn, 1000)
+			require.NoError(t, err)
+			require.Nil(t, user)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("SelectUser_ActiveByDefault", func(t *testing.T) {
+		user, err := q.SelectUser(ctx, userID)
+		require.NoError(t, err)
+		require.Equal(t, entity.UserStatusActive, user.Status)
+	})
+
+	t.Run("InsertUser_AfterSeededUsers", func(t *testing.T) {
+		id, err := q.InsertUser(ctx, entity.User{
+			Status:    entity.UserStatusActive,
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+		require.Greater(t, id, 9)
+
+		user, err := q.SelectUser(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, entity.Money(0), user.Balance)
+		require.Equal(t, entity.UserStatusActive, user.Status)
+	})
+
+	t.Run("UpdateUserStatus_Success", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateUserStatus(ctx, *txn, 2, entity.UserStatusFrozen)
+		})
+		require.NoError(t, err)
+
+		user, err := q.SelectUser(ctx, 2)
+		require.NoError(t, err)
+		require.Equal(t, entity.UserStatusFrozen, user.Status)
+	})
+
+	t.Run("UpdateUserStatus_NotFound", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateUserStatus(ctx, *txn, 1000, entity.UserStatusFrozen)
+		})
+		require.Error(t, err)
+	})
+}
+
+func TestDatabaseSelectGameResults(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	userID := 2
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+
+	// Three game results, one minute apart, the newest being the last one
+	for i, gameStatus := range []entity.GameStatus{entity.GameStatusWin, entity.GameStatusLose, entity.GameStatusWin} {
+		gameResult := entity.GameResult{
+			UserID:            userID,
+			GameStatus:        gameStatus,
+			TransactionSource: entity.TransactionSourceGame,
+			TransactionID:     fmt.Sprintf("history-%d", i),
+			Amount:            entity.Money(1000),
+			Currency:          entity.DefaultCurrency,
+			CreatedAt:         createdAt.Add(time.Duration(i) * time.Minute),
+		}
+
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, gameResult)
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	t.Run("SelectGameResults_NewestFirst", func(t *testing.T) {
+		gameResults, err := q.SelectGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, gameResults, 3)
+		require.Equal(t, "history-2", gameResults[0].TransactionID)
+		require.Equal(t, "history-0", gameResults[2].TransactionID)
+	})
+
+	t.Run("SelectGameResults_Cursor", func(t *testing.T) {
+		firstPage, err := q.SelectGameResults(ctx, entity.GameResultFilter{UserID: userID, Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, firstPage, 1)
+
+		cursor := entity.GameResultCursor{CreatedAt: firstPage[0].CreatedAt, ID: firstPage[0].ID}
+		secondPage, err := q.SelectGameResults(ctx, entity.GameResultFilter{UserID: userID, Cursor: &cursor, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, secondPage, 2)
+		require.Equal(t, "history-1", secondPage[0].TransactionID)
+	})
+
+	t.Run("SelectGameResults_Filters", func(t *testing.T) {
+		gameStatus := entity.GameStatusWin
+		from := createdAt.Add(time.Minute)
+
+		gameResults, err := q.SelectGameResults(ctx, entity.GameResultFilter{
+			UserID:      userID,
+			GameStatus:  &gameStatus,
+			CreatedFrom: &from,
+			Limit:       10,
+		})
+		require.NoError(t, err)
+		require.Len(t, gameResults, 1)
+		require.Equal(t, "history-2", gameResults[0].TransactionID)
+	})
+
+	t.Run("SelectGameResults_OtherUser", func(t *testing.T) {
+		gameResults, err := q.SelectGameResults(ctx, entity.GameResultFilter{UserID: 3, Limit: 10})
+		require.NoError(t, err)
+		require.Empty(t, gameResults)
+	})
+}
+
+func TestDatabaseSelectAPIKeyByHash(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	_, err := q.dbConn.ExecContext(ctx, `
+		INSERT INTO api_keys (name, key_hash, sources, user_ids, admin)
+		VALUES ('game-server', $1, '{game,server}', '{1,2}', FALSE), ('payments', $2, '{payment}', NULL, TRUE)`,
+		entity.HashAPIKey("game-key"), entity.HashAPIKey("payment-key"))
+	require.NoError(t, err)
+
+	t.Run("Restricted_Users", func(t *testing.T) {
+		apiKey, err := q.SelectAPIKeyByHash(ctx, entity.HashAPIKey("game-key"))
+		require.NoError(t, err)
+		require.NotNil(t, apiKey)
+		assert.Equal(t, "game-server", apiKey.Name)
+		assert.Equal(t, []entity.TransactionSource{entity.TransactionSourceGame, entity.TransactionSourceServer}, apiKey.Sources)
+		assert.Equal(t, []int{1, 2}, apiKey.UserIDs)
+		assert.False(t, apiKey.Admin)
+		assert.False(t, apiKey.Revoked())
+	})
+
+	t.Run("Any_User", func(t *testing.T) {
+		apiKey, err := q.SelectAPIKeyByHash(ctx, entity.HashAPIKey("payment-key"))
+		require.NoError(t, err)
+		require.NotNil(t, apiKey)
+		assert.Equal(t, []entity.TransactionSource{entity.TransactionSourcePayment}, apiKey.Sources)
+		assert.Empty(t, apiKey.UserIDs)
+		assert.True(t, apiKey.Admin)
+	})
+
+	t.Run("Not_Found", func(t *testing.T) {
+		apiKey, err := q.SelectAPIKeyByHash(ctx, entity.HashAPIKey("unknown"))
+		require.NoError(t, err)
+		require.Nil(t, apiKey)
+	})
+}
+
+func TestDatabaseInsertAndRevokeAPIKey(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	id, err := q.InsertAPIKey(ctx, entity.APIKey{
+		Name:      "acme games",
+		KeyHash:   entity.HashAPIKey("acme-key"),
+		Sources:   []entity.TransactionSource{entity.TransactionSourceGame, entity.TransactionSourceServer},
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, id)
+
+	apiKey, err := q.SelectAPIKeyByHash(ctx, entity.HashAPIKey("acme-key"))
+	require.NoError(t, err)
+	require.NotNil(t, apiKey)
+	assert.Equal(t, id, apiKey.ID)
+	assert.Equal(t, []entity.TransactionSource{entity.TransactionSourceGame, entity.TransactionSourceServer}, apiKey.Sources)
+	assert.Empty(t, apiKey.UserIDs, "allowing every user")
+	assert.False(t, apiKey.Admin)
+
+	revokedAt := time.Now().Truncate(time.Microsecond)
+	revoked, err := q.RevokeAPIKey(ctx, id, revokedAt)
+	require.NoError(t, err)
+	require.NotNil(t, revoked)
+	assert.True(t, revoked.RevokedAt.Equal(revokedAt))
+
+	// Revoking it again keeps the original revocation time
+	revoked, err = q.RevokeAPIKey(ctx, id, revokedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, revoked.RevokedAt.Equal(revokedAt))
+
+	revoked, err = q.RevokeAPIKey(ctx, 999, revokedAt)
+	require.NoError(t, err)
+	assert.Nil(t, revoked)
+}
+
+func TestDatabaseWallets(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	userID := 1
+
+	t.Run("SelectWalletForUpdate_Missing", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			wallet, err := q.SelectWalletForUpdate(ctx, *txn, userID, "JPY")
+			require.NoError(t, err)
+			require.Nil(t, wallet)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("UpdateWallet_Upsert", func(t *testing.T) {
+		for _, balance := range []entity.Money{entity.Money(100000), entity.Money(150000)} {
+			err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+				return q.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: "JPY", Balance: balance})
+			})
+			require.NoError(t, err)
+		}
+
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			wallet, err := q.SelectWalletForUpdate(ctx, *txn, userID, "JPY")
+			require.NoError(t, err)
+			require.NotNil(t, wallet)
+			assert.Equal(t, entity.Money(150000), wallet.Balance)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("SelectWallets_OrderedByCurrency", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: entity.DefaultCurrency, Balance: entity.Money(2500)})
+		})
+		require.NoError(t, err)
+
+		wallets, err := q.SelectWallets(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, wallets, 2)
+		assert.Equal(t, entity.DefaultCurrency, wallets[0].Currency)
+		assert.Equal(t, entity.Money(2500), wallets[0].Balance)
+		assert.Equal(t, entity.Currency("JPY"), wallets[1].Currency)
+
+		// The user balance is the one in the default currency
+		user, err := q.SelectUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.Money(2500), user.Balance)
+	})
+
+	t.Run("UpdateWallet_Negative", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: "USD", Balance: entity.Money(-1)})
+		})
+		require.Error(t, err)
+
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateWallet(ctx, *txn, entity.Wallet{UserID: userID, Currency: "USD", BonusBalance: entity.Money(-1)})
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("UpdateWallet_BonusBalance", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.UpdateWallet(ctx, *txn, entity.Wallet{
+				UserID:              userID,
+				Currency:            entity.DefaultCurrency,
+				Balance:             entity.Money(2500),
+				BonusBalance:        entity.Money(2000),
+				WageringRequirement: entity.Money(10000),
+			})
+		})
+		require.NoError(t, err)
+
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			wallet, err := q.SelectWalletForUpdate(ctx, *txn, userID, entity.DefaultCurrency)
+			require.NoError(t, err)
+			require.NotNil(t, wallet)
+			assert.Equal(t, entity.Money(2000), wallet.BonusBalance)
+			assert.Equal(t, entity.Money(10000), wallet.WageringRequirement)
+			return nil
+		})
+		require.NoError(t, err)
+
+		user, err := q.SelectUser(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.Money(2500), user.Balance)
+		assert.Equal(t, entity.Money(2000), user.BonusBalance)
+		assert.Equal(t, entity.Money(10000), user.WageringRequirement)
+	})
+}
+
+func TestDatabaseHolds(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	userID := 1
+	now := time.Now()
+
+	insertHold := func(t *testing.T, amount entity.Money, expiresAt time.Time) int {
+		var id int
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+			id, err = q.InsertHold(ctx, *txn, entity.Hold{
+				UserID:            userID,
+				Amount:            amount,
+				Currency:          entity.DefaultCurrency,
+				Status:            entity.HoldStatusOpen,
+				TransactionSource: entity.TransactionSourceGame,
+				ExpiresAt:         expiresAt,
+				CreatedAt:         now,
+				UpdatedAt:         now,
+			})
+			return err
+		})
+		require.NoError(t, err)
+		return id
+	}
+
+	openID := insertHold(t, entity.Money(1000), now.Add(time.Minute))
+	insertHold(t, entity.Money(500), now.Add(-time.Minute))
+
+	t.Run("SelectUser_HeldBalance", func(t *testing.T) {
+		user, err := q.SelectUser(ctx, userID)
+		require.NoError(t, err)
+
+		// The expired hold is not counted
+		assert.Equal(t, entity.Money(1000), user.HeldBalance)
+		assert.Equal(t, user.Balance-entity.Money(1000), user.AvailableBalance())
+	})
+
+	t.Run("UpdateHold_Captured", func(t *testing.T) {
+		transactionID := "bet-1"
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			hold, err := q.SelectHoldForUpdate(ctx, *txn, openID)
+			require.NoError(t, err)
+			require.NotNil(t, hold)
+
+			hold.Status = entity.HoldStatusCaptured
+			hold.TransactionID = &transactionID
+			hold.UpdatedAt = time.Now()
+			return q.UpdateHold(ctx, *txn, *hold)
+		})
+		require.NoError(t, err)
+
+		hold, err := q.SelectHold(ctx, openID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.HoldStatusCaptured, hold.Status)
+		assert.Equal(t, &transactionID, hold.TransactionID)
+
+		err = q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			user, err := q.SelectUserForUpdate(ctx, *txn, userID)
+			require.NoError(t, err)
+			assert.Equal(t, entity.Money(0), user.HeldBalance)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("SelectHold_NotFound", func(t *testing.T) {
+		hold, err := q.SelectHold(ctx, 999)
+		require.NoError(t, err)
+		require.Nil(t, hold)
+	})
+}
+
+func TestDatabaseSelectGameResultsByRoundID(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	userID := 1
+	roundID, otherRoundID := "round-1", "round-2"
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	insertGameResult := func(t *testing.T, transactionID string, gameStatus entity.GameStatus, roundID *string, createdAt time.Time) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, entity.GameResult{
+				UserID:            userID,
+				GameStatus:        gameStatus,
+				TransactionSource: entity.TransactionSourceGame,
+				TransactionID:     transactionID,
+				Amount:            entity.Money(100),
+				Currency:          entity.DefaultCurrency,
+				RoundID:           roundID,
+				CreatedAt:         createdAt,
+			})
+			return err
+		})
+		require.NoError(t, err)
+	}
+
+	insertGameResult(t, "payout-1", entity.GameStatusWin, &roundID, now.Add(time.Minute))
+	insertGameResult(t, "stake-1", entity.GameStatusLose, &roundID, now)
+	insertGameResult(t, "stake-2", entity.GameStatusLose, &otherRoundID, now)
+	insertGameResult(t, "no-round", entity.GameStatusLose, nil, now)
+
+	var gameResults []entity.GameResult
+	err := q.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+		gameResults, err = q.SelectGameResultsByRoundID(ctx, *txn, userID, roundID)
+		return err
+	})
+	require.NoError(t, err)
+
+	// Oldest-first, only the game results of the round
+	require.Len(t, gameResults, 2)
+	assert.Equal(t, "stake-1", gameResults[0].TransactionID)
+	assert.Equal(t, "payout-1", gameResults[1].TransactionID)
+	assert.Equal(t, &roundID, gameResults[0].RoundID)
+
+	err = q.WithTransaction(ctx, func(txn *sqlx.Tx) (err error) {
+		gameResults, err = q.SelectGameResultsByRoundID(ctx, *txn, userID+1, roundID)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Empty(t, gameResults)
+
+	// The same outside of a db transaction
+	gameResults, err = q.SelectRoundGameResults(ctx, userID, roundID)
+	require.NoError(t, err)
+	require.Len(t, gameResults, 2)
+	assert.Equal(t, "stake-1", gameResults[0].TransactionID)
+}
+
+func TestDatabaseLedger(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	userID := 1
+	now := time.Now()
+
+	insertPostings := func(gameResultID int, cash, bonus entity.Money, counterparty entity.LedgerAccount) error {
+		return q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.InsertLedgerPostings(ctx, *txn, []entity.LedgerPosting{
+				{GameResultID: gameResultID, Amount: cash, LedgerAccount: entity.UserCashAccount(userID, entity.DefaultCurrency), CreatedAt: now},
+				{GameResultID: gameResultID, Amount: bonus, LedgerAccount: entity.UserBonusAccount(userID, entity.DefaultCurrency), CreatedAt: now},
+				{GameResultID: gameResultID, Amount: -(cash + bonus), LedgerAccount: counterparty, CreatedAt: now},
+			})
+		})
+	}
+
+	t.Run("InsertLedgerPostings_Balanced", func(t *testing.T) {
+		require.NoError(t, insertPostings(1, entity.Money(5000), entity.Money(2000), entity.CounterpartyAccount(entity.TransactionSourcePayment, entity.DefaultCurrency)))
+		require.NoError(t, insertPostings(2, entity.Money(-1000), entity.Money(-500), entity.CounterpartyAccount(entity.TransactionSourceGame, entity.DefaultCurrency)))
+
+		balances, err := q.SelectLedgerBalances(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, []entity.LedgerBalance{
+			{LedgerAccount: entity.UserCashAccount(userID, entity.DefaultCurrency), Balance: entity.Money(4000)},
+			{LedgerAccount: entity.UserBonusAccount(userID, entity.DefaultCurrency), Balance: entity.Money(1500)},
+		}, balances)
+	})
+
+	t.Run("InsertLedgerPostings_Unbalanced", func(t *testing.T) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.InsertLedgerPostings(ctx, *txn, []entity.LedgerPosting{
+				{GameResultID: 3, Amount: entity.Money(1000), LedgerAccount: entity.UserCashAccount(userID, entity.DefaultCurrency), CreatedAt: now},
+			})
+		})
+		require.Error(t, err)
+
+		// The rejected posting is rolled back
+		balances, err := q.SelectLedgerBalances(ctx, userID)
+		require.NoError(t, err)
+		assert.Equal(t, entity.Money(4000), balances[0].Balance)
+	})
+
+	t.Run("SelectLedgerBalances_OtherUser", func(t *testing.T) {
+		balances, err := q.SelectLedgerBalances(ctx, 2)
+		require.NoError(t, err)
+		assert.Empty(t, balances)
+	})
+}
+
+func TestDatabaseLedgerSnapshots(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	userID := 1
+	midnight := time.Now().UTC().Truncate(24 * time.Hour).Add(-24 * time.Hour)
+
+	insertPostings := func(gameResultID int, cash entity.Money, createdAt time.Time) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			return q.InsertLedgerPostings(ctx, *txn, []entity.LedgerPosting{
+				{GameResultID: gameResultID, Amount: cash, LedgerAccount: entity.UserCashAccount(userID, entity.DefaultCurrency), CreatedAt: createdAt},
+				{GameResultID: gameResultID, Amount: -cash, LedgerAccount: entity.CounterpartyAccount(entity.TransactionSourceGame, entity.DefaultCurrency), CreatedAt: createdAt},
+			})
+		})
+		require.NoError(t, err)
+	}
+	cashBalanceAt := func(at time.Time) []entity.LedgerBalance {
+		balances, err := q.SelectLedgerBalancesAt(ctx, userID, at)
+		require.NoError(t, err)
+		return balances
+	}
+	cashBalance := func(balance entity.Money) []entity.LedgerBalance {
+		return []entity.LedgerBalance{{LedgerAccount: entity.UserCashAccount(userID, entity.DefaultCurrency), Balance: balance}}
+	}
+
+	insertPostings(1, entity.Money(5000), midnight.Add(-2*time.Hour))
+	insertPostings(2, entity.Money(-1000), midnight.Add(-time.Hour))
+
+	t.Run("SelectLedgerBalancesAt_WithoutSnapshot", func(t *testing.T) {
+		assert.Empty(t, cashBalanceAt(midnight.Add(-3*time.Hour)))
+		assert.Equal(t, cashBalance(entity.Money(5000)), cashBalanceAt(midnight.Add(-90*time.Minute)))
+		assert.Equal(t, cashBalance(entity.Money(4000)), cashBalanceAt(midnight))
+	})
+
+	t.Run("InsertLedgerSnapshots", func(t *testing.T) {
+		// The cash account of the user and the house account of the games
+		snapshots, err := q.InsertLedgerSnapshots(ctx, midnight)
+		require.NoError(t, err)
+		assert.Equal(t, 2, snapshots)
+
+		// Snapshotting the same time again is a no-op
+		snapshots, err = q.InsertLedgerSnapshots(ctx, midnight)
+		require.NoError(t, err)
+		assert.Equal(t, 0, snapshots)
+	})
+
+	t.Run("SelectLedgerBalancesAt_WithSnapshot", func(t *testing.T) {
+		insertPostings(3, entity.Money(2500), midnight.Add(time.Hour))
+
+		// Before the snapshot the balances still come from the postings
+		assert.Equal(t, cashBalance(entity.Money(5000)), cashBalanceAt(midnight.Add(-90*time.Minute)))
+
+		// From the snapshot on they add up the postings made after it
+		assert.Equal(t, cashBalance(entity.Money(4000)), cashBalanceAt(midnight))
+		assert.Equal(t, cashBalance(entity.Money(4000)), cashBalanceAt(midnight.Add(30*time.Minute)))
+		assert.Equal(t, cashBalance(entity.Money(6500)), cashBalanceAt(midnight.Add(2*time.Hour)))
+	})
+
+	t.Run("SelectLedgerBalancesAt_OtherUser", func(t *testing.T) {
+		balances, err := q.SelectLedgerBalancesAt(ctx, 2, midnight)
+		require.NoError(t, err)
+		assert.Empty(t, balances)
+	})
+}
+
+func TestDatabaseBalanceDiscrepancies(t *testing.T) {
+	ctx, teardownTest, q := setupTestQuerier(t)
+	defer teardownTest(t)
+
+	record := func(userID int, status entity.GameStatus, amount entity.Money, transactionID string, wallet entity.Wallet) {
+		err := q.WithTransaction(ctx, func(txn *sqlx.Tx) error {
+			_, err := q.InsertGameResult(ctx, *txn, entity.GameResult{
+				UserID:            userID,
+				GameStatus:        status,
+				TransactionSource: entity.TransactionSourceGame,
+				TransactionID:     transactionID,
+				Amount:            amount,
+				Currency:          entity.DefaultCurrency,
+				CreatedAt:         time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+			return q.UpdateWallet(ctx, *txn, wallet)
+		})
+		require.NoError(t, err)
+	}
+
+	// The cash and bonus balances of the first user match its history
+	record(1, entity.GameStatusWin, entity.Money(5000), "win-1", entity.Wallet{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(5000)})
+	record(1, entity.GameStatusLose, entity.Money(1000), "lose-1", entity.Wallet{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(3000), BonusBalance: entity.Money(1000)})
+
+	// The balance of the second user was changed behind the history
+	record(2, entity.GameStatusWin, entity.Money(2000), "win-2", entity.Wallet{UserID: 2, Currency: entity.DefaultCurrency, Balance: entity.Money(2500)})
+
+	count, err := q.CountWallets(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	discrepancies, err := q.SelectBalanceDiscrepancies(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []entity.BalanceDiscrepancy{
+		{UserID: 2, Currency: entity.DefaultCurrency, Balance: entity.Money(2500), ExpectedBalance: entity.Money(2000)},
+	}, discrepancies)
 }
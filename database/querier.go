@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"github.com/ildomm/account-balance-manager/entity"
 	"github.com/jmoiron/sqlx"
@@ -12,8 +13,29 @@ type Querier interface {
 	WithTransaction(ctx context.Context, fn func(*sqlx.Tx) error) (err error)
 
 	SelectUser(ctx context.Context, userID int) (*entity.User, error)
+	SelectUserForUpdate(ctx context.Context, txn sqlx.Tx, userID int) (*entity.User, error)
+	SelectWallets(ctx context.Context, userID int) ([]entity.Wallet, error)
+	SelectWalletForUpdate(ctx context.Context, txn sqlx.Tx, userID int, currency entity.Currency) (*entity.Wallet, error)
 	TransactionIDExist(ctx context.Context, transactionID string) (bool, error)
+	SelectGameResults(ctx context.Context, filter entity.GameResultFilter) ([]entity.GameResult, error)
+	SelectGameResultByTransactionID(ctx context.Context, txn sqlx.Tx, transactionID string) (*entity.GameResult, error)
+	SelectGameResultsByRoundID(ctx context.Context, txn sqlx.Tx, userID int, roundID string) ([]entity.GameResult, error)
+	SelectRoundGameResults(ctx context.Context, userID int, roundID string) ([]entity.GameResult, error)
+	SelectHold(ctx context.Context, holdID int) (*entity.Hold, error)
+	SelectHoldForUpdate(ctx context.Context, txn sqlx.Tx, holdID int) (*entity.Hold, error)
+	SelectLedgerBalances(ctx context.Context, userID int) ([]entity.LedgerBalance, error)
+	SelectLedgerBalancesAt(ctx context.Context, userID int, at time.Time) ([]entity.LedgerBalance, error)
+	CountWallets(ctx context.Context) (int, error)
+	SelectBalanceDiscrepancies(ctx context.Context) ([]entity.BalanceDiscrepancy, error)
 
+	InsertUser(ctx context.Context, user entity.User) (int, error)
 	InsertGameResult(ctx context.Context, txn sqlx.Tx, gameResult entity.GameResult) (int, error)
-	UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userID int, balance float64) error
+	UpdateWallet(ctx context.Context, txn sqlx.Tx, wallet entity.Wallet) error
+	UpdateUserStatus(ctx context.Context, txn sqlx.Tx, userID int, status entity.UserStatus) error
+	InsertHold(ctx context.Context, txn sqlx.Tx, hold entity.Hold) (int, error)
+	UpdateHold(ctx context.Context, txn sqlx.Tx, hold entity.Hold) error
+	InsertLedgerPostings(ctx context.Context, txn sqlx.Tx, postings []entity.LedgerPosting) error
+	InsertLedgerSnapshots(ctx context.Context, takenAt time.Time) (int, error)
+	InsertAPIKey(ctx context.Context, apiKey entity.APIKey) (int, error)
+	RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) (*entity.APIKey, error)
 }
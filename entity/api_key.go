@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"time"
+)
+
+// apiKeyPrefix makes the keys easy to recognize, eg: in a leaked configuration
+const apiKeyPrefix = "abm_"
+
+// APIKey authorizes its bearer to post transactions of some sources only,
+// and optionally for some users only.
+// Only the admin keys can manage the users themselves.
+// Only the hash of the key is known, see HashAPIKey.
+type APIKey struct {
+	ID        int
+	Name      string
+	KeyHash   string
+	Sources   []TransactionSource
+	UserIDs   []int // Empty allows every user
+	Admin     bool
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// GenerateAPIKey returns a new random API key, to be handed to its bearer
+// and stored through its hash only
+func GenerateAPIKey() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(bytes), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 of the key, as stored in the database
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Revoked tells whether the key can no longer be used
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// AllowsSource tells whether the key can post transactions of the given source
+func (k APIKey) AllowsSource(source TransactionSource) bool {
+	return slices.Contains(k.Sources, source)
+}
+
+// AllowsUser tells whether the key can operate on the given user
+func (k APIKey) AllowsUser(userID int) bool {
+	return len(k.UserIDs) == 0 || slices.Contains(k.UserIDs, userID)
+}
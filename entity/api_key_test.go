@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "abm_"))
+	assert.Len(t, key, len("abm_")+64)
+
+	other, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestHashAPIKey(t *testing.T) {
+	// echo -n secret | sha256sum
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", HashAPIKey("secret"))
+}
+
+func TestAPIKeyAllowsSource(t *testing.T) {
+	key := APIKey{Sources: []TransactionSource{TransactionSourceGame}}
+
+	assert.True(t, key.AllowsSource(TransactionSourceGame))
+	assert.False(t, key.AllowsSource(TransactionSourcePayment))
+	assert.False(t, key.AllowsSource(TransactionSourceServer))
+}
+
+func TestAPIKeyAllowsUser(t *testing.T) {
+	assert.True(t, APIKey{}.AllowsUser(1), "no user restriction")
+
+	key := APIKey{UserIDs: []int{1, 2}}
+	assert.True(t, key.AllowsUser(2))
+	assert.False(t, key.AllowsUser(3))
+}
+
+func TestAPIKeyRevoked(t *testing.T) {
+	now := time.Now()
+	assert.False(t, APIKey{}.Revoked())
+	assert.True(t, APIKey{RevokedAt: &now}.Revoked())
+}
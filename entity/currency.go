@@ -0,0 +1,78 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code, eg: "EUR"
+type Currency string
+
+// DefaultCurrency is the currency of the transactions and holds given none,
+// and the one of the balance reported on the users
+const DefaultCurrency Currency = "EUR"
+
+// currencyMinorUnits lists the supported currencies along with their number of minor units
+// Money holds hundredths, so currencies with more than MoneyScale minor units can not be supported
+var currencyMinorUnits = map[Currency]int{
+	"AUD": 2,
+	"BRL": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"CLP": 0,
+	"DKK": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"ISK": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"MXN": 2,
+	"NOK": 2,
+	"NZD": 2,
+	"PLN": 2,
+	"SEK": 2,
+	"USD": 2,
+}
+
+// ParseCurrency parses a case-insensitive ISO 4217 code into a supported Currency
+func ParseCurrency(value string) (Currency, error) {
+	currency := Currency(strings.ToUpper(value))
+	if !currency.Known() {
+		return "", ErrUnknownCurrency
+	}
+	return currency, nil
+}
+
+// Known tells whether the currency is supported
+func (c Currency) Known() bool {
+	_, ok := currencyMinorUnits[c]
+	return ok
+}
+
+// MinorUnits returns the number of fractional digits of the currency, eg: 2 for EUR and 0 for JPY
+func (c Currency) MinorUnits() int {
+	return currencyMinorUnits[c]
+}
+
+// ValidAmount tells whether the amount fits the minor units of the currency, eg: no cents for JPY
+func (c Currency) ValidAmount(amount Money) bool {
+	step := Money(1)
+	for i := c.MinorUnits(); i < MoneyScale; i++ {
+		step *= 10
+	}
+	return amount%step == 0
+}
+
+func (c *Currency) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		*c = Currency(v)
+	default:
+		*c = Currency(value.(string))
+	}
+	return nil
+}
+
+func (c Currency) Value() (driver.Value, error) {
+	return string(c), nil
+}
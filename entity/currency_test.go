@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCurrency(t *testing.T) {
+	currency, err := ParseCurrency("usd")
+	require.NoError(t, err)
+	assert.Equal(t, Currency("USD"), currency)
+
+	currency, err = ParseCurrency("EUR")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultCurrency, currency)
+
+	for _, value := range []string{"", "EU", "EURO", "XXX", "BHD"} {
+		_, err := ParseCurrency(value)
+		assert.ErrorIs(t, err, ErrUnknownCurrency, value)
+	}
+}
+
+func TestCurrencyMinorUnits(t *testing.T) {
+	assert.Equal(t, 2, Currency("EUR").MinorUnits())
+	assert.Equal(t, 0, Currency("JPY").MinorUnits())
+
+	// Every supported currency fits in Money
+	for currency, minorUnits := range currencyMinorUnits {
+		assert.LessOrEqual(t, minorUnits, MoneyScale, currency)
+	}
+}
+
+func TestCurrencyValidAmount(t *testing.T) {
+	assert.True(t, Currency("EUR").ValidAmount(Money(1055)))
+	assert.True(t, Currency("JPY").ValidAmount(Money(1000)))
+	assert.True(t, Currency("JPY").ValidAmount(Money(-500)))
+	assert.False(t, Currency("JPY").ValidAmount(Money(1050)))
+	assert.False(t, Currency("JPY").ValidAmount(Money(1)))
+}
+
+func TestCurrencyScan(t *testing.T) {
+	var currency Currency
+	require.NoError(t, currency.Scan("GBP"))
+	assert.Equal(t, Currency("GBP"), currency)
+
+	require.NoError(t, currency.Scan([]byte("JPY")))
+	assert.Equal(t, Currency("JPY"), currency)
+
+	value, err := currency.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "JPY", value)
+}
@@ -14,3 +14,49 @@ var ErrInvalidUser = errors.New("invalid user Id")
 var ErrInvalidTransactionSource = errors.New("invalid transaction source")
 var ErrCreatingGameResult = errors.New("error recording game result")
 var ErrServerInternal = errors.New("internal server error")
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+var ErrInvalidPageLimit = errors.New("invalid page limit")
+var ErrInvalidTimeWindow = errors.New("invalid time window")
+var ErrTransactionNotFound = errors.New("transaction not found")
+var ErrTransactionAlreadyReversed = errors.New("transaction already reversed")
+var ErrTransactionNotReversible = errors.New("a reversal can not be reversed")
+var ErrReservedTransactionID = errors.New("transaction id uses a reserved prefix")
+var ErrUserFrozen = errors.New("user account is frozen")
+var ErrUserClosed = errors.New("user account is closed")
+var ErrInvalidUserStatus = errors.New("invalid user status")
+var ErrInvalidUserStatusTransition = errors.New("invalid user status transition")
+var ErrMissingAPIKey = errors.New("missing api key")
+var ErrInvalidAPIKey = errors.New("invalid api key")
+var ErrTransactionSourceNotAllowed = errors.New("transaction source not allowed for this api key")
+var ErrUserNotAllowed = errors.New("user not allowed for this api key")
+var ErrAdminNotAllowed = errors.New("user management not allowed for this api key")
+var ErrAPIKeyNotFound = errors.New("api key not found")
+var ErrMissingAPIKeyName = errors.New("missing api key name")
+var ErrReversalSourceMismatch = errors.New("a transaction can only be reversed by its own source")
+var ErrMissingSignature = errors.New("missing request signature")
+var ErrUnknownSigningProvider = errors.New("unknown signing provider")
+var ErrInvalidSignatureTimestamp = errors.New("invalid signature timestamp")
+var ErrSignatureExpired = errors.New("signature timestamp outside of the allowed window")
+var ErrInvalidSignature = errors.New("invalid request signature")
+var ErrRateLimited = errors.New("too many requests")
+var ErrMissingReason = errors.New("missing reason")
+var ErrSchemaVersionMismatch = errors.New("database schema version mismatch")
+var ErrHoldNotFound = errors.New("hold not found")
+var ErrHoldNotOpen = errors.New("hold is not open")
+var ErrHoldExpired = errors.New("hold expired")
+var ErrInvalidHoldExpiry = errors.New("invalid hold expiry")
+var ErrCreatingHold = errors.New("error recording hold")
+var ErrHoldSourceMismatch = errors.New("a hold can only be settled by its own source")
+var ErrInvalidRoundID = errors.New("invalid round id")
+var ErrRoundNotFound = errors.New("round not found")
+var ErrRoundNotOpen = errors.New("round is not open")
+var ErrUnknownCurrency = errors.New("unknown currency")
+var ErrCurrencyMismatch = errors.New("currency mismatch")
+var ErrInvalidWageringMultiplier = errors.New("invalid wagering multiplier")
+var ErrWageringRequirementTooHigh = errors.New("wagering requirement too high")
+var ErrBalanceTooHigh = errors.New("balance too high")
+var ErrLedgerMismatch = errors.New("wallet balances do not match the ledger")
+var ErrBalanceDiscrepancies = errors.New("wallet balances do not match the transaction history")
+var ErrInvalidTimestamp = errors.New("invalid timestamp, expected RFC3339")
+var ErrFutureTimestamp = errors.New("timestamp in the future")
+var ErrSnapshotTooRecent = errors.New("snapshot time too recent, transactions may still be committing")
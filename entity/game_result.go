@@ -2,6 +2,10 @@ package entity
 
 import (
 	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -30,6 +34,24 @@ func ParseTransactionSource(value interface{}) *TransactionSource {
 	return &source
 }
 
+func ParseGameStatus(value interface{}) *GameStatus {
+	status := GameStatus(value.(string))
+
+	if status != GameStatusWin &&
+		status != GameStatusLose {
+		return nil
+	}
+	return &status
+}
+
+// Opposite returns the game status that compensates this one.
+func (e GameStatus) Opposite() GameStatus {
+	if e == GameStatusWin {
+		return GameStatusLose
+	}
+	return GameStatusWin
+}
+
 func (e *GameStatus) Scan(value interface{}) error {
 	*e = GameStatus(value.(string))
 	return nil
@@ -49,11 +71,135 @@ func (e TransactionSource) Value() (driver.Value, error) {
 }
 
 type GameResult struct {
-	ID                int               `db:"id"`
-	UserID            int               `db:"user_id"`
-	GameStatus        GameStatus        `db:"game_status"`
-	TransactionSource TransactionSource `db:"transaction_source"`
-	TransactionID     string            `db:"transaction_id"`
-	Amount            float64           `db:"amount" `
-	CreatedAt         time.Time         `db:"created_at"`
+	ID                    int               `db:"id"`
+	UserID                int               `db:"user_id"`
+	GameStatus            GameStatus        `db:"game_status"`
+	TransactionSource     TransactionSource `db:"transaction_source"`
+	TransactionID         string            `db:"transaction_id"`
+	Amount                Money             `db:"amount"`
+	Currency              Currency          `db:"currency"`
+	ReversesTransactionID *string           `db:"reverses_transaction_id"`
+	Reason                *string           `db:"reason"`
+	RoundID               *string           `db:"round_id"`
+	BonusAmount           Money             `db:"bonus_amount"`
+	WageringRequirement   Money             `db:"wagering_requirement"` // Granted by a bonus, or worked off by a wagered lose
+	BalanceAfter          Money             `db:"balance_after"`
+	BonusBalanceAfter     Money             `db:"bonus_balance_after"`
+	CreatedAt             time.Time         `db:"created_at"`
+}
+
+// IsReplayOf tells whether the game result carries the same payload as the other one,
+// meaning it is a retry of the same transaction.
+func (g GameResult) IsReplayOf(other GameResult) bool {
+	return g.UserID == other.UserID &&
+		g.GameStatus == other.GameStatus &&
+		g.Amount == other.Amount &&
+		g.Currency == other.Currency &&
+		g.TransactionSource == other.TransactionSource &&
+		equalRoundIDs(g.RoundID, other.RoundID) &&
+		g.ReversesTransactionID == nil && other.ReversesTransactionID == nil
+}
+
+func equalRoundIDs(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// adjustmentTransactionIDPrefix prefixes the transaction ID of a manual adjustment
+const adjustmentTransactionIDPrefix = "adjustment-"
+
+// AdjustmentTransactionID returns the transaction ID of a manual adjustment, unique by the given ID
+func AdjustmentTransactionID(id string) string {
+	return adjustmentTransactionIDPrefix + id
+}
+
+// bonusTransactionIDPrefix prefixes the transaction ID of a bonus grant
+const bonusTransactionIDPrefix = "bonus-"
+
+// BonusTransactionID returns the transaction ID of a bonus grant, unique by the given ID
+func BonusTransactionID(id string) string {
+	return bonusTransactionIDPrefix + id
+}
+
+// reversalTransactionIDPrefix prefixes the transaction ID of a reversal
+const reversalTransactionIDPrefix = "reversal-"
+
+// ReversalTransactionID returns the transaction ID of the reversal of the given transaction.
+// Being derived from the original transaction ID, a second reversal attempt always collides with the first one.
+func ReversalTransactionID(transactionID string) string {
+	return reversalTransactionIDPrefix + transactionID
+}
+
+// reservedTransactionIDPrefixes are the prefixes of the transaction IDs derived by the service,
+// which the clients can not use, or they could collide with a later adjustment, bonus or reversal
+var reservedTransactionIDPrefixes = []string{adjustmentTransactionIDPrefix, bonusTransactionIDPrefix, reversalTransactionIDPrefix}
+
+// IsReservedTransactionID tells whether the transaction ID starts with a prefix reserved to the service
+func IsReservedTransactionID(transactionID string) bool {
+	for _, prefix := range reservedTransactionIDPrefixes {
+		if strings.HasPrefix(transactionID, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// GameResultCursor points at the last game result of a page.
+// Game results are listed newest-first, ordered by creation time and ID.
+type GameResultCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// GameResultFilter narrows down the game results listed for a user.
+type GameResultFilter struct {
+	UserID            int
+	GameStatus        *GameStatus
+	TransactionSource *TransactionSource
+	CreatedFrom       *time.Time
+	CreatedTo         *time.Time
+	Cursor            *GameResultCursor
+	Limit             int
+}
+
+// GameResultPage is a single page of game results.
+// NextCursor is nil when there are no more game results to list.
+type GameResultPage struct {
+	GameResults []GameResult
+	NextCursor  *GameResultCursor
+}
+
+// Encode returns the opaque string representation of the cursor, safe to be used in URLs.
+func (c GameResultCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixMicro(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseGameResultCursor decodes a cursor previously produced by GameResultCursor.Encode.
+func ParseGameResultCursor(value string) (*GameResultCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(parts[1])
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &GameResultCursor{
+		CreatedAt: time.UnixMicro(createdAt).UTC(),
+		ID:        id,
+	}, nil
 }
@@ -48,7 +48,7 @@ func TestGameResult(t *testing.T) {
 	gameStatus := GameStatusWin
 	transactionSource := TransactionSourceGame
 	transactionID := "tx123"
-	amount := 10.15
+	amount := Money(1015)
 	createdAt := time.Now()
 
 	gameResult := GameResult{
@@ -106,3 +106,129 @@ func TestParseTransactionSource(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGameStatus(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  *GameStatus
+	}{
+		{"Win Status", "win", func() *GameStatus { s := GameStatusWin; return &s }()},
+		{"Lose Status", "lose", func() *GameStatus { s := GameStatusLose; return &s }()},
+		{"Invalid Status", "invalid", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseGameStatus(tt.input.(string))
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestGameResultCursorRoundTrip(t *testing.T) {
+	cursor := GameResultCursor{
+		CreatedAt: time.Date(2024, 5, 17, 10, 30, 15, 123456000, time.UTC),
+		ID:        42,
+	}
+
+	parsed, err := ParseGameResultCursor(cursor.Encode())
+	require.NoError(t, err)
+	require.Equal(t, cursor.ID, parsed.ID)
+	require.True(t, cursor.CreatedAt.Equal(parsed.CreatedAt))
+}
+
+func TestParseGameResultCursorInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Not Base64", "***"},
+		{"Missing ID", "MTIzNDU"},  // "12345"
+		{"Invalid ID", "MTIzOmFi"}, // "123:ab"
+		{"Zero ID", "MTIzOjA"},     // "123:0"
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseGameResultCursor(tt.input)
+			require.ErrorIs(t, err, ErrInvalidCursor)
+		})
+	}
+}
+
+func TestGameStatusOpposite(t *testing.T) {
+	require.Equal(t, GameStatusLose, GameStatusWin.Opposite())
+	require.Equal(t, GameStatusWin, GameStatusLose.Opposite())
+}
+
+func TestIsReservedTransactionID(t *testing.T) {
+	require.True(t, IsReservedTransactionID(ReversalTransactionID("tx123")))
+	require.True(t, IsReservedTransactionID(AdjustmentTransactionID("tx123")))
+	require.True(t, IsReservedTransactionID(BonusTransactionID("tx123")))
+	require.False(t, IsReservedTransactionID("tx123"))
+	require.False(t, IsReservedTransactionID("my-reversal-123"))
+}
+
+func TestGameResultIsReplayOf(t *testing.T) {
+	original := GameResult{
+		ID:                1,
+		UserID:            1,
+		GameStatus:        GameStatusWin,
+		TransactionSource: TransactionSourceGame,
+		TransactionID:     "tx123",
+		Amount:            Money(1015),
+		BalanceAfter:      Money(2030),
+		CreatedAt:         time.Now(),
+	}
+
+	// Only the payload is compared, not what was computed when recording it
+	retry := GameResult{
+		UserID:            1,
+		GameStatus:        GameStatusWin,
+		TransactionSource: TransactionSourceGame,
+		TransactionID:     "tx123",
+		Amount:            Money(1015),
+	}
+	require.True(t, original.IsReplayOf(retry))
+
+	otherUser := retry
+	otherUser.UserID = 2
+	require.False(t, original.IsReplayOf(otherUser))
+
+	otherStatus := retry
+	otherStatus.GameStatus = GameStatusLose
+	require.False(t, original.IsReplayOf(otherStatus))
+
+	otherAmount := retry
+	otherAmount.Amount = Money(1016)
+	require.False(t, original.IsReplayOf(otherAmount))
+
+	otherSource := retry
+	otherSource.TransactionSource = TransactionSourcePayment
+	require.False(t, original.IsReplayOf(otherSource))
+
+	otherCurrency := retry
+	otherCurrency.Currency = "JPY"
+	require.False(t, original.IsReplayOf(otherCurrency))
+
+	roundID, sameRoundID := "round-1", "round-1"
+	inRound := original
+	inRound.RoundID = &roundID
+	require.False(t, inRound.IsReplayOf(retry))
+	require.False(t, original.IsReplayOf(inRound))
+
+	retryInRound := retry
+	retryInRound.RoundID = &sameRoundID
+	require.True(t, inRound.IsReplayOf(retryInRound))
+
+	otherRoundID := "round-2"
+	otherRound := retry
+	otherRound.RoundID = &otherRoundID
+	require.False(t, inRound.IsReplayOf(otherRound))
+
+	reversed := "tx000"
+	reversal := original
+	reversal.ReversesTransactionID = &reversed
+	require.False(t, reversal.IsReplayOf(retry))
+}
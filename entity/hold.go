@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+type HoldStatus string
+
+const (
+	HoldStatusOpen     HoldStatus = "open"
+	HoldStatusCaptured HoldStatus = "captured"
+	HoldStatusReleased HoldStatus = "released"
+	// HoldStatusExpired is never stored, an open hold past its expiry is reported as expired
+	HoldStatusExpired HoldStatus = "expired"
+)
+
+const (
+	// DefaultHoldExpiry is how long a hold lasts when no expiry is requested
+	DefaultHoldExpiry = time.Minute * 15
+	// MaxHoldExpiry is the longest a hold can last
+	MaxHoldExpiry = time.Hour * 24
+)
+
+func (e *HoldStatus) Scan(value interface{}) error {
+	*e = HoldStatus(value.(string))
+	return nil
+}
+
+func (e HoldStatus) Value() (driver.Value, error) {
+	return string(e), nil
+}
+
+// Hold reserves an amount of the user balance for an in-progress bet,
+// until it is captured into a lose game result, released or expired.
+// TransactionID is the game result the hold was captured into, if any.
+type Hold struct {
+	ID                int               `db:"id"`
+	UserID            int               `db:"user_id"`
+	Amount            Money             `db:"amount"`
+	Currency          Currency          `db:"currency"`
+	Status            HoldStatus        `db:"status"`
+	TransactionSource TransactionSource `db:"transaction_source"`
+	TransactionID     *string           `db:"transaction_id"`
+	ExpiresAt         time.Time         `db:"expires_at"`
+	CreatedAt         time.Time         `db:"created_at"`
+	UpdatedAt         time.Time         `db:"updated_at"`
+}
+
+// StatusAt returns the status of the hold at the given moment, expired once an open hold reached its expiry
+func (h Hold) StatusAt(moment time.Time) HoldStatus {
+	if h.Status == HoldStatusOpen && !moment.Before(h.ExpiresAt) {
+		return HoldStatusExpired
+	}
+	return h.Status
+}
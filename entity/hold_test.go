@@ -0,0 +1,42 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldStatusScan(t *testing.T) {
+	var status HoldStatus
+	err := status.Scan("captured")
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, status)
+}
+
+func TestHoldStatusValue(t *testing.T) {
+	val, err := HoldStatusReleased.Value()
+	require.NoError(t, err)
+	require.Equal(t, "released", val)
+}
+
+func TestHoldStatusAt(t *testing.T) {
+	expiresAt := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		status HoldStatus
+		moment time.Time
+		want   HoldStatus
+	}{
+		{HoldStatusOpen, expiresAt.Add(-time.Second), HoldStatusOpen},
+		{HoldStatusOpen, expiresAt, HoldStatusExpired},
+		{HoldStatusOpen, expiresAt.Add(time.Second), HoldStatusExpired},
+		{HoldStatusCaptured, expiresAt.Add(time.Second), HoldStatusCaptured},
+		{HoldStatusReleased, expiresAt.Add(time.Second), HoldStatusReleased},
+	}
+
+	for _, tt := range tests {
+		hold := Hold{Status: tt.status, ExpiresAt: expiresAt}
+		require.Equal(t, tt.want, hold.StatusAt(tt.moment), "%s at %s", tt.status, tt.moment)
+	}
+}
@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// LedgerAccountType is the kind of a ledger account
+type LedgerAccountType string
+
+const (
+	// LedgerAccountUserCash holds the cash balance of a user wallet
+	LedgerAccountUserCash LedgerAccountType = "user_cash"
+	// LedgerAccountUserBonus holds the bonus balance of a user wallet
+	LedgerAccountUserBonus LedgerAccountType = "user_bonus"
+	// LedgerAccountHouse is the counterparty of the game and server transactions
+	LedgerAccountHouse LedgerAccountType = "house"
+	// LedgerAccountPaymentClearing is the counterparty of the payment transactions
+	LedgerAccountPaymentClearing LedgerAccountType = "payment_clearing"
+)
+
+func (e *LedgerAccountType) Scan(value interface{}) error {
+	*e = LedgerAccountType(value.(string))
+	return nil
+}
+
+func (e LedgerAccountType) Value() (driver.Value, error) {
+	return string(e), nil
+}
+
+// LedgerAccount identifies an account of the ledger, one per type and currency,
+// and also per user for the accounts of the user wallets
+type LedgerAccount struct {
+	Type     LedgerAccountType `db:"account_type"`
+	UserID   *int              `db:"user_id"`
+	Currency Currency          `db:"currency"`
+}
+
+// UserCashAccount returns the ledger account of the cash balance of the user wallet in the currency
+func UserCashAccount(userID int, currency Currency) LedgerAccount {
+	return LedgerAccount{Type: LedgerAccountUserCash, UserID: &userID, Currency: currency}
+}
+
+// UserBonusAccount returns the ledger account of the bonus balance of the user wallet in the currency
+func UserBonusAccount(userID int, currency Currency) LedgerAccount {
+	return LedgerAccount{Type: LedgerAccountUserBonus, UserID: &userID, Currency: currency}
+}
+
+// CounterpartyAccount returns the ledger account on the other side of the transactions from the source:
+// the payment clearing account for the payments, and the house account for the rest
+func CounterpartyAccount(source TransactionSource, currency Currency) LedgerAccount {
+	if source == TransactionSourcePayment {
+		return LedgerAccount{Type: LedgerAccountPaymentClearing, Currency: currency}
+	}
+	return LedgerAccount{Type: LedgerAccountHouse, Currency: currency}
+}
+
+// LedgerPosting is a single movement of a ledger account, crediting it when positive and debiting it when negative.
+// The postings of a game result always sum to zero.
+type LedgerPosting struct {
+	ID           int   `db:"id"`
+	GameResultID int   `db:"game_result_id"`
+	Amount       Money `db:"amount"`
+	LedgerAccount
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// LedgerPostings returns the balanced postings of the game result, moving the user wallet from before to after,
+// the counterparty account taking the opposite of the moves of the cash and bonus balances
+// Zero moves are left out, such as the bonus balance of a game result paid in cash only
+func LedgerPostings(gameResult GameResult, before, after Wallet, counterparty LedgerAccount) []LedgerPosting {
+	cash := after.Balance - before.Balance
+	bonus := after.BonusBalance - before.BonusBalance
+
+	moves := []struct {
+		account LedgerAccount
+		amount  Money
+	}{
+		{UserCashAccount(gameResult.UserID, gameResult.Currency), cash},
+		{UserBonusAccount(gameResult.UserID, gameResult.Currency), bonus},
+		{counterparty, -(cash + bonus)},
+	}
+
+	postings := make([]LedgerPosting, 0, len(moves))
+	for _, move := range moves {
+		if move.amount == 0 {
+			continue
+		}
+		postings = append(postings, LedgerPosting{
+			GameResultID:  gameResult.ID,
+			Amount:        move.amount,
+			LedgerAccount: move.account,
+			CreatedAt:     gameResult.CreatedAt,
+		})
+	}
+	return postings
+}
+
+// LedgerBalance is the balance of a ledger account, the sum of its postings
+type LedgerBalance struct {
+	LedgerAccount
+	Balance Money `db:"balance"`
+}
+
+// LedgerMismatch reports a user wallet balance which differs from the balance of its ledger account
+type LedgerMismatch struct {
+	Account       LedgerAccount
+	WalletBalance Money
+	LedgerBalance Money
+}
+
+// HistoricalBalance is the cash and bonus balance of a user wallet as of a past time
+type HistoricalBalance struct {
+	UserID       int
+	Currency     Currency
+	Balance      Money
+	BonusBalance Money
+	At           time.Time
+}
+
+// HistoricalBalances folds the balances of the user cash and bonus accounts as of the time into one balance per currency,
+// in the order of the ledger balances
+func HistoricalBalances(userID int, balances []LedgerBalance, at time.Time) []HistoricalBalance {
+	historical := []HistoricalBalance{}
+	for _, balance := range balances {
+		if len(historical) == 0 || historical[len(historical)-1].Currency != balance.Currency {
+			historical = append(historical, HistoricalBalance{UserID: userID, Currency: balance.Currency, At: at})
+		}
+
+		switch current := &historical[len(historical)-1]; balance.Type {
+		case LedgerAccountUserCash:
+			current.Balance += balance.Balance
+		case LedgerAccountUserBonus:
+			current.BonusBalance += balance.Balance
+		}
+	}
+	return historical
+}
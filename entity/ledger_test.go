@@ -0,0 +1,94 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerAccountTypeScan(t *testing.T) {
+	var accountType LedgerAccountType
+	require.NoError(t, accountType.Scan("payment_clearing"))
+	assert.Equal(t, LedgerAccountPaymentClearing, accountType)
+
+	value, err := LedgerAccountHouse.Value()
+	require.NoError(t, err)
+	assert.Equal(t, "house", value)
+}
+
+func TestCounterpartyAccount(t *testing.T) {
+	assert.Equal(t, LedgerAccount{Type: LedgerAccountHouse, Currency: "EUR"}, CounterpartyAccount(TransactionSourceGame, "EUR"))
+	assert.Equal(t, LedgerAccount{Type: LedgerAccountHouse, Currency: "EUR"}, CounterpartyAccount(TransactionSourceServer, "EUR"))
+	assert.Equal(t, LedgerAccount{Type: LedgerAccountPaymentClearing, Currency: "JPY"}, CounterpartyAccount(TransactionSourcePayment, "JPY"))
+}
+
+func TestLedgerPostings(t *testing.T) {
+	createdAt := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+	gameResult := GameResult{ID: 7, UserID: 1, Currency: DefaultCurrency, CreatedAt: createdAt}
+	house := CounterpartyAccount(TransactionSourceGame, DefaultCurrency)
+
+	tests := []struct {
+		name     string
+		before   Wallet
+		after    Wallet
+		expected map[LedgerAccountType]Money
+	}{
+		{
+			name:     "Cash win",
+			before:   Wallet{Balance: Money(1000)},
+			after:    Wallet{Balance: Money(1500)},
+			expected: map[LedgerAccountType]Money{LedgerAccountUserCash: Money(500), LedgerAccountHouse: Money(-500)},
+		},
+		{
+			name:     "Lose drawn from cash and bonus",
+			before:   Wallet{Balance: Money(1000), BonusBalance: Money(2000)},
+			after:    Wallet{Balance: Money(0), BonusBalance: Money(1500)},
+			expected: map[LedgerAccountType]Money{LedgerAccountUserCash: Money(-1000), LedgerAccountUserBonus: Money(-500), LedgerAccountHouse: Money(1500)},
+		},
+		{
+			name:     "Lose turning the bonus into cash",
+			before:   Wallet{Balance: Money(1000), BonusBalance: Money(2000)},
+			after:    Wallet{Balance: Money(2500), BonusBalance: Money(0)},
+			expected: map[LedgerAccountType]Money{LedgerAccountUserCash: Money(1500), LedgerAccountUserBonus: Money(-2000), LedgerAccountHouse: Money(500)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			postings := LedgerPostings(gameResult, tt.before, tt.after, house)
+			require.Len(t, postings, len(tt.expected))
+
+			sum := Money(0)
+			for _, posting := range postings {
+				assert.Equal(t, tt.expected[posting.Type], posting.Amount, posting.Type)
+				assert.Equal(t, 7, posting.GameResultID)
+				assert.Equal(t, DefaultCurrency, posting.Currency)
+				assert.Equal(t, createdAt, posting.CreatedAt)
+				if posting.Type == LedgerAccountHouse {
+					assert.Nil(t, posting.UserID)
+				} else {
+					assert.Equal(t, 1, *posting.UserID)
+				}
+				sum += posting.Amount
+			}
+			assert.Equal(t, Money(0), sum)
+		})
+	}
+}
+
+func TestHistoricalBalances(t *testing.T) {
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	balances := []LedgerBalance{
+		{LedgerAccount: UserCashAccount(1, DefaultCurrency), Balance: Money(1000)},
+		{LedgerAccount: UserBonusAccount(1, DefaultCurrency), Balance: Money(500)},
+		{LedgerAccount: UserBonusAccount(1, "JPY"), Balance: Money(300)},
+	}
+
+	assert.Equal(t, []HistoricalBalance{
+		{UserID: 1, Currency: DefaultCurrency, Balance: Money(1000), BonusBalance: Money(500), At: at},
+		{UserID: 1, Currency: "JPY", Balance: Money(0), BonusBalance: Money(300), At: at},
+	}, HistoricalBalances(1, balances, at))
+	assert.Empty(t, HistoricalBalances(1, nil, at))
+}
@@ -0,0 +1,7 @@
+package entity
+
+// MigrationStatus is the state of the database schema, as recorded by the migrations
+type MigrationStatus struct {
+	Version uint
+	Dirty   bool
+}
@@ -0,0 +1,136 @@
+package entity
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount of money, held as an integer number of cents.
+// It maps to the DECIMAL(10,2) columns of the database.
+type Money int64
+
+const (
+	// MoneyScale is the number of fractional digits of Money
+	MoneyScale = 2
+
+	// MaxMoney is the largest amount the DECIMAL(10,2) columns can store
+	MaxMoney Money = 99_999_999_99
+
+	centsPerUnit = 100
+)
+
+// moneyPattern accepts an optional sign, an integer part and up to MoneyScale fractional digits.
+var moneyPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]{1,2})?$`)
+
+// ParseMoney parses a decimal string, such as "10", "10.5" or "-10.55", into Money.
+// It rejects anything else, including more than two fractional digits and exponents,
+// and amounts the DECIMAL(10,2) columns can not store, beyond MaxMoney either way.
+func ParseMoney(value string) (Money, error) {
+	money, err := parseMoney(value)
+	if err != nil {
+		return 0, err
+	}
+	if money > MaxMoney || money < -MaxMoney {
+		return 0, ErrInvalidAmount
+	}
+	return money, nil
+}
+
+// parseMoney parses a decimal string into Money as ParseMoney does, but without bounding it to MaxMoney.
+func parseMoney(value string) (Money, error) {
+	if !moneyPattern.MatchString(value) {
+		return 0, ErrInvalidAmount
+	}
+
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+
+	units, fraction, _ := strings.Cut(value, ".")
+	fraction += strings.Repeat("0", MoneyScale-len(fraction))
+
+	unitsValue, err := strconv.ParseInt(units, 10, 64)
+	if err != nil || unitsValue > math.MaxInt64/centsPerUnit-1 {
+		return 0, ErrInvalidAmount
+	}
+	fractionValue, err := strconv.ParseInt(fraction, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+
+	money := Money(unitsValue*centsPerUnit + fractionValue)
+	if negative {
+		money = -money
+	}
+	return money, nil
+}
+
+// String formats the amount with exactly two fractional digits, eg: "10.50".
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/centsPerUnit, cents%centsPerUnit)
+}
+
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		return m.scanString(v)
+	case []byte:
+		return m.scanString(string(v))
+	case int64:
+		*m = Money(v * centsPerUnit)
+		return nil
+	case float64:
+		*m = Money(math.Round(v * centsPerUnit))
+		return nil
+	default:
+		return fmt.Errorf("cannot scan %T into Money", value)
+	}
+}
+
+// scanString reads the amount unbounded, as the sums and the snapshots of the ledger exceed MaxMoney.
+func (m *Money) scanString(value string) error {
+	money, err := parseMoney(value)
+	if err != nil {
+		return fmt.Errorf("cannot scan %q into Money: %w", value, err)
+	}
+	*m = money
+	return nil
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// MarshalJSON writes the amount as a JSON string, eg: "10.50".
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// UnmarshalJSON reads the amount from either a JSON string or a JSON number,
+// applying the same strict rules as ParseMoney.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	value := string(bytes.TrimSpace(data))
+	if strings.HasPrefix(value, `"`) {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return ErrInvalidAmount
+		}
+	}
+
+	money, err := ParseMoney(value)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
@@ -0,0 +1,131 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Money
+	}{
+		{"Integer", "100", Money(10000)},
+		{"One Decimal", "10.5", Money(1050)},
+		{"Two Decimals", "10.55", Money(1055)},
+		{"Cents Only", "0.01", Money(1)},
+		{"Negative", "-1.11", Money(-111)},
+		{"Zero", "0.00", Money(0)},
+		{"Max", "99999999.99", MaxMoney},
+		{"Negative Max", "-99999999.99", -MaxMoney},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMoney(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Empty", ""},
+		{"Three Decimals", "10.555"},
+		{"Trailing Dot", "10."},
+		{"Leading Dot", ".5"},
+		{"Exponent", "1e2"},
+		{"Plus Sign", "+10"},
+		{"Letters", "1b.c23"},
+		{"Spaces", " 10"},
+		{"Overflow", "92233720368547758.07"},
+		{"Over Max", "100000000.00"},
+		{"Under Negative Max", "-100000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMoney(tt.input)
+			require.ErrorIs(t, err, ErrInvalidAmount)
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	require.Equal(t, "100.00", Money(10000).String())
+	require.Equal(t, "0.05", Money(5).String())
+	require.Equal(t, "-1.11", Money(-111).String())
+}
+
+func TestMoneyArithmeticIsExact(t *testing.T) {
+	// 0.1 + 0.2 drifts with float64, but never with Money
+	var total Money
+	for range 1000 {
+		total += Money(10)
+		total += Money(20)
+	}
+	require.Equal(t, "300.00", total.String())
+}
+
+func TestMoneyScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		input interface{}
+		want  Money
+	}{
+		{"String", "10.55", Money(1055)},
+		{"Bytes", []byte("10.55"), Money(1055)},
+		{"Integer", int64(10), Money(1000)},
+		{"Float", 10.55, Money(1055)},
+		{"Ledger Sum Over Max", "123456789012.34", Money(12345678901234)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var money Money
+			err := money.Scan(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, money)
+		})
+	}
+
+	var money Money
+	require.Error(t, money.Scan(true))
+	require.Error(t, money.Scan("10.555"))
+}
+
+func TestMoneyValue(t *testing.T) {
+	val, err := Money(1055).Value()
+	require.NoError(t, err)
+	require.Equal(t, "10.55", val)
+}
+
+func TestMoneyJSON(t *testing.T) {
+	bytes, err := json.Marshal(struct {
+		Amount Money `json:"amount"`
+	}{Amount: Money(1050)})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"10.50"}`, string(bytes))
+
+	var payload struct {
+		Amount Money `json:"amount"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"10.5"}`), &payload))
+	require.Equal(t, Money(1050), payload.Amount)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":10.55}`), &payload))
+	require.Equal(t, Money(1055), payload.Amount)
+
+	err = json.Unmarshal([]byte(`{"amount":"10.555"}`), &payload)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+
+	err = json.Unmarshal([]byte(`{"amount":true}`), &payload)
+	require.ErrorIs(t, err, ErrInvalidAmount)
+}
@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// BalanceDiscrepancy reports a wallet whose balance differs from the one recomputed from its transaction history
+type BalanceDiscrepancy struct {
+	UserID   int      `db:"user_id"`
+	Currency Currency `db:"currency"`
+	// Balance is the cash and bonus balance of the wallet
+	Balance Money `db:"balance"`
+	// ExpectedBalance is the sum of the game results of the wallet, the wins minus the loses, reversals included
+	ExpectedBalance Money `db:"expected_balance"`
+}
+
+// Difference is how much the wallet balance exceeds the expected balance, negative when it falls short
+func (d BalanceDiscrepancy) Difference() Money {
+	return d.Balance - d.ExpectedBalance
+}
+
+// Reconciliation is the outcome of comparing every wallet balance with its transaction history
+type Reconciliation struct {
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	WalletsChecked int
+	Discrepancies  []BalanceDiscrepancy
+}
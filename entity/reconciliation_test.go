@@ -0,0 +1,12 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalanceDiscrepancyDifference(t *testing.T) {
+	assert.Equal(t, Money(250), BalanceDiscrepancy{Balance: 1250, ExpectedBalance: 1000}.Difference())
+	assert.Equal(t, Money(-1000), BalanceDiscrepancy{Balance: 0, ExpectedBalance: 1000}.Difference())
+}
@@ -0,0 +1,87 @@
+package entity
+
+import (
+	"time"
+)
+
+type RoundStatus string
+
+const (
+	// RoundStatusOpen is a round with a stake and no payout yet
+	RoundStatusOpen RoundStatus = "open"
+	// RoundStatusSettled is a round with a payout
+	RoundStatusSettled RoundStatus = "settled"
+	// RoundStatusVoided is a round whose stakes were all reversed, with no payout
+	RoundStatusVoided RoundStatus = "voided"
+)
+
+// MaxRoundIDLength is the longest round ID accepted
+const MaxRoundIDLength = 255
+
+// ValidRoundID tells whether the round ID given by the client can be recorded
+func ValidRoundID(roundID string) bool {
+	return roundID != "" && len(roundID) <= MaxRoundIDLength
+}
+
+// Round pairs the game results of a single game round of the user:
+// a lose game result is a stake, opening the round, and a win game result is the payout, settling it.
+// Reversals take their amount back from the stake or the payout they compensate,
+// a reversed payout leaving the round open again, and reversing all its stakes voiding it.
+// All the game results of a round are in the currency of its first stake.
+type Round struct {
+	ID          string
+	UserID      int
+	Currency    Currency
+	Stake       Money
+	Payout      Money
+	Status      RoundStatus
+	GameResults []GameResult
+	StartedAt   time.Time
+}
+
+// NewRound summarizes the game results of the round, given oldest-first
+func NewRound(userID int, roundID string, gameResults []GameResult) Round {
+	round := Round{
+		ID:          roundID,
+		UserID:      userID,
+		Status:      RoundStatusOpen,
+		GameResults: gameResults,
+	}
+	if len(gameResults) > 0 {
+		round.Currency = gameResults[0].Currency
+		round.StartedAt = gameResults[0].CreatedAt
+	}
+
+	stakes, payouts := 0, 0
+	for _, gameResult := range gameResults {
+		isReversal := gameResult.ReversesTransactionID != nil
+
+		switch {
+		case gameResult.GameStatus == GameStatusLose && !isReversal:
+			round.Stake += gameResult.Amount
+			stakes++
+		case gameResult.GameStatus == GameStatusWin && isReversal:
+			round.Stake -= gameResult.Amount
+			stakes--
+		case gameResult.GameStatus == GameStatusWin:
+			round.Payout += gameResult.Amount
+			payouts++
+		default:
+			round.Payout -= gameResult.Amount
+			payouts--
+		}
+	}
+
+	switch {
+	case payouts > 0:
+		round.Status = RoundStatusSettled
+	case len(gameResults) > 0 && stakes == 0:
+		round.Status = RoundStatusVoided
+	}
+	return round
+}
+
+// Net returns what the user won, or lost when negative, in the round
+func (r Round) Net() Money {
+	return r.Payout - r.Stake
+}
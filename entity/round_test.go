@@ -0,0 +1,62 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidRoundID(t *testing.T) {
+	assert.True(t, ValidRoundID("round-1"))
+	assert.True(t, ValidRoundID(string(make([]byte, MaxRoundIDLength))))
+	assert.False(t, ValidRoundID(""))
+	assert.False(t, ValidRoundID(string(make([]byte, MaxRoundIDLength+1))))
+}
+
+func TestNewRound(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	stakeID, payoutID := "stake-1", "payout-1"
+
+	stake := GameResult{TransactionID: stakeID, GameStatus: GameStatusLose, Amount: 500, Currency: "USD", CreatedAt: startedAt}
+	sideStake := GameResult{TransactionID: "stake-2", GameStatus: GameStatusLose, Amount: 100, CreatedAt: startedAt.Add(time.Second)}
+	payout := GameResult{TransactionID: payoutID, GameStatus: GameStatusWin, Amount: 1500, CreatedAt: startedAt.Add(time.Minute)}
+	stakeReversal := GameResult{TransactionID: ReversalTransactionID(stakeID), GameStatus: GameStatusWin, Amount: 500, ReversesTransactionID: &stakeID}
+	payoutReversal := GameResult{TransactionID: ReversalTransactionID(payoutID), GameStatus: GameStatusLose, Amount: 1500, ReversesTransactionID: &payoutID}
+
+	testCases := []struct {
+		name        string
+		gameResults []GameResult
+		stake       Money
+		payout      Money
+		net         Money
+		status      RoundStatus
+	}{
+		{name: "No game results", stake: 0, payout: 0, net: 0, status: RoundStatusOpen},
+		{name: "Stakes only", gameResults: []GameResult{stake, sideStake}, stake: 600, payout: 0, net: -600, status: RoundStatusOpen},
+		{name: "Settled", gameResults: []GameResult{stake, sideStake, payout}, stake: 600, payout: 1500, net: 900, status: RoundStatusSettled},
+		{name: "Reversed stake", gameResults: []GameResult{stake, sideStake, stakeReversal}, stake: 100, payout: 0, net: -100, status: RoundStatusOpen},
+		{name: "Reversed payout", gameResults: []GameResult{stake, payout, payoutReversal}, stake: 500, payout: 0, net: -500, status: RoundStatusOpen},
+		{name: "Reversed only stake", gameResults: []GameResult{stake, stakeReversal}, stake: 0, payout: 0, net: 0, status: RoundStatusVoided},
+		{name: "Reversed stake and payout", gameResults: []GameResult{stake, payout, payoutReversal, stakeReversal}, stake: 0, payout: 0, net: 0, status: RoundStatusVoided},
+		{name: "Reversed stake of a settled round", gameResults: []GameResult{stake, payout, stakeReversal}, stake: 0, payout: 1500, net: 1500, status: RoundStatusSettled},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			round := NewRound(1, "round-1", tc.gameResults)
+
+			assert.Equal(t, "round-1", round.ID)
+			assert.Equal(t, 1, round.UserID)
+			assert.Equal(t, tc.stake, round.Stake)
+			assert.Equal(t, tc.payout, round.Payout)
+			assert.Equal(t, tc.net, round.Net())
+			assert.Equal(t, tc.status, round.Status)
+			assert.Equal(t, tc.gameResults, round.GameResults)
+			if len(tc.gameResults) > 0 {
+				assert.Equal(t, Currency("USD"), round.Currency)
+				assert.Equal(t, startedAt, round.StartedAt)
+			}
+		})
+	}
+}
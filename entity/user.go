@@ -1,11 +1,69 @@
 package entity
 
 import (
+	"database/sql/driver"
 	"time"
 )
 
+type UserStatus string
+
+const (
+	UserStatusActive UserStatus = "active"
+	UserStatusFrozen UserStatus = "frozen"
+	UserStatusClosed UserStatus = "closed"
+)
+
+// userStatusTransitions lists the statuses reachable from each status
+// A closed user is never reopened
+var userStatusTransitions = map[UserStatus][]UserStatus{
+	UserStatusActive: {UserStatusFrozen, UserStatusClosed},
+	UserStatusFrozen: {UserStatusActive, UserStatusClosed},
+	UserStatusClosed: {},
+}
+
+func ParseUserStatus(value interface{}) *UserStatus {
+	status := UserStatus(value.(string))
+
+	if status != UserStatusActive &&
+		status != UserStatusFrozen &&
+		status != UserStatusClosed {
+		return nil
+	}
+	return &status
+}
+
+// CanTransitionTo tells whether a user in this status can be moved to the next one
+func (e UserStatus) CanTransitionTo(next UserStatus) bool {
+	for _, status := range userStatusTransitions[e] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *UserStatus) Scan(value interface{}) error {
+	*e = UserStatus(value.(string))
+	return nil
+}
+
+func (e UserStatus) Value() (driver.Value, error) {
+	return string(e), nil
+}
+
+// User is an account holder.
+// The balances are the ones of the wallet of the user in DefaultCurrency, see Wallet.
 type User struct {
-	ID        int       `db:"id"`
-	Balance   float64   `db:"balance"`
-	CreatedAt time.Time `db:"created_at"`
+	ID                  int        `db:"id"`
+	Balance             Money      `db:"balance"`
+	BonusBalance        Money      `db:"bonus_balance"`
+	WageringRequirement Money      `db:"wagering_requirement"`
+	HeldBalance         Money      `db:"held_balance"`
+	Status              UserStatus `db:"status"`
+	CreatedAt           time.Time  `db:"created_at"`
+}
+
+// AvailableBalance is the cash and bonus balance that can still be lost, left aside by the open holds
+func (u User) AvailableBalance() Money {
+	return u.Balance + u.BonusBalance - u.HeldBalance
 }
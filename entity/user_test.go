@@ -0,0 +1,63 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestUserStatusScan(t *testing.T) {
+	var status UserStatus
+	err := status.Scan("frozen")
+	require.NoError(t, err)
+	require.Equal(t, UserStatusFrozen, status)
+}
+
+func TestUserStatusValue(t *testing.T) {
+	val, err := UserStatusClosed.Value()
+	require.NoError(t, err)
+	require.Equal(t, "closed", val)
+}
+
+func TestParseUserStatus(t *testing.T) {
+	require.Equal(t, UserStatusActive, *ParseUserStatus("active"))
+	require.Equal(t, UserStatusFrozen, *ParseUserStatus("frozen"))
+	require.Equal(t, UserStatusClosed, *ParseUserStatus("closed"))
+	require.Nil(t, ParseUserStatus("deleted"))
+}
+
+func TestUserStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from UserStatus
+		to   UserStatus
+		want bool
+	}{
+		{UserStatusActive, UserStatusFrozen, true},
+		{UserStatusActive, UserStatusClosed, true},
+		{UserStatusActive, UserStatusActive, false},
+		{UserStatusFrozen, UserStatusActive, true},
+		{UserStatusFrozen, UserStatusClosed, true},
+		{UserStatusFrozen, UserStatusFrozen, false},
+		{UserStatusClosed, UserStatusActive, false},
+		{UserStatusClosed, UserStatusFrozen, false},
+		{UserStatusClosed, UserStatusClosed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" to "+string(tt.to), func(t *testing.T) {
+			require.Equal(t, tt.want, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestUserAvailableBalance(t *testing.T) {
+	user := User{Balance: Money(10000), HeldBalance: Money(2550)}
+	require.Equal(t, Money(7450), user.AvailableBalance())
+
+	user.HeldBalance = 0
+	require.Equal(t, user.Balance, user.AvailableBalance())
+}
+
+func TestUserAvailableBalanceWithBonus(t *testing.T) {
+	user := User{Balance: Money(1000), BonusBalance: Money(2000), HeldBalance: Money(500)}
+	require.Equal(t, Money(2500), user.AvailableBalance())
+}
@@ -0,0 +1,119 @@
+package entity
+
+import "time"
+
+// BalanceOrder is the order in which the lose game results draw from the cash and bonus balances
+type BalanceOrder string
+
+const (
+	BalanceOrderCashFirst  BalanceOrder = "cash_first"
+	BalanceOrderBonusFirst BalanceOrder = "bonus_first"
+)
+
+// DefaultBalanceOrder spends the cash balance before the bonus balance
+const DefaultBalanceOrder = BalanceOrderCashFirst
+
+// MaxWageringMultiplier caps the number of times a bonus must be wagered
+const MaxWageringMultiplier = 100
+
+func ParseBalanceOrder(value interface{}) *BalanceOrder {
+	order := BalanceOrder(value.(string))
+
+	if order != BalanceOrderCashFirst &&
+		order != BalanceOrderBonusFirst {
+		return nil
+	}
+	return &order
+}
+
+// Wallet is the balance of a user in a single currency.
+// Balance is the withdrawable cash balance, while BonusBalance is the bonus money granted by the promotions,
+// which becomes cash once WageringRequirement, the amount still to be lost in game results, is met.
+// HeldBalance is the part of the balances reserved by the open holds in that currency, see Hold.
+type Wallet struct {
+	UserID              int       `db:"user_id"`
+	Currency            Currency  `db:"currency"`
+	Balance             Money     `db:"balance"`
+	BonusBalance        Money     `db:"bonus_balance"`
+	WageringRequirement Money     `db:"wagering_requirement"`
+	HeldBalance         Money     `db:"held_balance"`
+	CreatedAt           time.Time `db:"created_at"`
+	UpdatedAt           time.Time `db:"updated_at"`
+}
+
+// AvailableBalance is the cash and bonus balance that can still be lost, left aside by the open holds
+func (w Wallet) AvailableBalance() Money {
+	return w.Balance + w.BonusBalance - w.HeldBalance
+}
+
+// BonusShare returns the part of a lose amount drawn from the bonus balance, spending the balances in the order
+func (w Wallet) BonusShare(amount Money, order BalanceOrder) Money {
+	if order == BalanceOrderBonusFirst {
+		return min(amount, w.BonusBalance)
+	}
+	return min(max(amount-w.Balance, 0), w.BonusBalance)
+}
+
+// Apply moves the balances by the game result, its BonusAmount on the bonus balance and the rest on the cash balance
+// The wagering requirement of a bonus grant is added along with it, and taken back by its reversal
+// A wagered lose also counts its amount towards the wagering requirement, see Wager,
+// recording the requirement it worked off so that its reversal puts it back
+// It records the resulting balances on the game result
+// It returns ErrUserNegativeBalance if either balance would become negative,
+// and ErrBalanceTooHigh or ErrWageringRequirementTooHigh if a balance or the wagering requirement would not fit in its column,
+// leaving the wallet and the game result untouched
+func (w *Wallet) Apply(gameResult *GameResult, wagered bool) error {
+	cash := gameResult.Amount - gameResult.BonusAmount
+	bonus := gameResult.BonusAmount
+	requirement := gameResult.WageringRequirement
+	if gameResult.GameStatus == GameStatusLose {
+		cash, bonus, requirement = -cash, -bonus, -requirement
+	}
+
+	if w.Balance+cash < 0 || w.BonusBalance+bonus < 0 {
+		return ErrUserNegativeBalance
+	}
+	if w.WageringRequirement+requirement > MaxMoney {
+		return ErrWageringRequirementTooHigh
+	}
+
+	// The settled bonus moves into the cash balance, so the balances are only bounded once applied
+	applied := *w
+	applied.Balance += cash
+	applied.BonusBalance += bonus
+	applied.WageringRequirement = max(applied.WageringRequirement+requirement, 0)
+	worked := gameResult.WageringRequirement
+	if wagered && gameResult.GameStatus == GameStatusLose {
+		worked = min(gameResult.Amount, applied.WageringRequirement)
+		applied.Wager(gameResult.Amount)
+	} else {
+		applied.settleBonus()
+	}
+	if applied.Balance > MaxMoney || applied.BonusBalance > MaxMoney {
+		return ErrBalanceTooHigh
+	}
+	*w = applied
+
+	gameResult.WageringRequirement = worked
+	gameResult.BalanceAfter = w.Balance
+	gameResult.BonusBalanceAfter = w.BonusBalance
+	return nil
+}
+
+// Wager counts a lost amount towards the wagering requirement
+func (w *Wallet) Wager(amount Money) {
+	w.WageringRequirement = max(w.WageringRequirement-amount, 0)
+	w.settleBonus()
+}
+
+// settleBonus turns the bonus balance into cash once the wagering requirement is met,
+// and drops the wagering requirement once the bonus balance is spent
+func (w *Wallet) settleBonus() {
+	switch {
+	case w.WageringRequirement == 0:
+		w.Balance += w.BonusBalance
+		w.BonusBalance = 0
+	case w.BonusBalance == 0:
+		w.WageringRequirement = 0
+	}
+}
@@ -0,0 +1,187 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletAvailableBalance(t *testing.T) {
+	wallet := Wallet{Currency: DefaultCurrency, Balance: Money(10000), HeldBalance: Money(2500)}
+	assert.Equal(t, Money(7500), wallet.AvailableBalance())
+
+	wallet.BonusBalance = Money(1000)
+	assert.Equal(t, Money(8500), wallet.AvailableBalance())
+}
+
+func TestParseBalanceOrder(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *BalanceOrder
+	}{
+		{"cash_first", func() *BalanceOrder { o := BalanceOrderCashFirst; return &o }()},
+		{"bonus_first", func() *BalanceOrder { o := BalanceOrderBonusFirst; return &o }()},
+		{"cash", nil},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseBalanceOrder(tt.value))
+		})
+	}
+}
+
+func TestWalletBonusShare(t *testing.T) {
+	wallet := Wallet{Balance: Money(1000), BonusBalance: Money(2000)}
+
+	tests := []struct {
+		name   string
+		amount Money
+		order  BalanceOrder
+		want   Money
+	}{
+		{"cash first within cash", Money(800), BalanceOrderCashFirst, 0},
+		{"cash first beyond cash", Money(1500), BalanceOrderCashFirst, Money(500)},
+		{"cash first beyond both", Money(5000), BalanceOrderCashFirst, Money(2000)},
+		{"bonus first within bonus", Money(800), BalanceOrderBonusFirst, Money(800)},
+		{"bonus first beyond bonus", Money(2500), BalanceOrderBonusFirst, Money(2000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, wallet.BonusShare(tt.amount, tt.order))
+		})
+	}
+}
+
+func TestWalletApplyBonusGrant(t *testing.T) {
+	wallet := Wallet{Balance: Money(1000)}
+	grant := GameResult{GameStatus: GameStatusWin, Amount: Money(2000), BonusAmount: Money(2000), WageringRequirement: Money(10000)}
+
+	require.NoError(t, wallet.Apply(&grant, false))
+	assert.Equal(t, Money(1000), wallet.Balance)
+	assert.Equal(t, Money(2000), wallet.BonusBalance)
+	assert.Equal(t, Money(10000), wallet.WageringRequirement)
+	assert.Equal(t, Money(1000), grant.BalanceAfter)
+	assert.Equal(t, Money(2000), grant.BonusBalanceAfter)
+
+	// Reversing the grant takes the bonus and its wagering requirement back
+	reversal := GameResult{GameStatus: GameStatusLose, Amount: Money(2000), BonusAmount: Money(2000), WageringRequirement: Money(10000)}
+	require.NoError(t, wallet.Apply(&reversal, false))
+	assert.Equal(t, Wallet{Balance: Money(1000)}, wallet)
+}
+
+func TestWalletApplyWageringRequirementTooHigh(t *testing.T) {
+	wallet := Wallet{BonusBalance: Money(2000), WageringRequirement: MaxMoney - Money(100)}
+	grant := GameResult{GameStatus: GameStatusWin, Amount: Money(2000), BonusAmount: Money(2000), WageringRequirement: Money(200)}
+
+	assert.ErrorIs(t, wallet.Apply(&grant, false), ErrWageringRequirementTooHigh)
+	assert.Equal(t, Money(2000), wallet.BonusBalance, "left untouched")
+}
+
+func TestWalletApplyBalanceTooHigh(t *testing.T) {
+	wallet := Wallet{Balance: MaxMoney - Money(100)}
+	win := GameResult{GameStatus: GameStatusWin, Amount: Money(200)}
+
+	assert.ErrorIs(t, wallet.Apply(&win, false), ErrBalanceTooHigh)
+	assert.Equal(t, MaxMoney-Money(100), wallet.Balance, "left untouched")
+	assert.Equal(t, Money(0), win.BalanceAfter)
+
+	wallet = Wallet{BonusBalance: MaxMoney - Money(100), WageringRequirement: Money(1000)}
+	grant := GameResult{GameStatus: GameStatusWin, Amount: Money(200), BonusAmount: Money(200)}
+	assert.ErrorIs(t, wallet.Apply(&grant, false), ErrBalanceTooHigh)
+
+	// Meeting the wagering requirement would turn a bonus balance too high for the cash balance into cash
+	wallet = Wallet{Balance: MaxMoney - Money(100), BonusBalance: Money(1000), WageringRequirement: Money(500)}
+	lose := GameResult{GameStatus: GameStatusLose, Amount: Money(500), BonusAmount: Money(0)}
+	assert.ErrorIs(t, wallet.Apply(&lose, true), ErrBalanceTooHigh)
+	assert.Equal(t, Wallet{Balance: MaxMoney - Money(100), BonusBalance: Money(1000), WageringRequirement: Money(500)}, wallet, "left untouched")
+	assert.Equal(t, Money(0), lose.WageringRequirement)
+
+	// Up to the largest amount the column can store
+	wallet = Wallet{Balance: MaxMoney - Money(100)}
+	win = GameResult{GameStatus: GameStatusWin, Amount: Money(100)}
+	require.NoError(t, wallet.Apply(&win, false))
+	assert.Equal(t, MaxMoney, win.BalanceAfter)
+}
+
+func TestWalletApplyWageredLose(t *testing.T) {
+	wallet := Wallet{Balance: Money(1000), BonusBalance: Money(2000), WageringRequirement: Money(3000)}
+	lose := GameResult{GameStatus: GameStatusLose, Amount: Money(1500), BonusAmount: Money(500)}
+
+	require.NoError(t, wallet.Apply(&lose, true))
+	assert.Equal(t, Money(0), wallet.Balance)
+	assert.Equal(t, Money(1500), wallet.BonusBalance)
+	assert.Equal(t, Money(1500), wallet.WageringRequirement)
+	assert.Equal(t, Money(1500), lose.WageringRequirement, "the requirement worked off")
+	assert.Equal(t, Money(0), lose.BalanceAfter)
+	assert.Equal(t, Money(1500), lose.BonusBalanceAfter)
+
+	// Reversing the lose puts back the balances along with the requirement it worked off
+	reversal := GameResult{GameStatus: GameStatusWin, Amount: lose.Amount, BonusAmount: lose.BonusAmount, WageringRequirement: lose.WageringRequirement}
+	reverted := wallet
+	require.NoError(t, reverted.Apply(&reversal, false))
+	assert.Equal(t, Wallet{Balance: Money(1000), BonusBalance: Money(2000), WageringRequirement: Money(3000)}, reverted)
+
+	// Meeting the wagering requirement turns the bonus left into cash
+	lose = GameResult{GameStatus: GameStatusLose, Amount: Money(1500), BonusAmount: Money(1500)}
+	wallet.BonusBalance = Money(2000)
+	require.NoError(t, wallet.Apply(&lose, true))
+	assert.Equal(t, Money(500), wallet.Balance)
+	assert.Equal(t, Money(0), wallet.BonusBalance)
+	assert.Equal(t, Money(0), wallet.WageringRequirement)
+	assert.Equal(t, Money(500), lose.BalanceAfter)
+	assert.Equal(t, Money(0), lose.BonusBalanceAfter)
+}
+
+func TestWalletApplyUnwageredLose(t *testing.T) {
+	wallet := Wallet{Balance: Money(1000), BonusBalance: Money(2000), WageringRequirement: Money(3000)}
+	lose := GameResult{GameStatus: GameStatusLose, Amount: Money(500)}
+
+	require.NoError(t, wallet.Apply(&lose, false))
+	assert.Equal(t, Money(500), wallet.Balance)
+	assert.Equal(t, Money(3000), wallet.WageringRequirement)
+}
+
+func TestWalletApplySpentBonusDropsWagering(t *testing.T) {
+	wallet := Wallet{BonusBalance: Money(2000), WageringRequirement: Money(10000)}
+	lose := GameResult{GameStatus: GameStatusLose, Amount: Money(2000), BonusAmount: Money(2000)}
+
+	require.NoError(t, wallet.Apply(&lose, true))
+	assert.Equal(t, Wallet{}, wallet)
+}
+
+func TestWalletApplyNegativeBalance(t *testing.T) {
+	wallet := Wallet{Balance: Money(1000), BonusBalance: Money(500), WageringRequirement: Money(1000)}
+
+	tests := []struct {
+		name       string
+		gameResult GameResult
+	}{
+		{"cash", GameResult{GameStatus: GameStatusLose, Amount: Money(1200), BonusAmount: Money(100)}},
+		{"bonus", GameResult{GameStatus: GameStatusLose, Amount: Money(600), BonusAmount: Money(600)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := wallet
+			require.ErrorIs(t, w.Apply(&tt.gameResult, true), ErrUserNegativeBalance)
+			assert.Equal(t, wallet, w)
+		})
+	}
+}
+
+func TestWalletWager(t *testing.T) {
+	wallet := Wallet{Balance: Money(1000), BonusBalance: Money(2000), WageringRequirement: Money(3000)}
+
+	wallet.Wager(Money(1000))
+	assert.Equal(t, Money(2000), wallet.WageringRequirement)
+	assert.Equal(t, Money(2000), wallet.BonusBalance)
+
+	wallet.Wager(Money(5000))
+	assert.Equal(t, Money(0), wallet.WageringRequirement)
+	assert.Equal(t, Money(0), wallet.BonusBalance)
+	assert.Equal(t, Money(3000), wallet.Balance)
+}
@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header carrying the request ID,
+// honored when sent by the client and always echoed back in the response
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the attribute under which the request ID is logged
+const RequestIDKey = "request_id"
+
+// requestIDPattern restricts the client provided request IDs to a safe subset,
+// so they can be logged and echoed back as is
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDContextKey struct{}
+
+// Setup installs a JSON logger writing to w as the default slog logger.
+// The standard log package gets redirected to it as well.
+func Setup(w io.Writer) {
+	slog.SetDefault(slog.New(NewHandler(w)))
+}
+
+// NewHandler returns a JSON handler writing to w,
+// adding the request ID found in the context to every record
+func NewHandler(w io.Writer) slog.Handler {
+	return contextHandler{Handler: slog.NewJSONHandler(w, nil)}
+}
+
+// contextHandler decorates a handler with the values carried by the context
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestIDFrom(ctx); requestID != "" {
+		record.AddAttrs(slog.String(RequestIDKey, requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// NewRequestID generates a new random request ID
+func NewRequestID() string {
+	return uuid.NewString()
+}
+
+// ValidRequestID tells whether a client provided request ID can be honored
+func ValidRequestID(requestID string) bool {
+	return requestIDPattern.MatchString(requestID)
+}
+
+// WithRequestID returns a copy of the context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestIDFrom returns the request ID carried by the context, if any
+func RequestIDFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// Error is the attribute under which errors are logged
+func Error(err error) slog.Attr {
+	return slog.Any("error", err)
+}
@@ -0,0 +1,55 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerWritesJSONWithRequestID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(NewHandler(buf))
+
+	ctx := WithRequestID(context.Background(), "abc-123")
+	logger.ErrorContext(ctx, "error locating user", Error(errors.New("boom")), slog.Int("user_id", 1))
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "error locating user", record["msg"])
+	assert.Equal(t, "boom", record["error"])
+	assert.Equal(t, float64(1), record["user_id"])
+	assert.Equal(t, "abc-123", record[RequestIDKey])
+}
+
+func TestHandlerWithoutRequestID(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(NewHandler(buf)).With(slog.String("component", "dao"))
+
+	logger.InfoContext(context.Background(), "opened database connection")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "dao", record["component"])
+	assert.NotContains(t, record, RequestIDKey)
+}
+
+func TestRequestIDFrom(t *testing.T) {
+	assert.Empty(t, RequestIDFrom(context.Background()))
+	assert.Equal(t, "abc", RequestIDFrom(WithRequestID(context.Background(), "abc")))
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID(NewRequestID()))
+	assert.True(t, ValidRequestID("client.req:42_a-b"))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("with space"))
+	assert.False(t, ValidRequestID("line\nbreak"))
+	assert.False(t, ValidRequestID(string(bytes.Repeat([]byte("a"), 129))))
+}
@@ -0,0 +1,215 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "abm"
+
+const (
+	OperationCreateGameResult  = "create_game_result"
+	OperationReverseGameResult = "reverse_game_result"
+	OperationCreateAdjustment  = "create_adjustment"
+	OperationCreateHold        = "create_hold"
+	OperationCaptureHold       = "capture_hold"
+	OperationReleaseHold       = "release_hold"
+	OperationGrantBonus        = "grant_bonus"
+)
+
+const (
+	OutcomeSuccess  = "success"
+	OutcomeReplayed = "replayed"
+	OutcomeError    = "error"
+)
+
+// outcomes labels the business errors of the game result operations,
+// any other error is labelled as OutcomeError
+var outcomes = []struct {
+	err   error
+	label string
+}{
+	{entity.ErrUserNotFound, "user_not_found"},
+	{entity.ErrUserFrozen, "user_frozen"},
+	{entity.ErrUserClosed, "user_closed"},
+	{entity.ErrUserNegativeBalance, "negative_balance"},
+	{entity.ErrTransactionIdExists, "transaction_id_exists"},
+	{entity.ErrTransactionNotFound, "transaction_not_found"},
+	{entity.ErrTransactionAlreadyReversed, "transaction_already_reversed"},
+	{entity.ErrTransactionNotReversible, "transaction_not_reversible"},
+	{entity.ErrHoldNotFound, "hold_not_found"},
+	{entity.ErrHoldNotOpen, "hold_not_open"},
+	{entity.ErrHoldExpired, "hold_expired"},
+	{entity.ErrRoundNotFound, "round_not_found"},
+	{entity.ErrRoundNotOpen, "round_not_open"},
+	{entity.ErrUnknownCurrency, "unknown_currency"},
+	{entity.ErrCurrencyMismatch, "currency_mismatch"},
+	{entity.ErrInvalidAmount, "invalid_amount"},
+}
+
+// Metrics holds the Prometheus collectors of the service, on its own registry
+// A nil *Metrics is valid, and observes nothing
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests        *prometheus.CounterVec
+	httpRequestDuration *prometheus.HistogramVec
+	gameResultOutcomes  *prometheus.CounterVec
+	gameResultAmounts   *prometheus.CounterVec
+
+	reconciliationRuns          *prometheus.CounterVec
+	reconciliationDiscrepancies *prometheus.GaugeVec
+	reconciliationLastSuccess   prometheus.Gauge
+}
+
+// NewMetrics creates the service collectors, along with the Go runtime and process ones
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests, by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of the HTTP requests, by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+
+		gameResultOutcomes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "game_result_operations_total",
+			Help:      "Number of game result operations, by operation and outcome.",
+		}, []string{"operation", "outcome"}),
+
+		gameResultAmounts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "game_result_amount_total",
+			Help:      "Sum of the recorded game result amounts, by state, transaction source and currency.",
+		}, []string{"state", "source", "currency"}),
+
+		reconciliationRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconciliation_runs_total",
+			Help:      "Number of balance reconciliations, by outcome.",
+		}, []string{"outcome"}),
+
+		reconciliationDiscrepancies: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "reconciliation_discrepancies",
+			Help:      "Number of wallets whose balance differs from their transaction history at the last reconciliation, by currency.",
+		}, []string{"currency"}),
+
+		reconciliationLastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "reconciliation_last_success_timestamp_seconds",
+			Help:      "Time of the last successful balance reconciliation.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpRequestDuration,
+		m.gameResultOutcomes,
+		m.gameResultAmounts,
+		m.reconciliationRuns,
+		m.reconciliationDiscrepancies,
+		m.reconciliationLastSuccess,
+	)
+
+	return m
+}
+
+// Handler serves the collected metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// MustRegister registers additional collectors, panicking on conflicts
+func (m *Metrics) MustRegister(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// ObserveHTTPRequest counts a served HTTP request and its latency
+// The route is the path template, to keep the number of series bounded
+func (m *Metrics) ObserveHTTPRequest(route string, method string, status int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpRequestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveGameResultOutcome counts the outcome of a game result operation
+func (m *Metrics) ObserveGameResultOutcome(operation string, outcome string) {
+	if m == nil {
+		return
+	}
+
+	m.gameResultOutcomes.WithLabelValues(operation, outcome).Inc()
+}
+
+// ObserveGameResultError counts a failed game result operation, labelled by its error
+func (m *Metrics) ObserveGameResultError(operation string, err error) {
+	m.ObserveGameResultOutcome(operation, ErrorOutcome(err))
+}
+
+// ObserveGameResultAmount adds a recorded game result to the amount totals
+func (m *Metrics) ObserveGameResultAmount(gameResult entity.GameResult) {
+	if m == nil {
+		return
+	}
+
+	m.gameResultAmounts.
+		WithLabelValues(string(gameResult.GameStatus), string(gameResult.TransactionSource), string(gameResult.Currency)).
+		Add(float64(gameResult.Amount) / 100)
+}
+
+// ObserveReconciliation records a successful balance reconciliation along with its discrepancies,
+// replacing the ones of the previous reconciliation
+func (m *Metrics) ObserveReconciliation(reconciliation entity.Reconciliation) {
+	if m == nil {
+		return
+	}
+
+	m.reconciliationRuns.WithLabelValues(OutcomeSuccess).Inc()
+	m.reconciliationDiscrepancies.Reset()
+	for _, discrepancy := range reconciliation.Discrepancies {
+		m.reconciliationDiscrepancies.WithLabelValues(string(discrepancy.Currency)).Inc()
+	}
+	m.reconciliationLastSuccess.Set(float64(reconciliation.FinishedAt.Unix()))
+}
+
+// ObserveReconciliationError counts a failed balance reconciliation, keeping the discrepancies of the last successful one
+func (m *Metrics) ObserveReconciliationError() {
+	if m == nil {
+		return
+	}
+
+	m.reconciliationRuns.WithLabelValues(OutcomeError).Inc()
+}
+
+// ErrorOutcome returns the outcome label of the given error
+func ErrorOutcome(err error) string {
+	for _, outcome := range outcomes {
+		if errors.Is(err, outcome.err) {
+			return outcome.label
+		}
+	}
+	return OutcomeError
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	m := NewMetrics()
+
+	m.ObserveHTTPRequest("/user/{id}/balance", http.MethodGet, http.StatusOK, 10*time.Millisecond)
+	m.ObserveHTTPRequest("/user/{id}/balance", http.MethodGet, http.StatusOK, 20*time.Millisecond)
+	m.ObserveHTTPRequest("/user/{id}/balance", http.MethodGet, http.StatusNotFound, 5*time.Millisecond)
+
+	require.Equal(t, float64(2), testutil.ToFloat64(m.httpRequests.WithLabelValues("/user/{id}/balance", "GET", "200")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.httpRequests.WithLabelValues("/user/{id}/balance", "GET", "404")))
+	require.Equal(t, 2, testutil.CollectAndCount(m.httpRequestDuration))
+}
+
+func TestObserveGameResultOutcome(t *testing.T) {
+	m := NewMetrics()
+
+	m.ObserveGameResultOutcome(OperationCreateGameResult, OutcomeSuccess)
+	m.ObserveGameResultError(OperationCreateGameResult, entity.ErrUserNegativeBalance)
+	m.ObserveGameResultError(OperationCreateGameResult, fmt.Errorf("executing transaction: %w", entity.ErrTransactionIdExists))
+	m.ObserveGameResultError(OperationCreateGameResult, errors.New("connection reset"))
+
+	require.Equal(t, float64(1), testutil.ToFloat64(m.gameResultOutcomes.WithLabelValues(OperationCreateGameResult, OutcomeSuccess)))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.gameResultOutcomes.WithLabelValues(OperationCreateGameResult, "negative_balance")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.gameResultOutcomes.WithLabelValues(OperationCreateGameResult, "transaction_id_exists")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.gameResultOutcomes.WithLabelValues(OperationCreateGameResult, OutcomeError)))
+}
+
+func TestObserveGameResultAmount(t *testing.T) {
+	m := NewMetrics()
+
+	m.ObserveGameResultAmount(entity.GameResult{GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: entity.Money(1050), Currency: entity.DefaultCurrency})
+	m.ObserveGameResultAmount(entity.GameResult{GameStatus: entity.GameStatusWin, TransactionSource: entity.TransactionSourceGame, Amount: entity.Money(25), Currency: entity.DefaultCurrency})
+	m.ObserveGameResultAmount(entity.GameResult{GameStatus: entity.GameStatusLose, TransactionSource: entity.TransactionSourcePayment, Amount: entity.Money(300), Currency: entity.DefaultCurrency})
+	m.ObserveGameResultAmount(entity.GameResult{GameStatus: entity.GameStatusLose, TransactionSource: entity.TransactionSourcePayment, Amount: entity.Money(50000), Currency: "JPY"})
+
+	require.InDelta(t, 10.75, testutil.ToFloat64(m.gameResultAmounts.WithLabelValues("win", "game", "EUR")), 0.0001)
+	require.InDelta(t, 3.00, testutil.ToFloat64(m.gameResultAmounts.WithLabelValues("lose", "payment", "EUR")), 0.0001)
+	require.InDelta(t, 500.00, testutil.ToFloat64(m.gameResultAmounts.WithLabelValues("lose", "payment", "JPY")), 0.0001)
+}
+
+func TestObserveReconciliation(t *testing.T) {
+	m := NewMetrics()
+	finishedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	m.ObserveReconciliation(entity.Reconciliation{FinishedAt: finishedAt, Discrepancies: []entity.BalanceDiscrepancy{
+		{UserID: 1, Currency: entity.DefaultCurrency},
+		{UserID: 2, Currency: entity.DefaultCurrency},
+		{UserID: 2, Currency: "JPY"},
+	}})
+	require.Equal(t, float64(2), testutil.ToFloat64(m.reconciliationDiscrepancies.WithLabelValues("EUR")))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.reconciliationDiscrepancies.WithLabelValues("JPY")))
+
+	// A failed reconciliation keeps the discrepancies of the last successful one
+	m.ObserveReconciliationError()
+	require.Equal(t, 2, testutil.CollectAndCount(m.reconciliationDiscrepancies))
+
+	// The next reconciliation replaces them
+	m.ObserveReconciliation(entity.Reconciliation{FinishedAt: finishedAt.Add(time.Hour), Discrepancies: []entity.BalanceDiscrepancy{
+		{UserID: 2, Currency: "JPY"},
+	}})
+	require.Equal(t, 1, testutil.CollectAndCount(m.reconciliationDiscrepancies))
+	require.Equal(t, float64(2), testutil.ToFloat64(m.reconciliationRuns.WithLabelValues(OutcomeSuccess)))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.reconciliationRuns.WithLabelValues(OutcomeError)))
+	require.Equal(t, float64(finishedAt.Add(time.Hour).Unix()), testutil.ToFloat64(m.reconciliationLastSuccess))
+}
+
+func TestNilMetricsObservesNothing(t *testing.T) {
+	var m *Metrics
+
+	require.NotPanics(t, func() {
+		m.ObserveHTTPRequest("/health", http.MethodGet, http.StatusOK, time.Millisecond)
+		m.ObserveGameResultOutcome(OperationCreateGameResult, OutcomeSuccess)
+		m.ObserveGameResultError(OperationCreateGameResult, entity.ErrUserNotFound)
+		m.ObserveGameResultAmount(entity.GameResult{})
+		m.ObserveReconciliation(entity.Reconciliation{})
+		m.ObserveReconciliationError()
+	})
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	m := NewMetrics()
+	m.MustRegister(NewDBStatsCollector(func() sql.DBStats {
+		return sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2, WaitCount: 7}
+	}))
+
+	expected := `
+# HELP abm_db_open_connections The number of established connections both in use and idle.
+# TYPE abm_db_open_connections gauge
+abm_db_open_connections 3
+# HELP abm_db_wait_count_total The total number of connections waited for.
+# TYPE abm_db_wait_count_total counter
+abm_db_wait_count_total 7
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "abm_db_open_connections", "abm_db_wait_count_total")
+	require.NoError(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveGameResultOutcome(OperationCreateGameResult, OutcomeSuccess)
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	body, err := io.ReadAll(rr.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `abm_game_result_operations_total{operation="create_game_result",outcome="success"} 1`)
+	require.Contains(t, string(body), "go_goroutines")
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows bursts of up to Requests, refilled evenly over Per.
+// The zero Limit disables the limiting.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit parses a limit formatted as <requests>/<duration>, eg: "100/1m".
+// An empty value disables the limiting.
+func ParseLimit(value string) (Limit, error) {
+	if value == "" {
+		return Limit{}, nil
+	}
+
+	requests, per, found := strings.Cut(value, "/")
+	if !found {
+		return Limit{}, fmt.Errorf("rate limit %q is not formatted as <requests>/<duration>", value)
+	}
+
+	limit := Limit{}
+	var err error
+	if limit.Requests, err = strconv.Atoi(requests); err != nil || limit.Requests <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q requests must be a positive integer", value)
+	}
+	if limit.Per, err = time.ParseDuration(per); err != nil || limit.Per <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q duration must be positive", value)
+	}
+	return limit, nil
+}
+
+// Enabled tells whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Per > 0
+}
+
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Per)
+}
+
+// bucket holds the tokens left as of the last update
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is a set of token buckets, one per key, all sharing the same limit.
+// It is safe for concurrent use.
+type Limiter struct {
+	limit    Limit
+	interval time.Duration // the time to refill a single token
+
+	lock    sync.Mutex
+	buckets map[string]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// NewLimiter creates a Limiter enforcing the limit on every key
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:    limit,
+		interval: limit.Per / time.Duration(limit.Requests),
+		buckets:  make(map[string]*bucket),
+		now:      time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the key.
+// When empty, it returns false along with how long to wait for the next token.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: float64(l.limit.Requests), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(l.limit.Requests), b.tokens+float64(now.Sub(b.updated))/float64(l.interval))
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(l.interval))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets refilled by now, they are the same as new ones.
+// It runs at most once per limit period, keeping the map bounded by the keys active within it.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.limit.Per {
+		return
+	}
+	l.swept = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= l.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit("100/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 100, Per: time.Minute}, limit)
+	assert.True(t, limit.Enabled())
+	assert.Equal(t, "100/1m0s", limit.String())
+
+	limit, err = ParseLimit("")
+	require.NoError(t, err)
+	assert.False(t, limit.Enabled())
+	assert.Equal(t, "unlimited", limit.String())
+}
+
+func TestParseLimitInvalid(t *testing.T) {
+	for _, value := range []string{"100", "a/1s", "0/1s", "-1/1s", "10/a", "10/0s", "10/-1s"} {
+		_, err := ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewLimiter(Limit{Requests: 2, Per: time.Second})
+	limiter.now = func() time.Time { return now }
+
+	// The burst
+	allowed, _ := limiter.Allow("user:1")
+	assert.True(t, allowed)
+	allowed, _ = limiter.Allow("user:1")
+	assert.True(t, allowed)
+
+	// Exhausted, a token comes back every 500ms
+	allowed, retryAfter := limiter.Allow("user:1")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	// Other keys have their own bucket
+	allowed, _ = limiter.Allow("user:2")
+	assert.True(t, allowed)
+
+	now = now.Add(200 * time.Millisecond)
+	allowed, retryAfter = limiter.Allow("user:1")
+	assert.False(t, allowed)
+	assert.Equal(t, 300*time.Millisecond, retryAfter)
+
+	now = now.Add(300 * time.Millisecond)
+	allowed, _ = limiter.Allow("user:1")
+	assert.True(t, allowed)
+}
+
+func TestLimiterSweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewLimiter(Limit{Requests: 1, Per: time.Second})
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("user:1")
+	limiter.Allow("user:2")
+	assert.Len(t, limiter.buckets, 2)
+
+	now = now.Add(time.Second)
+	limiter.Allow("user:3")
+	assert.Len(t, limiter.buckets, 1, "the refilled buckets are dropped")
+
+	allowed, _ := limiter.Allow("user:1")
+	assert.True(t, allowed)
+}
@@ -6,10 +6,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/ildomm/account-balance-manager/dao"
 	"github.com/ildomm/account-balance-manager/entity"
-	"log"
+	"github.com/ildomm/account-balance-manager/logging"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // accountHandler handles all requests related to game results.
@@ -37,6 +40,10 @@ func (h *accountHandler) CreateGameResultFunc(w http.ResponseWriter, r *http.Req
 	// Validate the request body.
 	var req CreateGameResultRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, entity.ErrInvalidAmount) {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidAmount.Error()})
+			return
+		}
 		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrRequestPayload.Error()})
 		return
 	}
@@ -46,10 +53,13 @@ func (h *accountHandler) CreateGameResultFunc(w http.ResponseWriter, r *http.Req
 		WriteErrorResponse(w, http.StatusBadRequest, []string{"transaction_id is required"})
 		return
 	}
+	if entity.IsReservedTransactionID(req.TransactionID) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrReservedTransactionID.Error()})
+		return
+	}
 
-	// Validate amount type cast and value
-	amount, err := strconv.ParseFloat(req.Amount, 64)
-	if err != nil || amount <= 0 {
+	// Validate amount value, its format is validated while decoding
+	if req.Amount <= 0 {
 		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidAmount.Error()})
 		return
 	}
@@ -60,6 +70,13 @@ func (h *accountHandler) CreateGameResultFunc(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Validate the currency, its precision is validated along with the balance.
+	currency, err := parseRequestCurrency(req.Currency)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+
 	// Extract and validate the user ID from the request path.
 	vars := mux.Vars(r)
 	userID, err := strconv.Atoi(vars["id"])
@@ -69,22 +86,95 @@ func (h *accountHandler) CreateGameResultFunc(w http.ResponseWriter, r *http.Req
 	}
 
 	// Perform the business logic.
-	_, err = h.accountDAO.CreateGameResult(r.Context(), userID, req.GameStatus, amount, *transactionSource, req.TransactionID)
+	gameResult, err := h.accountDAO.CreateGameResult(r.Context(), userID, req.GameStatus, req.Amount, currency, *transactionSource, req.TransactionID, req.RoundID)
 	if err != nil {
 		switch {
-		case errors.Is(err, entity.ErrUserNotFound):
+		case errors.Is(err, entity.ErrInvalidRoundID) || errors.Is(err, entity.ErrUnknownCurrency) || errors.Is(err, entity.ErrInvalidAmount):
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserNotFound) || errors.Is(err, entity.ErrRoundNotFound):
 			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
-		case errors.Is(err, entity.ErrTransactionIdExists) || errors.Is(err, entity.ErrUserNegativeBalance):
+		case errors.Is(err, entity.ErrTransactionIdExists) || errors.Is(err, entity.ErrRoundNotOpen) || errors.Is(err, entity.ErrCurrencyMismatch):
+			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserFrozen):
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserClosed):
+			WriteErrorResponse(w, http.StatusGone, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserNegativeBalance) || errors.Is(err, entity.ErrBalanceTooHigh):
 			WriteErrorResponse(w, http.StatusNotAcceptable, []string{err.Error()})
 		default:
 			// Log the actual error but return a generic message
-			log.Printf("Internal error: %v", err)
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
 			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
 		}
 		return
 	}
 
-	WriteAPIResponse(w, http.StatusOK, nil)
+	transactionResponse := transformTransactionResponse(*gameResult)
+	WriteAPIResponse(w, http.StatusOK, transactionResponse)
+}
+
+// ReverseGameResultFunc handles the request to reverse a game result of the user.
+func (h *accountHandler) ReverseGameResultFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Validate the headers.
+	transactionSource := entity.ParseTransactionSource(strings.ToLower(r.Header.Get("Source-Type")))
+	if transactionSource == nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidTransactionSource.Error()})
+		return
+	}
+
+	// Extract and validate the user ID and the transaction ID from the request path.
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return
+	}
+	transactionID := vars["transactionId"]
+
+	// Perform the business logic.
+	reversal, err := h.accountDAO.ReverseGameResult(r.Context(), userID, transactionID, *transactionSource)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrUserNotFound) || errors.Is(err, entity.ErrTransactionNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case errors.Is(err, entity.ErrTransactionAlreadyReversed) || errors.Is(err, entity.ErrTransactionNotReversible):
+			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+		case errors.Is(err, entity.ErrReversalSourceMismatch):
+			WriteErrorResponse(w, http.StatusForbidden, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserFrozen):
+			WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserClosed):
+			WriteErrorResponse(w, http.StatusGone, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserNegativeBalance) || errors.Is(err, entity.ErrBalanceTooHigh):
+			WriteErrorResponse(w, http.StatusNotAcceptable, []string{err.Error()})
+		default:
+			// Log the actual error but return a generic message
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		}
+		return
+	}
+
+	transactionResponse := transformTransactionResponse(*reversal)
+	WriteAPIResponse(w, http.StatusOK, transactionResponse)
+}
+
+// CreateUserFunc handles the request to create a new account user.
+func (h *accountHandler) CreateUserFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user, err := h.accountDAO.CreateUser(r.Context())
+	if err != nil {
+		// Log the actual error but return a generic message
+		slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		return
+	}
+
+	userResponse := transformUserResponse(*user)
+	WriteAPIResponse(w, http.StatusCreated, userResponse)
 }
 
 // RetrieveUserFunc handles the request to retrieve the account user.
@@ -106,7 +196,7 @@ func (h *accountHandler) RetrieveUserFunc(w http.ResponseWriter, r *http.Request
 			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
 		default:
 			// Log the actual error but return a generic message
-			log.Printf("Internal error: %v", err)
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
 			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
 		}
 		return
@@ -116,12 +206,585 @@ func (h *accountHandler) RetrieveUserFunc(w http.ResponseWriter, r *http.Request
 	WriteAPIResponse(w, http.StatusOK, userResponse)
 }
 
+// RetrieveBalancesFunc handles the request to retrieve the balances of the account user, one per currency.
+// The `currency` query parameter narrows them down to the balance in that currency.
+// The `at` query parameter asks for the balances as of that past RFC3339 time instead, see retrieveBalancesAt.
+func (h *accountHandler) RetrieveBalancesFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract and validate the user ID from the request path.
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return
+	}
+
+	var currency *entity.Currency
+	if value := r.URL.Query().Get("currency"); value != "" {
+		parsed, parseErr := entity.ParseCurrency(value)
+		if parseErr != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{parseErr.Error()})
+			return
+		}
+		currency = &parsed
+	}
+
+	if value := r.URL.Query().Get("at"); value != "" {
+		h.retrieveBalancesAt(w, r, userID, currency, value)
+		return
+	}
+
+	var wallets []entity.Wallet
+	if currency != nil {
+		var wallet *entity.Wallet
+		wallet, err = h.accountDAO.RetrieveWallet(r.Context(), userID, *currency)
+		if wallet != nil {
+			wallets = []entity.Wallet{*wallet}
+		}
+	} else {
+		wallets, err = h.accountDAO.RetrieveWallets(r.Context(), userID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrUserNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			// Log the actual error but return a generic message
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		}
+		return
+	}
+
+	// The single balance of the former response is the one in the default currency, unless asked for another one
+	legacyCurrency := entity.DefaultCurrency
+	if currency != nil {
+		legacyCurrency = *currency
+	}
+	balancesResponse := transformBalancesResponse(userID, legacyCurrency, wallets)
+	WriteAPIResponse(w, http.StatusOK, balancesResponse)
+}
+
+// retrieveBalancesAt answers the balances of the user as of a past time, computed from the ledger,
+// one per currency the user had transacted in by then, or only the one in the currency when given.
+func (h *accountHandler) retrieveBalancesAt(w http.ResponseWriter, r *http.Request, userID int, currency *entity.Currency, value string) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidTimestamp.Error()})
+		return
+	}
+
+	balances, err := h.accountDAO.RetrieveBalancesAt(r.Context(), userID, at)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrFutureTimestamp):
+			WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		case errors.Is(err, entity.ErrUserNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			// Log the actual error but return a generic message
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		}
+		return
+	}
+
+	// A currency the user had not transacted in by then has a zero balance
+	if currency != nil {
+		balance := entity.HistoricalBalance{UserID: userID, Currency: *currency, At: at}
+		for _, historical := range balances {
+			if historical.Currency == *currency {
+				balance = historical
+			}
+		}
+		balances = []entity.HistoricalBalance{balance}
+	}
+
+	historicalBalancesResponse := transformHistoricalBalancesResponse(userID, at, balances)
+	WriteAPIResponse(w, http.StatusOK, historicalBalancesResponse)
+}
+
+// UpdateUserStatusFunc handles the request to activate, freeze or close the account user.
+func (h *accountHandler) UpdateUserStatusFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract and validate the user ID from the request path.
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return
+	}
+
+	// Parse the request body.
+	var req UpdateUserStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrRequestPayload.Error()})
+		return
+	}
+	status := entity.ParseUserStatus(strings.ToLower(string(req.Status)))
+	if status == nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUserStatus.Error()})
+		return
+	}
+
+	user, err := h.accountDAO.UpdateUserStatus(r.Context(), userID, *status)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrUserNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		case errors.Is(err, entity.ErrInvalidUserStatusTransition):
+			WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+		default:
+			// Log the actual error but return a generic message
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		}
+		return
+	}
+
+	userResponse := transformUserResponse(*user)
+	WriteAPIResponse(w, http.StatusOK, userResponse)
+}
+
+// CreateHoldFunc handles the request to reserve an amount of the user balance for an in-progress bet.
+func (h *accountHandler) CreateHoldFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Validate the headers.
+	transactionSource := entity.ParseTransactionSource(strings.ToLower(r.Header.Get("Source-Type")))
+	if transactionSource == nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidTransactionSource.Error()})
+		return
+	}
+
+	// Validate the request body.
+	var req CreateHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		if errors.Is(err, entity.ErrInvalidAmount) {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidAmount.Error()})
+			return
+		}
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrRequestPayload.Error()})
+		return
+	}
+
+	// Validate amount value, its format is validated while decoding
+	if req.Amount <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidAmount.Error()})
+		return
+	}
+
+	expiresIn := entity.DefaultHoldExpiry
+	if req.ExpiresInSeconds != nil {
+		expiresIn = time.Duration(*req.ExpiresInSeconds) * time.Second
+		if expiresIn <= 0 || expiresIn > entity.MaxHoldExpiry {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidHoldExpiry.Error()})
+			return
+		}
+	}
+
+	// Validate the currency, its precision is validated along with the balance.
+	currency, err := parseRequestCurrency(req.Currency)
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+
+	// Extract and validate the user ID from the request path.
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return
+	}
+
+	// Perform the business logic.
+	hold, err := h.accountDAO.CreateHold(r.Context(), userID, req.Amount, currency, *transactionSource, expiresIn)
+	if err != nil {
+		writeHoldErrorResponse(w, r, err)
+		return
+	}
+
+	holdResponse := transformHoldResponse(*hold)
+	WriteAPIResponse(w, http.StatusCreated, holdResponse)
+}
+
+// parseRequestCurrency parses the currency of a request body, entity.DefaultCurrency when empty.
+func parseRequestCurrency(value string) (entity.Currency, error) {
+	if value == "" {
+		return entity.DefaultCurrency, nil
+	}
+	return entity.ParseCurrency(value)
+}
+
+// RetrieveHoldFunc handles the request to retrieve a hold of the user.
+func (h *accountHandler) RetrieveHoldFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, holdID, ok := parseHoldPath(w, r)
+	if !ok {
+		return
+	}
+
+	hold, err := h.accountDAO.RetrieveHold(r.Context(), userID, holdID)
+	if err != nil {
+		writeHoldErrorResponse(w, r, err)
+		return
+	}
+
+	holdResponse := transformHoldResponse(*hold)
+	WriteAPIResponse(w, http.StatusOK, holdResponse)
+}
+
+// CaptureHoldFunc handles the request to turn a hold of the user into a lose transaction.
+func (h *accountHandler) CaptureHoldFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Validate the headers.
+	transactionSource := entity.ParseTransactionSource(strings.ToLower(r.Header.Get("Source-Type")))
+	if transactionSource == nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidTransactionSource.Error()})
+		return
+	}
+
+	// Validate the request body.
+	var req CaptureHoldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrRequestPayload.Error()})
+		return
+	}
+
+	// Basic request validation
+	if req.TransactionID == "" {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{"transaction_id is required"})
+		return
+	}
+	if entity.IsReservedTransactionID(req.TransactionID) {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrReservedTransactionID.Error()})
+		return
+	}
+
+	userID, holdID, ok := parseHoldPath(w, r)
+	if !ok {
+		return
+	}
+
+	// Perform the business logic.
+	gameResult, err := h.accountDAO.CaptureHold(r.Context(), userID, holdID, req.TransactionID, *transactionSource)
+	if err != nil {
+		writeHoldErrorResponse(w, r, err)
+		return
+	}
+
+	transactionResponse := transformTransactionResponse(*gameResult)
+	WriteAPIResponse(w, http.StatusOK, transactionResponse)
+}
+
+// ReleaseHoldFunc handles the request to give a hold of the user back to the available balance.
+func (h *accountHandler) ReleaseHoldFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Validate the headers.
+	transactionSource := entity.ParseTransactionSource(strings.ToLower(r.Header.Get("Source-Type")))
+	if transactionSource == nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidTransactionSource.Error()})
+		return
+	}
+
+	userID, holdID, ok := parseHoldPath(w, r)
+	if !ok {
+		return
+	}
+
+	hold, err := h.accountDAO.ReleaseHold(r.Context(), userID, holdID, *transactionSource)
+	if err != nil {
+		writeHoldErrorResponse(w, r, err)
+		return
+	}
+
+	holdResponse := transformHoldResponse(*hold)
+	WriteAPIResponse(w, http.StatusOK, holdResponse)
+}
+
+// parseHoldPath extracts and validates the user ID and the hold ID from the request path.
+// It writes the error response when they are invalid.
+func parseHoldPath(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return 0, 0, false
+	}
+	holdID, err := strconv.Atoi(vars["holdId"])
+	if err != nil || holdID <= 0 {
+		WriteErrorResponse(w, http.StatusNotFound, []string{entity.ErrHoldNotFound.Error()})
+		return 0, 0, false
+	}
+	return userID, holdID, true
+}
+
+// writeHoldErrorResponse maps the errors of the hold operations to their HTTP status.
+func writeHoldErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, entity.ErrUnknownCurrency) || errors.Is(err, entity.ErrInvalidAmount):
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+	case errors.Is(err, entity.ErrUserNotFound) || errors.Is(err, entity.ErrHoldNotFound):
+		WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+	case errors.Is(err, entity.ErrHoldNotOpen) || errors.Is(err, entity.ErrHoldExpired) || errors.Is(err, entity.ErrTransactionIdExists):
+		WriteErrorResponse(w, http.StatusConflict, []string{err.Error()})
+	case errors.Is(err, entity.ErrHoldSourceMismatch):
+		WriteErrorResponse(w, http.StatusForbidden, []string{err.Error()})
+	case errors.Is(err, entity.ErrUserFrozen):
+		WriteErrorResponse(w, http.StatusLocked, []string{err.Error()})
+	case errors.Is(err, entity.ErrUserClosed):
+		WriteErrorResponse(w, http.StatusGone, []string{err.Error()})
+	case errors.Is(err, entity.ErrUserNegativeBalance) || errors.Is(err, entity.ErrBalanceTooHigh):
+		WriteErrorResponse(w, http.StatusNotAcceptable, []string{err.Error()})
+	default:
+		// Log the actual error but return a generic message
+		slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+	}
+}
+
+// RetrieveRoundFunc handles the request to retrieve the summary of a game round of the user.
+func (h *accountHandler) RetrieveRoundFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract and validate the user ID and the round ID from the request path.
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return
+	}
+	roundID := vars["roundId"]
+
+	round, err := h.accountDAO.RetrieveRound(r.Context(), userID, roundID)
+	if err != nil {
+		if errors.Is(err, entity.ErrRoundNotFound) {
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+			return
+		}
+		// Log the actual error but return a generic message
+		slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+		WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		return
+	}
+
+	roundResponse := transformRoundResponse(*round)
+	WriteAPIResponse(w, http.StatusOK, roundResponse)
+}
+
+// ListGameResultsFunc handles the request to list the game results of a user, newest-first.
+func (h *accountHandler) ListGameResultsFunc(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract and validate the user ID from the request path.
+	vars := mux.Vars(r)
+	userID, err := strconv.Atoi(vars["id"])
+	if err != nil || userID <= 0 {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrInvalidUser.Error()})
+		return
+	}
+
+	// Validate the pagination and filter parameters.
+	filter, err := parseGameResultFilter(r.URL.Query())
+	if err != nil {
+		WriteErrorResponse(w, http.StatusBadRequest, []string{err.Error()})
+		return
+	}
+	filter.UserID = userID
+
+	page, err := h.accountDAO.ListGameResults(r.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrUserNotFound):
+			WriteErrorResponse(w, http.StatusNotFound, []string{err.Error()})
+		default:
+			// Log the actual error but return a generic message
+			slog.ErrorContext(r.Context(), "internal error", logging.Error(err))
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{"An internal error occurred"})
+		}
+		return
+	}
+
+	gameResultsResponse := transformGameResultsResponse(userID, *page)
+	WriteAPIResponse(w, http.StatusOK, gameResultsResponse)
+}
+
+// parseGameResultFilter builds the game results filter from the URL query parameters.
+func parseGameResultFilter(query url.Values) (entity.GameResultFilter, error) {
+	filter := entity.GameResultFilter{Limit: DefaultPageLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > MaxPageLimit {
+			return filter, entity.ErrInvalidPageLimit
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := entity.ParseGameResultCursor(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Cursor = cursor
+	}
+
+	if value := query.Get("state"); value != "" {
+		filter.GameStatus = entity.ParseGameStatus(strings.ToLower(value))
+		if filter.GameStatus == nil {
+			return filter, entity.ErrInvalidGameStatus
+		}
+	}
+
+	if value := query.Get("source"); value != "" {
+		filter.TransactionSource = entity.ParseTransactionSource(strings.ToLower(value))
+		if filter.TransactionSource == nil {
+			return filter, entity.ErrInvalidTransactionSource
+		}
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.CreatedFrom, "to": &filter.CreatedTo} {
+		if value := query.Get(param); value != "" {
+			moment, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, entity.ErrInvalidTimeWindow
+			}
+			moment = moment.UTC()
+			*target = &moment
+		}
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return filter, entity.ErrInvalidTimeWindow
+	}
+
+	return filter, nil
+}
+
+// Transform entity.GameResultPage to server.GameResultsResponse
+func transformGameResultsResponse(userID int, page entity.GameResultPage) GameResultsResponse {
+	response := GameResultsResponse{
+		UserID:       userID,
+		Transactions: make([]TransactionResponse, 0, len(page.GameResults)),
+	}
+
+	for _, gameResult := range page.GameResults {
+		response.Transactions = append(response.Transactions, transformTransactionResponse(gameResult))
+	}
+
+	if page.NextCursor != nil {
+		response.NextCursor = page.NextCursor.Encode()
+	}
+
+	return response
+}
+
+// Transform entity.GameResult to server.TransactionResponse
+func transformTransactionResponse(gameResult entity.GameResult) TransactionResponse {
+	return TransactionResponse{
+		ID:                    gameResult.ID,
+		TransactionID:         gameResult.TransactionID,
+		GameStatus:            gameResult.GameStatus,
+		Amount:                gameResult.Amount,
+		Currency:              gameResult.Currency,
+		TransactionSource:     gameResult.TransactionSource,
+		ReversesTransactionID: gameResult.ReversesTransactionID,
+		Reason:                gameResult.Reason,
+		RoundID:               gameResult.RoundID,
+		BonusAmount:           gameResult.BonusAmount,
+		Balance:               gameResult.BalanceAfter,
+		BonusBalance:          gameResult.BonusBalanceAfter,
+		CreatedAt:             gameResult.CreatedAt,
+	}
+}
+
 // Transform entity.User to server.UserResponse
 func transformUserResponse(user entity.User) UserResponse {
 	return UserResponse{
-		UserID: user.ID,
+		UserID:              user.ID,
+		Balance:             user.Balance,
+		BonusBalance:        user.BonusBalance,
+		WageringRequirement: user.WageringRequirement,
+		AvailableBalance:    user.AvailableBalance(),
+		Currency:            entity.DefaultCurrency,
+		Status:              user.Status,
+		CreatedAt:           user.CreatedAt,
+	}
+}
 
-		// Transform the balance to a string, rounded to 2 decimal places
-		Balance: strconv.FormatFloat(user.Balance, 'f', 2, 64),
+// Transform the entity.Wallet list to server.BalancesResponse
+func transformBalancesResponse(userID int, legacyCurrency entity.Currency, wallets []entity.Wallet) BalancesResponse {
+	response := BalancesResponse{
+		UserID:   userID,
+		Balances: make([]WalletResponse, 0, len(wallets)),
+	}
+	for _, wallet := range wallets {
+		if wallet.Currency == legacyCurrency {
+			response.Balance = wallet.Balance
+		}
+		response.Balances = append(response.Balances, WalletResponse{
+			Currency:            wallet.Currency,
+			Balance:             wallet.Balance,
+			BonusBalance:        wallet.BonusBalance,
+			WageringRequirement: wallet.WageringRequirement,
+			AvailableBalance:    wallet.AvailableBalance(),
+		})
+	}
+	return response
+}
+
+// Transform []entity.HistoricalBalance to server.HistoricalBalancesResponse
+func transformHistoricalBalancesResponse(userID int, at time.Time, balances []entity.HistoricalBalance) HistoricalBalancesResponse {
+	response := HistoricalBalancesResponse{
+		UserID:   userID,
+		At:       at,
+		Balances: make([]HistoricalBalanceResponse, 0, len(balances)),
+	}
+	for _, balance := range balances {
+		response.Balances = append(response.Balances, HistoricalBalanceResponse{
+			Currency:     balance.Currency,
+			Balance:      balance.Balance,
+			BonusBalance: balance.BonusBalance,
+		})
+	}
+	return response
+}
+
+// Transform entity.Hold to server.HoldResponse
+func transformHoldResponse(hold entity.Hold) HoldResponse {
+	return HoldResponse{
+		HoldID:            hold.ID,
+		UserID:            hold.UserID,
+		Amount:            hold.Amount,
+		Currency:          hold.Currency,
+		Status:            hold.Status,
+		TransactionSource: hold.TransactionSource,
+		TransactionID:     hold.TransactionID,
+		ExpiresAt:         hold.ExpiresAt,
+		CreatedAt:         hold.CreatedAt,
+	}
+}
+
+// Transform entity.Round to server.RoundResponse
+func transformRoundResponse(round entity.Round) RoundResponse {
+	response := RoundResponse{
+		RoundID:      round.ID,
+		UserID:       round.UserID,
+		Status:       round.Status,
+		Currency:     round.Currency,
+		Stake:        round.Stake,
+		Payout:       round.Payout,
+		Net:          round.Net(),
+		StartedAt:    round.StartedAt,
+		Transactions: []TransactionResponse{},
+	}
+	for _, gameResult := range round.GameResults {
+		response.Transactions = append(response.Transactions, transformTransactionResponse(gameResult))
 	}
+	return response
 }
@@ -2,13 +2,13 @@ package server
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
 	"testing"
 	"time"
 
@@ -27,7 +27,8 @@ func TestHealthHandlerOnSuccess(t *testing.T) {
 	// Create a ResponseRecorder (which satisfies http.ResponseWriter) to record the response.
 	rr := httptest.NewRecorder()
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		server := Server{}
+		server := NewServer()
+		server.WithVersion("v1.2.3", "abc1234")
 		server.HealthHandler(w, r)
 	})
 
@@ -41,69 +42,184 @@ func TestHealthHandlerOnSuccess(t *testing.T) {
 	err = json.Unmarshal(body, &actual)
 	require.NoError(t, err)
 
-	expected := HealthResponse{Status: "ok", Version: "v1"}
+	expected := HealthResponse{Status: "ok", Version: "v1.2.3", GitSha: "abc1234"}
 	assert.Equal(t, expected, actual)
 }
 
-// TestGameResultFuncSuccess tests the CreateGameResultFunc for a successful response using a real server.
+// TestReadyHandlerOnSuccess tests the ReadyHandler when the database is reachable and migrated.
+func TestReadyHandlerOnSuccess(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("Ping", mock.Anything).Return(nil)
+	databaseMock.On("MigrationStatus", mock.Anything).Return(&entity.MigrationStatus{Version: 9}, nil)
+	databaseMock.On("Stats").Return(sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2})
+
+	server := NewServer()
+	server.WithVersion("v1.2.3", "abc1234")
+	server.WithHealthChecker(databaseMock)
+
+	req, err := http.NewRequest(http.MethodGet, "/ready", nil)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	server.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var actual ReadyResponse
+	err = json.Unmarshal(rr.Body.Bytes(), &actual)
+	require.NoError(t, err)
+
+	expected := ReadyResponse{
+		Status:  "ready",
+		Version: "v1.2.3",
+		GitSha:  "abc1234",
+		Database: DatabaseReadiness{
+			Reachable:        true,
+			MigrationVersion: 9,
+			Pool:             ConnectionPoolResponse{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2},
+		},
+	}
+	assert.Equal(t, expected, actual)
+	databaseMock.AssertExpectations(t)
+}
+
+// TestReadyHandlerOnErrors tests the ReadyHandler when the service is not ready.
+func TestReadyHandlerOnErrors(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mockSetup func(databaseMock *test_helpers.DatabaseMock)
+	}{
+		{
+			name: "Database Unreachable",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("Ping", mock.Anything).Return(errors.New("connection refused"))
+			},
+		},
+		{
+			name: "Migration Status Error",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("Ping", mock.Anything).Return(nil)
+				databaseMock.On("MigrationStatus", mock.Anything).Return(nil, errors.New("relation does not exist"))
+			},
+		},
+		{
+			name: "Not Migrated",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("Ping", mock.Anything).Return(nil)
+				databaseMock.On("MigrationStatus", mock.Anything).Return(nil, nil)
+			},
+		},
+		{
+			name: "Dirty Migration",
+			mockSetup: func(databaseMock *test_helpers.DatabaseMock) {
+				databaseMock.On("Ping", mock.Anything).Return(nil)
+				databaseMock.On("MigrationStatus", mock.Anything).Return(&entity.MigrationStatus{Version: 9, Dirty: true}, nil)
+			},
+		},
+		{
+			name: "No Health Checker",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer()
+
+			databaseMock := test_helpers.NewDatabaseMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(databaseMock)
+				databaseMock.On("Stats").Return(sql.DBStats{})
+				server.WithHealthChecker(databaseMock)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/ready", nil)
+			require.NoError(t, err)
+			rr := httptest.NewRecorder()
+			server.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+			var actual ReadyResponse
+			err = json.Unmarshal(rr.Body.Bytes(), &actual)
+			require.NoError(t, err)
+
+			assert.Equal(t, "unavailable", actual.Status)
+			assert.NotEmpty(t, actual.Errors)
+			databaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGameResultFuncSuccess tests the CreateGameResultFunc for a successful response.
 func TestGameResultFuncOnSuccess(t *testing.T) {
 	daoMock := test_helpers.NewDAOMock()
 
 	// Set up mock expectations
 	testGameResult := &entity.GameResult{
-		ID:            1,
-		UserID:        1,
-		GameStatus:    "win",
-		Amount:        100,
-		TransactionID: "123",
-		CreatedAt:     time.Now(),
+		ID:                1,
+		UserID:            1,
+		GameStatus:        "win",
+		TransactionSource: entity.TransactionSourceGame,
+		Amount:            entity.Money(10000),
+		Currency:          entity.DefaultCurrency,
+		TransactionID:     "123",
+		BalanceAfter:      entity.Money(25050),
+		CreatedAt:         time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC),
 	}
 	daoMock.On("CreateGameResult",
 		mock.Anything,
 		mock.Anything,
 		mock.Anything,
 		mock.Anything,
+		entity.DefaultCurrency,
+		mock.Anything,
 		mock.Anything,
 		mock.Anything,
 	).Return(testGameResult, nil)
 
 	// Create the server and set the mock manager
 	server := NewServer()
-
 	server.WithAccountManager(daoMock)
-	port, err := getAvailablePort(8000)
-	assert.NoError(t, err)
-	server.WithListenAddress(port)
 
-	go func() {
-		err := server.Run()
-		assert.NoError(t, err, "server failed to run")
-	}()
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
 
 	// Create the request body
 	reqBody := CreateGameResultRequest{
 		GameStatus:    "win",
-		Amount:        "100",
+		Amount:        entity.Money(10000),
 		TransactionID: "123",
 	}
 	body, _ := json.Marshal(reqBody)
 
 	// Create the request
-	url := fmt.Sprintf("http://localhost:%d/user/%d/transaction", port, testGameResult.UserID)
+	url := fmt.Sprintf("%s/user/%d/transaction", testServer.URL, testGameResult.UserID)
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	require.NoError(t, err)
 	req.Header.Set("source-type", string(entity.TransactionSourceGame))
 
-	// Use httptest to create a server
-	testServer := httptest.NewServer(server.router())
-	defer testServer.Close()
-
 	// Execute the request
 	resp, err := http.DefaultClient.Do(req)
-	assert.NoError(t, err, "request to server failed")
+	require.NoError(t, err, "request to server failed")
 	defer resp.Body.Close()
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual TransactionResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	expected := TransactionResponse{
+		ID:                testGameResult.ID,
+		TransactionID:     testGameResult.TransactionID,
+		GameStatus:        testGameResult.GameStatus,
+		Amount:            testGameResult.Amount,
+		Currency:          testGameResult.Currency,
+		TransactionSource: testGameResult.TransactionSource,
+		Balance:           testGameResult.BalanceAfter,
+		CreatedAt:         testGameResult.CreatedAt,
+	}
+	assert.Equal(t, expected, actual)
 }
 
 func TestCreateGameResultFuncOnErrors(t *testing.T) {
@@ -129,27 +245,35 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 			name:           "Invalid User ID",
 			mockSetup:      nil, // No mock setup required for this test
 			userID:         "invalid-user-id",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
+			expectedStatus: http.StatusBadRequest,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name:           "Reserved Transaction ID",
+			mockSetup:      nil,
+			userID:         "1",
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "reversal-123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
 		{
 			name: "User Not Found",
 			mockSetup: func(daoMock *test_helpers.DAOMock) {
-				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
 			},
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
 			expectedStatus: http.StatusNotFound,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
 		{
 			name: "Invalid Game Status",
 			mockSetup: func(daoMock *test_helpers.DAOMock) {
-				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrInvalidGameStatus)
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrInvalidGameStatus)
 			},
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "invalid-status", Amount: "100", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "invalid-status", Amount: entity.Money(10000), TransactionID: "123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
@@ -157,7 +281,7 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 			name:           "Invalid Transaction Source",
 			mockSetup:      nil, // No mock setup required for this test
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     "invalid-source",
 		},
@@ -165,35 +289,131 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 			name:           "Invalid Amount Format",
 			mockSetup:      nil, // No mock setup required for this test
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "1b.c23", TransactionID: "123"},
+			requestBody:    map[string]string{"state": "win", "amount": "1b.c23", "transactionId": "123"},
+			expectedStatus: http.StatusBadRequest,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name:           "Too Many Amount Decimals",
+			mockSetup:      nil, // No mock setup required for this test
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "10.123", "transactionId": "123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
 		{
 			name: "Transaction ID Exists",
 			mockSetup: func(daoMock *test_helpers.DAOMock) {
-				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrTransactionIdExists)
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrTransactionIdExists)
 			},
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"},
-			expectedStatus: http.StatusNotAcceptable,
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
+			expectedStatus: http.StatusConflict,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
 		{
 			name: "User Negative Balance",
 			mockSetup: func(daoMock *test_helpers.DAOMock) {
-				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserNegativeBalance)
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserNegativeBalance)
+			},
+			userID:         "1",
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
+			expectedStatus: http.StatusNotAcceptable,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "Balance Too High",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrBalanceTooHigh)
 			},
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
 			expectedStatus: http.StatusNotAcceptable,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
+		{
+			name: "User Frozen",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserFrozen)
+			},
+			userID:         "1",
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
+			expectedStatus: http.StatusLocked,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "User Closed",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserClosed)
+			},
+			userID:         "1",
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
+			expectedStatus: http.StatusGone,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "Invalid Round ID",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrInvalidRoundID)
+			},
+			userID:         "1",
+			requestBody:    map[string]string{"state": "lose", "amount": "10.00", "transactionId": "123", "roundId": ""},
+			expectedStatus: http.StatusBadRequest,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "Round Not Found",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrRoundNotFound)
+			},
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "10.00", "transactionId": "123", "roundId": "round-1"},
+			expectedStatus: http.StatusNotFound,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "Round Not Open",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrRoundNotOpen)
+			},
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "10.00", "transactionId": "123", "roundId": "round-1"},
+			expectedStatus: http.StatusConflict,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name:           "Unknown Currency",
+			mockSetup:      nil,
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "10.00", "currency": "XYZ", "transactionId": "123"},
+			expectedStatus: http.StatusBadRequest,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "Amount Beyond Currency Precision",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, entity.Currency("JPY"), mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrInvalidAmount)
+			},
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "10.50", "currency": "jpy", "transactionId": "123"},
+			expectedStatus: http.StatusBadRequest,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name: "Round Currency Mismatch",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything, entity.Currency("USD"), mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrCurrencyMismatch)
+			},
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "10.00", "currency": "USD", "transactionId": "123", "roundId": "round-1"},
+			expectedStatus: http.StatusConflict,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
 		{
 			name:           "Empty Transaction ID",
 			mockSetup:      nil,
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: ""},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: ""},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
@@ -201,7 +421,7 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 			name:           "Zero Amount",
 			mockSetup:      nil,
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "0.00", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(0), TransactionID: "123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
@@ -209,7 +429,15 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 			name:           "Negative Amount",
 			mockSetup:      nil,
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "-100.00", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(-10000), TransactionID: "123"},
+			expectedStatus: http.StatusBadRequest,
+			sourceType:     string(entity.TransactionSourceGame),
+		},
+		{
+			name:           "Amount Over Max",
+			mockSetup:      nil,
+			userID:         "1",
+			requestBody:    map[string]string{"state": "win", "amount": "100000000.00", "transactionId": "123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     string(entity.TransactionSourceGame),
 		},
@@ -217,7 +445,7 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 			name:           "Missing Source-Type Header",
 			mockSetup:      nil,
 			userID:         "1",
-			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: "100", TransactionID: "123"},
+			requestBody:    CreateGameResultRequest{GameStatus: "win", Amount: entity.Money(10000), TransactionID: "123"},
 			expectedStatus: http.StatusBadRequest,
 			sourceType:     "", // Empty source type to test missing header
 		},
@@ -232,29 +460,21 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 
 			server := NewServer()
 			server.WithAccountManager(daoMock)
-			port, err := getAvailablePort(8000)
-			assert.NoError(t, err)
-			server.WithListenAddress(port)
 
-			go func() {
-				err := server.Run()
-				require.NoError(t, err)
-			}()
+			// Use httptest for server mocking
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
 
 			// Prepare request
 			body, err := json.Marshal(tc.requestBody)
 			if err != nil {
 				body = []byte(tc.requestBody.(string)) // handle non-JSON cases
 			}
-			url := fmt.Sprintf("http://localhost:%d/user/%s/transaction", port, tc.userID)
+			url := fmt.Sprintf("%s/user/%s/transaction", testServer.URL, tc.userID)
 			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 			require.NoError(t, err)
 			req.Header.Set("source-type", tc.sourceType)
 
-			// Use httptest for server mocking
-			testServer := httptest.NewServer(server.router())
-			defer testServer.Close()
-
 			// Execute request and validate response
 			resp, err := http.DefaultClient.Do(req)
 			require.NoError(t, err)
@@ -265,60 +485,162 @@ func TestCreateGameResultFuncOnErrors(t *testing.T) {
 	}
 }
 
-// TestRetrieveUserFuncOnSuccess tests the RetrieveUserFunc for a successful response using a real server.
-func TestRetrieveUserFuncOnSuccess(t *testing.T) {
+// TestRetrieveBalancesFuncOnSuccess tests the RetrieveBalancesFunc for a successful response.
+func TestRetrieveBalancesFuncOnSuccess(t *testing.T) {
 	daoMock := test_helpers.NewDAOMock()
 
 	// Set up mock expectations
-	testUser := &entity.User{
-		ID:      1,
-		Balance: 100,
+	testWallets := []entity.Wallet{
+		{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(10000), BonusBalance: entity.Money(2000), WageringRequirement: entity.Money(10000), HeldBalance: entity.Money(2500)},
+		{UserID: 1, Currency: "JPY", Balance: entity.Money(150000)},
 	}
-
-	daoMock.On("RetrieveUser", mock.Anything, mock.Anything).Return(testUser, nil)
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return(testWallets, nil)
+	daoMock.On("RetrieveWallet", mock.Anything, 1, entity.Currency("JPY")).Return(&testWallets[1], nil)
 
 	// Create the server and set the mock manager
 	server := NewServer()
 	server.WithAccountManager(daoMock)
-	port, err := getAvailablePort(8000)
-	assert.NoError(t, err)
-	server.WithListenAddress(port)
 
-	go func() {
-		err := server.Run()
-		assert.NoError(t, err, "server failed to run")
-	}()
+	// Use httptest to create a server
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// All the balances
+	resp, err := http.Get(fmt.Sprintf("%s/user/1/balance", testServer.URL))
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
 
-	// Create the request
-	url := fmt.Sprintf("http://localhost:%d/user/%d/balance", port, testUser.ID)
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual BalancesResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	expected := BalancesResponse{
+		UserID:  1,
+		Balance: entity.Money(10000),
+		Balances: []WalletResponse{
+			{Currency: entity.DefaultCurrency, Balance: entity.Money(10000), BonusBalance: entity.Money(2000), WageringRequirement: entity.Money(10000), AvailableBalance: entity.Money(9500)},
+			{Currency: "JPY", Balance: entity.Money(150000), AvailableBalance: entity.Money(150000)},
+		},
+	}
+	assert.Equal(t, expected, actual)
+
+	// The balance in a single currency, the code is case-insensitive
+	resp, err = http.Get(fmt.Sprintf("%s/user/1/balance?currency=jpy", testServer.URL))
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	actual = BalancesResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&actual)
 	require.NoError(t, err)
 
+	expected.Balance = entity.Money(150000)
+	expected.Balances = expected.Balances[1:]
+	assert.Equal(t, expected, actual)
+	daoMock.AssertExpectations(t)
+}
+
+// TestRetrieveBalancesFuncLegacyBalance tests that the former single balance field stays in the default currency.
+func TestRetrieveBalancesFuncLegacyBalance(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return([]entity.Wallet{{UserID: 1, Currency: "JPY", Balance: entity.Money(150000)}}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp, err := http.Get(fmt.Sprintf("%s/user/1/balance", testServer.URL))
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	var actual map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+
+	// No wallet in the default currency yet
+	assert.Equal(t, "0.00", actual["balance"])
+}
+
+// TestRetrieveBalancesFuncAtOnSuccess tests the RetrieveBalancesFunc for the balances as of a past time.
+func TestRetrieveBalancesFuncAtOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	at := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	testBalances := []entity.HistoricalBalance{
+		{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(10000), BonusBalance: entity.Money(2000), At: at},
+		{UserID: 1, Currency: "JPY", Balance: entity.Money(150000), At: at},
+	}
+	daoMock.On("RetrieveBalancesAt", mock.Anything, 1, at).Return(testBalances, nil)
+
+	// Create the server and set the mock manager
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
 	// Use httptest to create a server
 	testServer := httptest.NewServer(server.router())
 	defer testServer.Close()
 
-	// Execute the request
-	resp, err := http.DefaultClient.Do(req)
-	assert.NoError(t, err, "request to server failed")
+	// All the balances at the end of the day
+	resp, err := http.Get(fmt.Sprintf("%s/user/1/balance?at=2024-05-01T00:00:00Z", testServer.URL))
+	require.NoError(t, err, "request to server failed")
 	defer resp.Body.Close()
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 
-	var actual UserResponse
+	var actual HistoricalBalancesResponse
 	err = json.NewDecoder(resp.Body).Decode(&actual)
 	require.NoError(t, err)
 
-	expectedBalance := strconv.FormatFloat(testUser.Balance, 'f', 2, 64)
-	assert.Equal(t, expectedBalance, actual.Balance)
-	assert.Equal(t, testUser.ID, actual.UserID)
+	expected := HistoricalBalancesResponse{
+		UserID: 1,
+		At:     at,
+		Balances: []HistoricalBalanceResponse{
+			{Currency: entity.DefaultCurrency, Balance: entity.Money(10000), BonusBalance: entity.Money(2000)},
+			{Currency: "JPY", Balance: entity.Money(150000)},
+		},
+	}
+	assert.Equal(t, expected, actual)
+
+	// The balance in a single currency
+	resp, err = http.Get(fmt.Sprintf("%s/user/1/balance?at=2024-05-01T00:00:00Z&currency=jpy", testServer.URL))
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	actual = HistoricalBalancesResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	expected.Balances = expected.Balances[1:]
+	assert.Equal(t, expected, actual)
+
+	// A currency the user had not transacted in by then has a zero balance
+	resp, err = http.Get(fmt.Sprintf("%s/user/1/balance?at=2024-05-01T00:00:00Z&currency=GBP", testServer.URL))
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	actual = HistoricalBalancesResponse{}
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	expected.Balances = []HistoricalBalanceResponse{{Currency: "GBP"}}
+	assert.Equal(t, expected, actual)
+	daoMock.AssertExpectations(t)
 }
 
-func TestRetrieveUserFuncOnErrors(t *testing.T) {
+func TestRetrieveBalancesFuncOnErrors(t *testing.T) {
 	type testCase struct {
 		name           string
 		mockSetup      func(daoMock *test_helpers.DAOMock)
-		userID         string
+		path           string
 		expectedStatus int
 	}
 
@@ -326,35 +648,79 @@ func TestRetrieveUserFuncOnErrors(t *testing.T) {
 		{
 			name:           "Invalid User ID",
 			mockSetup:      nil, // No mock setup required for this test
-			userID:         "invalid-user-id",
+			path:           "/user/invalid-user-id/balance",
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name: "User Not Found",
 			mockSetup: func(daoMock *test_helpers.DAOMock) {
-				daoMock.On("RetrieveUser", mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
+				daoMock.On("RetrieveWallets", mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
 			},
-			userID:         "1",
+			path:           "/user/1/balance",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "User Not Found In Currency",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveWallet", mock.Anything, mock.Anything, entity.Currency("USD")).Return(nil, entity.ErrUserNotFound)
+			},
+			path:           "/user/1/balance?currency=USD",
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:           "Unknown Currency",
+			mockSetup:      nil,
+			path:           "/user/1/balance?currency=XYZ",
+			expectedStatus: http.StatusBadRequest,
+		},
 		{
 			name: "Internal error",
 			mockSetup: func(daoMock *test_helpers.DAOMock) {
-				daoMock.On("RetrieveUser", mock.Anything, mock.Anything).Return(nil, errors.New("server error"))
+				daoMock.On("RetrieveWallets", mock.Anything, mock.Anything).Return(nil, errors.New("server error"))
 			},
-			userID:         "1",
+			path:           "/user/1/balance",
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name:           "Invalid Timestamp",
+			mockSetup:      nil,
+			path:           "/user/1/balance?at=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Future Timestamp",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveBalancesAt", mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrFutureTimestamp)
+			},
+			path:           "/user/1/balance?at=2999-01-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "User Not Found At Timestamp",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveBalancesAt", mock.Anything, mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
+			},
+			path:           "/user/1/balance?at=2024-05-01T00:00:00Z",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Internal error At Timestamp",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveBalancesAt", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("server error"))
+			},
+			path:           "/user/1/balance?at=2024-05-01T00:00:00Z",
 			expectedStatus: http.StatusInternalServerError,
 		},
 		{
 			name:           "Zero User ID",
 			mockSetup:      nil,
-			userID:         "0",
+			path:           "/user/0/balance",
 			expectedStatus: http.StatusBadRequest,
 		},
 		{
 			name:           "Negative User ID",
 			mockSetup:      nil,
-			userID:         "-1",
+			path:           "/user/-1/balance",
 			expectedStatus: http.StatusBadRequest,
 		},
 	}
@@ -368,30 +734,1164 @@ func TestRetrieveUserFuncOnErrors(t *testing.T) {
 
 			server := NewServer()
 			server.WithAccountManager(daoMock)
-			port, err := getAvailablePort(8000)
-			assert.NoError(t, err)
-			server.WithListenAddress(port)
-
-			go func() {
-				err := server.Run()
-				require.NoError(t, err)
-			}()
-
-			// Prepare request
-			url := fmt.Sprintf("http://localhost:%d/user/%s/balance", port, tc.userID)
-			req, err := http.NewRequest(http.MethodGet, url, nil)
-			require.NoError(t, err)
 
 			// Use httptest for server mocking
 			testServer := httptest.NewServer(server.router())
 			defer testServer.Close()
 
 			// Execute request and validate response
+			resp, err := http.Get(testServer.URL + tc.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+		})
+	}
+}
+
+// TestListGameResultsFuncOnSuccess tests the ListGameResultsFunc for a successful response.
+func TestListGameResultsFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	createdAt := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+	testPage := &entity.GameResultPage{
+		GameResults: []entity.GameResult{
+			{
+				ID:                2,
+				UserID:            1,
+				GameStatus:        entity.GameStatusLose,
+				TransactionSource: entity.TransactionSourceGame,
+				TransactionID:     "456",
+				Amount:            entity.Money(1050),
+				BalanceAfter:      entity.Money(2000),
+				CreatedAt:         createdAt,
+			},
+		},
+		NextCursor: &entity.GameResultCursor{CreatedAt: createdAt, ID: 2},
+	}
+
+	source := entity.TransactionSourceGame
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	expectedFilter := entity.GameResultFilter{
+		UserID:            1,
+		TransactionSource: &source,
+		CreatedFrom:       &from,
+		Limit:             1,
+	}
+	daoMock.On("ListGameResults", mock.Anything, expectedFilter).Return(testPage, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	url := fmt.Sprintf("%s/user/1/transactions?limit=1&source=game&from=2024-05-01T00:00:00Z", testServer.URL)
+	resp, err := http.Get(url)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual GameResultsResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	expected := GameResultsResponse{
+		UserID: 1,
+		Transactions: []TransactionResponse{
+			{
+				ID:                2,
+				TransactionID:     "456",
+				GameStatus:        entity.GameStatusLose,
+				Amount:            entity.Money(1050),
+				TransactionSource: entity.TransactionSourceGame,
+				Balance:           entity.Money(2000),
+				CreatedAt:         createdAt,
+			},
+		},
+		NextCursor: testPage.NextCursor.Encode(),
+	}
+	assert.Equal(t, expected, actual)
+	daoMock.AssertExpectations(t)
+}
+
+func TestListGameResultsFuncOnErrors(t *testing.T) {
+	type testCase struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		path           string
+		expectedStatus int
+	}
+
+	testCases := []testCase{
+		{
+			name:           "Invalid User ID",
+			path:           "/user/invalid-user-id/transactions",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Limit",
+			path:           "/user/1/transactions?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Cursor",
+			path:           "/user/1/transactions?cursor=***",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid State",
+			path:           "/user/1/transactions?state=draw",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Source",
+			path:           "/user/1/transactions?source=casino",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Time Format",
+			path:           "/user/1/transactions?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Inverted Time Window",
+			path:           "/user/1/transactions?from=2024-05-02T00:00:00Z&to=2024-05-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "User Not Found",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("ListGameResults", mock.Anything, mock.Anything).Return(nil, entity.ErrUserNotFound)
+			},
+			path:           "/user/1/transactions",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Internal error",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("ListGameResults", mock.Anything, mock.Anything).Return(nil, errors.New("server error"))
+			},
+			path:           "/user/1/transactions",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			// Execute request and validate response
+			resp, err := http.Get(testServer.URL + tc.path)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+// TestReverseGameResultFuncOnSuccess tests the ReverseGameResultFunc for a successful response.
+func TestReverseGameResultFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	reversed := "123"
+	testReversal := &entity.GameResult{
+		ID:                    2,
+		UserID:                1,
+		GameStatus:            entity.GameStatusLose,
+		TransactionSource:     entity.TransactionSourceGame,
+		TransactionID:         entity.ReversalTransactionID(reversed),
+		Amount:                entity.Money(10000),
+		ReversesTransactionID: &reversed,
+		CreatedAt:             time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC),
+	}
+	daoMock.On("ReverseGameResult", mock.Anything, 1, reversed, entity.TransactionSourceGame).Return(testReversal, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction/123/reverse", nil)
+	require.NoError(t, err)
+	req.Header.Set("Source-Type", string(entity.TransactionSourceGame))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual TransactionResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, testReversal.TransactionID, actual.TransactionID)
+	assert.Equal(t, entity.GameStatusLose, actual.GameStatus)
+	assert.Equal(t, &reversed, actual.ReversesTransactionID)
+	daoMock.AssertExpectations(t)
+}
+
+func TestReverseGameResultFuncOnErrors(t *testing.T) {
+	type testCase struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		userID         string
+		sourceType     string
+		expectedStatus int
+	}
+
+	reverseReturning := func(err error) func(daoMock *test_helpers.DAOMock) {
+		return func(daoMock *test_helpers.DAOMock) {
+			daoMock.On("ReverseGameResult", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, err)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:           "Invalid User ID",
+			userID:         "invalid-user-id",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing Source-Type Header",
+			userID:         "1",
+			sourceType:     "",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "User Not Found",
+			mockSetup:      reverseReturning(entity.ErrUserNotFound),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Transaction Not Found",
+			mockSetup:      reverseReturning(entity.ErrTransactionNotFound),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Already Reversed",
+			mockSetup:      reverseReturning(entity.ErrTransactionAlreadyReversed),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Not Reversible",
+			mockSetup:      reverseReturning(entity.ErrTransactionNotReversible),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Transaction Of Another Source",
+			mockSetup:      reverseReturning(entity.ErrReversalSourceMismatch),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "User Negative Balance",
+			mockSetup:      reverseReturning(entity.ErrUserNegativeBalance),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "User Frozen",
+			mockSetup:      reverseReturning(entity.ErrUserFrozen),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusLocked,
+		},
+		{
+			name:           "User Closed",
+			mockSetup:      reverseReturning(entity.ErrUserClosed),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusGone,
+		},
+		{
+			name:           "Internal error",
+			mockSetup:      reverseReturning(entity.ErrCreatingGameResult),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			url := fmt.Sprintf("%s/user/%s/transaction/123/reverse", testServer.URL, tc.userID)
+			req, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			req.Header.Set("Source-Type", tc.sourceType)
+
+			// Execute request and validate response
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateUserFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	testUser := &entity.User{
+		ID:        10,
+		Balance:   entity.Money(0),
+		Status:    entity.UserStatusActive,
+		CreatedAt: createdAt,
+	}
+	daoMock.On("CreateUser", mock.Anything).Return(testUser, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp, err := http.Post(testServer.URL+"/user", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var actual UserResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	expected := UserResponse{
+		UserID:    10,
+		Balance:   entity.Money(0),
+		Currency:  entity.DefaultCurrency,
+		Status:    entity.UserStatusActive,
+		CreatedAt: createdAt,
+	}
+	assert.Equal(t, expected, actual)
+	daoMock.AssertExpectations(t)
+}
+
+func TestCreateUserFuncOnError(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("CreateUser", mock.Anything).Return(nil, errors.New("database error"))
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp, err := http.Post(testServer.URL+"/user", "application/json", nil)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	daoMock.AssertExpectations(t)
+}
+
+func TestRetrieveUserFuncOnUserPath(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	testUser := &entity.User{
+		ID:          1,
+		Balance:     entity.Money(10000),
+		HeldBalance: entity.Money(2500),
+		Status:      entity.UserStatusFrozen,
+	}
+	daoMock.On("RetrieveUser", mock.Anything, 1).Return(testUser, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	resp, err := http.Get(testServer.URL + "/user/1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual UserResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, testUser.ID, actual.UserID)
+	assert.Equal(t, testUser.Balance, actual.Balance)
+	assert.Equal(t, entity.Money(7500), actual.AvailableBalance)
+	assert.Equal(t, entity.DefaultCurrency, actual.Currency)
+	assert.Equal(t, entity.UserStatusFrozen, actual.Status)
+}
+
+func TestUpdateUserStatusFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	testUser := &entity.User{
+		ID:      1,
+		Balance: entity.Money(10000),
+		Status:  entity.UserStatusFrozen,
+	}
+	daoMock.On("UpdateUserStatus", mock.Anything, 1, entity.UserStatusFrozen).Return(testUser, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	body, err := json.Marshal(UpdateUserStatusRequest{Status: entity.UserStatusFrozen})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPut, testServer.URL+"/user/1/status", bytes.NewReader(body))
+	require.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual UserResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.UserStatusFrozen, actual.Status)
+	daoMock.AssertExpectations(t)
+}
+
+func TestUpdateUserStatusFuncOnErrors(t *testing.T) {
+	type testCase struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		userID         string
+		requestBody    string
+		expectedStatus int
+	}
+
+	updateReturning := func(err error) func(daoMock *test_helpers.DAOMock) {
+		return func(daoMock *test_helpers.DAOMock) {
+			daoMock.On("UpdateUserStatus", mock.Anything, mock.Anything, mock.Anything).Return(nil, err)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:           "Invalid User ID",
+			userID:         "invalid-user-id",
+			requestBody:    `{"status":"frozen"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Request Body",
+			userID:         "1",
+			requestBody:    "not a json",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Status",
+			userID:         "1",
+			requestBody:    `{"status":"deleted"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "User Not Found",
+			mockSetup:      updateReturning(entity.ErrUserNotFound),
+			userID:         "1",
+			requestBody:    `{"status":"frozen"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid Transition",
+			mockSetup:      updateReturning(entity.ErrInvalidUserStatusTransition),
+			userID:         "1",
+			requestBody:    `{"status":"active"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Internal error",
+			mockSetup:      updateReturning(errors.New("database error")),
+			userID:         "1",
+			requestBody:    `{"status":"closed"}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			url := fmt.Sprintf("%s/user/%s/status", testServer.URL, tc.userID)
+			req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader([]byte(tc.requestBody)))
+			require.NoError(t, err)
+
+			// Execute request and validate response
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateHoldFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	createdAt := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+	testHold := &entity.Hold{
+		ID:                7,
+		UserID:            1,
+		Amount:            entity.Money(2500),
+		Currency:          "USD",
+		Status:            entity.HoldStatusOpen,
+		TransactionSource: entity.TransactionSourceGame,
+		ExpiresAt:         createdAt.Add(time.Minute),
+		CreatedAt:         createdAt,
+	}
+	daoMock.On("CreateHold", mock.Anything, 1, entity.Money(2500), entity.Currency("USD"), entity.TransactionSourceGame, time.Minute).Return(testHold, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body := `{"amount": "25.00", "currency": "usd", "expiresInSeconds": 60}`
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/holds", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Source-Type", string(entity.TransactionSourceGame))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var actual HoldResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, testHold.ID, actual.HoldID)
+	assert.Equal(t, testHold.Amount, actual.Amount)
+	assert.Equal(t, testHold.Currency, actual.Currency)
+	assert.Equal(t, entity.HoldStatusOpen, actual.Status)
+	assert.Equal(t, testHold.ExpiresAt, actual.ExpiresAt)
+	assert.Nil(t, actual.TransactionID)
+	daoMock.AssertExpectations(t)
+}
+
+func TestCreateHoldFuncOnErrors(t *testing.T) {
+	type testCase struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		userID         string
+		sourceType     string
+		body           string
+		expectedStatus int
+	}
+
+	createReturning := func(err error) func(daoMock *test_helpers.DAOMock) {
+		return func(daoMock *test_helpers.DAOMock) {
+			daoMock.On("CreateHold", mock.Anything, 1, entity.Money(2500), entity.DefaultCurrency, entity.TransactionSourceGame, entity.DefaultHoldExpiry).Return(nil, err)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:           "Missing Source-Type Header",
+			userID:         "1",
+			body:           `{"amount": "25.00"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Amount",
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "-25.00"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Amount Too High",
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "100000000.00"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Expiry",
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00", "expiresInSeconds": 0}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Expiry Too Long",
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00", "expiresInSeconds": 86401}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Unknown Currency",
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00", "currency": "XYZ"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Amount Beyond Currency Precision",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("CreateHold", mock.Anything, 1, entity.Money(2550), entity.Currency("JPY"), entity.TransactionSourceGame, entity.DefaultHoldExpiry).Return(nil, entity.ErrInvalidAmount)
+			},
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.50", "currency": "JPY"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid User ID",
+			userID:         "invalid-user-id",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "User Not Found",
+			mockSetup:      createReturning(entity.ErrUserNotFound),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Insufficient Available Balance",
+			mockSetup:      createReturning(entity.ErrUserNegativeBalance),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00"}`,
+			expectedStatus: http.StatusNotAcceptable,
+		},
+		{
+			name:           "User Frozen",
+			mockSetup:      createReturning(entity.ErrUserFrozen),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00"}`,
+			expectedStatus: http.StatusLocked,
+		},
+		{
+			name:           "Internal error",
+			mockSetup:      createReturning(entity.ErrCreatingHold),
+			userID:         "1",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"amount": "25.00"}`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			url := fmt.Sprintf("%s/user/%s/holds", testServer.URL, tc.userID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Source-Type", tc.sourceType)
+
+			// Execute request and validate response
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRetrieveHoldFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	testHold := &entity.Hold{
+		ID:                7,
+		UserID:            1,
+		Amount:            entity.Money(2500),
+		Status:            entity.HoldStatusExpired,
+		TransactionSource: entity.TransactionSourceGame,
+	}
+	daoMock.On("RetrieveHold", mock.Anything, 1, 7).Return(testHold, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp, err := http.Get(testServer.URL + "/user/1/holds/7")
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual HoldResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.HoldStatusExpired, actual.Status)
+	daoMock.AssertExpectations(t)
+}
+
+func TestCaptureHoldFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	testGameResult := &entity.GameResult{
+		ID:                3,
+		UserID:            1,
+		GameStatus:        entity.GameStatusLose,
+		TransactionSource: entity.TransactionSourceGame,
+		TransactionID:     "bet-123",
+		Amount:            entity.Money(2500),
+		BalanceAfter:      entity.Money(7500),
+	}
+	daoMock.On("CaptureHold", mock.Anything, 1, 7, "bet-123", entity.TransactionSourceGame).Return(testGameResult, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body := `{"transactionId": "bet-123"}`
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/holds/7/capture", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Source-Type", string(entity.TransactionSourceGame))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual TransactionResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, "bet-123", actual.TransactionID)
+	assert.Equal(t, entity.GameStatusLose, actual.GameStatus)
+	assert.Equal(t, entity.Money(7500), actual.Balance)
+	daoMock.AssertExpectations(t)
+}
+
+func TestCaptureHoldFuncOnErrors(t *testing.T) {
+	type testCase struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		holdID         string
+		sourceType     string
+		body           string
+		expectedStatus int
+	}
+
+	captureReturning := func(err error) func(daoMock *test_helpers.DAOMock) {
+		return func(daoMock *test_helpers.DAOMock) {
+			daoMock.On("CaptureHold", mock.Anything, 1, 7, "bet-123", entity.TransactionSourceGame).Return(nil, err)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:           "Missing Transaction ID",
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Reserved Transaction ID",
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bonus-123"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid Hold ID",
+			holdID:         "invalid-hold-id",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Hold Not Found",
+			mockSetup:      captureReturning(entity.ErrHoldNotFound),
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Hold Not Open",
+			mockSetup:      captureReturning(entity.ErrHoldNotOpen),
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Hold Expired",
+			mockSetup:      captureReturning(entity.ErrHoldExpired),
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Transaction ID Exists",
+			mockSetup:      captureReturning(entity.ErrTransactionIdExists),
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Hold Of Another Source",
+			mockSetup:      captureReturning(entity.ErrHoldSourceMismatch),
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "User Closed",
+			mockSetup:      captureReturning(entity.ErrUserClosed),
+			holdID:         "7",
+			sourceType:     string(entity.TransactionSourceGame),
+			body:           `{"transactionId": "bet-123"}`,
+			expectedStatus: http.StatusGone,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			url := fmt.Sprintf("%s/user/1/holds/%s/capture", testServer.URL, tc.holdID)
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Source-Type", tc.sourceType)
+
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReleaseHoldFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	testHold := &entity.Hold{
+		ID:                7,
+		UserID:            1,
+		Amount:            entity.Money(2500),
+		Status:            entity.HoldStatusReleased,
+		TransactionSource: entity.TransactionSourceGame,
+	}
+	daoMock.On("ReleaseHold", mock.Anything, 1, 7, entity.TransactionSourceGame).Return(testHold, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/holds/7/release", nil)
+	require.NoError(t, err)
+	req.Header.Set("Source-Type", string(entity.TransactionSourceGame))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual HoldResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.HoldStatusReleased, actual.Status)
+	daoMock.AssertExpectations(t)
+}
+
+func TestReleaseHoldFuncOnErrors(t *testing.T) {
+	type testCase struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		sourceType     string
+		expectedStatus int
+	}
+
+	releaseReturning := func(err error) func(daoMock *test_helpers.DAOMock) {
+		return func(daoMock *test_helpers.DAOMock) {
+			daoMock.On("ReleaseHold", mock.Anything, 1, 7, entity.TransactionSourceGame).Return(nil, err)
+		}
+	}
+
+	testCases := []testCase{
+		{
+			name:           "Missing Source-Type Header",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Hold Captured",
+			mockSetup:      releaseReturning(entity.ErrHoldNotOpen),
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Hold Of Another Source",
+			mockSetup:      releaseReturning(entity.ErrHoldSourceMismatch),
+			sourceType:     string(entity.TransactionSourceGame),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			req, err := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/holds/7/release", nil)
+			require.NoError(t, err)
+			req.Header.Set("Source-Type", tc.sourceType)
+
 			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err, "request to server failed")
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGameResultFuncInRound(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	roundID := "round-1"
+	testGameResult := &entity.GameResult{
+		ID:                1,
+		UserID:            1,
+		GameStatus:        entity.GameStatusLose,
+		TransactionSource: entity.TransactionSourceGame,
+		Amount:            entity.Money(1000),
+		TransactionID:     "stake-1",
+		RoundID:           &roundID,
+		BalanceAfter:      entity.Money(9000),
+	}
+	daoMock.On("CreateGameResult", mock.Anything, 1, entity.GameStatusLose, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "stake-1", &roundID).
+		Return(testGameResult, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	body := `{"state": "lose", "amount": "10.00", "transactionId": "stake-1", "roundId": "round-1"}`
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	req.Header.Set("Source-Type", string(entity.TransactionSourceGame))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual TransactionResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, &roundID, actual.RoundID)
+	daoMock.AssertExpectations(t)
+}
+
+func TestRetrieveRoundFuncOnSuccess(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+
+	// Set up mock expectations
+	roundID := "round-1"
+	startedAt := time.Date(2024, 5, 17, 10, 30, 0, 0, time.UTC)
+	testRound := entity.NewRound(1, roundID, []entity.GameResult{
+		{ID: 1, UserID: 1, GameStatus: entity.GameStatusLose, TransactionID: "stake-1", Amount: entity.Money(1000), RoundID: &roundID, CreatedAt: startedAt},
+		{ID: 2, UserID: 1, GameStatus: entity.GameStatusWin, TransactionID: "payout-1", Amount: entity.Money(2500), RoundID: &roundID, CreatedAt: startedAt.Add(time.Minute)},
+	})
+	daoMock.On("RetrieveRound", mock.Anything, 1, roundID).Return(&testRound, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Execute the request
+	resp, err := http.Get(testServer.URL + "/user/1/rounds/round-1")
+	require.NoError(t, err, "request to server failed")
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var actual RoundResponse
+	err = json.NewDecoder(resp.Body).Decode(&actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, roundID, actual.RoundID)
+	assert.Equal(t, entity.RoundStatusSettled, actual.Status)
+	assert.Equal(t, entity.Money(1000), actual.Stake)
+	assert.Equal(t, entity.Money(2500), actual.Payout)
+	assert.Equal(t, entity.Money(1500), actual.Net)
+	assert.Equal(t, startedAt, actual.StartedAt)
+	require.Len(t, actual.Transactions, 2)
+	assert.Equal(t, "stake-1", actual.Transactions[0].TransactionID)
+	daoMock.AssertExpectations(t)
+}
+
+func TestRetrieveRoundFuncOnErrors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		mockSetup      func(daoMock *test_helpers.DAOMock)
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "Invalid User ID",
+			path:           "/user/abc/rounds/round-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Round Not Found",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveRound", mock.Anything, 1, "round-1").Return(nil, entity.ErrRoundNotFound)
+			},
+			path:           "/user/1/rounds/round-1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Internal Error",
+			mockSetup: func(daoMock *test_helpers.DAOMock) {
+				daoMock.On("RetrieveRound", mock.Anything, 1, "round-1").Return(nil, errors.New("connection lost"))
+			},
+			path:           "/user/1/rounds/round-1",
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			daoMock := test_helpers.NewDAOMock()
+			if tc.mockSetup != nil {
+				tc.mockSetup(daoMock)
+			}
+
+			server := NewServer()
+			server.WithAccountManager(daoMock)
+
+			testServer := httptest.NewServer(server.router())
+			defer testServer.Close()
+
+			resp, err := http.Get(testServer.URL + tc.path)
 			require.NoError(t, err)
 			defer resp.Body.Close()
 
 			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			daoMock.AssertExpectations(t)
 		})
 	}
 }
@@ -1,13 +1,32 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/ildomm/account-balance-manager/database"
 	"github.com/ildomm/account-balance-manager/entity"
-	"log"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/ratelimit"
+	"github.com/ildomm/account-balance-manager/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"math"
 	"net/http"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -61,7 +80,9 @@ func (rm RecoverMiddleware) perform(next http.Handler) http.Handler {
 				}
 
 				// Log the error and stack trace
-				log.Printf("ERROR: recovering from error. Error: %s. StackTrace: %s \n", err, stackTrace)
+				slog.ErrorContext(r.Context(), "recovering from panic",
+					logging.Error(err.(error)),
+					slog.String("stack_trace", string(stackTrace)))
 
 				rm.response(r.Context(), w)
 			}
@@ -83,6 +104,31 @@ func defaultRecoveryResponse() RecoveryResponse {
 	}
 }
 
+// RequestIDMiddleware is a middleware that assigns an ID to every request.
+// A valid X-Request-ID sent by the client is honored, otherwise a new one is generated.
+// The ID is echoed back in the response header and carried by the request context, so it shows up in the logs.
+type RequestIDMiddleware struct{}
+
+// NewRequestIDMiddleware initializes a new RequestIDMiddleware
+func NewRequestIDMiddleware() func(next http.Handler) http.Handler {
+	return RequestIDMiddleware{}.perform
+}
+
+// perform is the middleware handler itself
+func (rim RequestIDMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(logging.RequestIDHeader)
+		if !logging.ValidRequestID(requestID) {
+			requestID = logging.NewRequestID()
+		}
+
+		w.Header().Set(logging.RequestIDHeader, requestID)
+
+		// Call the next handler as a normal flow execution, carrying the request ID
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), requestID)))
+	})
+}
+
 // StatusRecorder
 // Source:
 // https://upgear.io/blog/golang-tip-wrapping-http-response-writer-for-middleware/
@@ -98,6 +144,15 @@ func (r *StatusRecorder) WriteHeader(status int) {
 	}
 }
 
+// StatusCode returns the recorded status,
+// http.StatusOK when the handler wrote its response without setting one
+func (r *StatusRecorder) StatusCode() int {
+	if r.Status == 0 {
+		return http.StatusOK
+	}
+	return r.Status
+}
+
 // LoggingMiddleware is a middleware that logs the request
 type LoggingMiddleware struct{}
 
@@ -113,15 +168,330 @@ func (lm LoggingMiddleware) perform(next http.Handler) http.Handler {
 			ResponseWriter: w,
 		}
 
-		start := time.Now().UnixNano()
+		start := time.Now()
 
 		// Call the next handler as a normal flow execution
 		next.ServeHTTP(recorder, r)
 
-		end := time.Now().UnixNano()
-		duration := (end - start) / int64(time.Millisecond)
-
 		// Logs execution time of the request and other details
-		log.Printf("INFO: %s \"%s %s\" %d %dms\n", r.RemoteAddr, r.Method, r.URL.Path, recorder.Status, duration)
+		slog.InfoContext(r.Context(), "request served",
+			slog.String("remote_addr", r.RemoteAddr),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.StatusCode()),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()))
+	})
+}
+
+// MetricsMiddleware is a middleware that counts the requests and measures their latency
+type MetricsMiddleware struct {
+	metrics *metrics.Metrics
+}
+
+// NewMetricsMiddleware initializes a new MetricsMiddleware
+func NewMetricsMiddleware(metrics *metrics.Metrics) func(next http.Handler) http.Handler {
+	return MetricsMiddleware{
+		metrics: metrics,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (mm MetricsMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &StatusRecorder{
+			ResponseWriter: w,
+		}
+
+		start := time.Now()
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(recorder, r)
+
+		// Label by the route template, not the actual path, to keep the number of series bounded
+		mm.metrics.ObserveHTTPRequest(routeTemplate(r), r.Method, recorder.StatusCode(), time.Since(start))
+	})
+}
+
+// routeTemplate returns the path template of the matched route, eg: "/user/{id}/balance",
+// or the actual path when no route matched
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// TracingMiddleware is a middleware that traces the request,
+// continuing the trace of the caller when it sends a W3C traceparent header
+type TracingMiddleware struct {
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracingMiddleware initializes a new TracingMiddleware
+func NewTracingMiddleware() func(next http.Handler) http.Handler {
+	return TracingMiddleware{
+		propagator: otel.GetTextMapPropagator(),
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (tm TracingMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &StatusRecorder{
+			ResponseWriter: w,
+		}
+
+		route := routeTemplate(r)
+		ctx := tm.propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+			))
+		defer span.End()
+
+		// Call the next handler as a normal flow execution, within the span
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	})
 }
+
+// APIKeyHeader is the header carrying the API key of the caller
+const APIKeyHeader = "X-API-Key"
+
+type apiKeyContextKey struct{}
+
+// AuthenticationMiddleware is a middleware that requires a valid API key.
+// The key must be authorized for the user of the {id} path variable, when any,
+// and for the Source-Type header, when any.
+type AuthenticationMiddleware struct {
+	store database.APIKeyStore
+}
+
+// NewAuthenticationMiddleware initializes a new AuthenticationMiddleware
+func NewAuthenticationMiddleware(store database.APIKeyStore) func(next http.Handler) http.Handler {
+	return AuthenticationMiddleware{
+		store: store,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (am AuthenticationMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrMissingAPIKey.Error()})
+			return
+		}
+
+		apiKey, err := am.store.SelectAPIKeyByHash(r.Context(), entity.HashAPIKey(key))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "error locating api key", logging.Error(err))
+			WriteErrorResponse(w, http.StatusInternalServerError, []string{entity.ErrServerInternal.Error()})
+			return
+		}
+		if apiKey == nil || apiKey.Revoked() {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrInvalidAPIKey.Error()})
+			return
+		}
+
+		// Malformed user ids and sources are left to the handlers to reject
+		if userID, err := strconv.Atoi(mux.Vars(r)["id"]); err == nil && !apiKey.AllowsUser(userID) {
+			WriteErrorResponse(w, http.StatusForbidden, []string{entity.ErrUserNotAllowed.Error()})
+			return
+		}
+		if value := r.Header.Get("Source-Type"); value != "" {
+			source := entity.ParseTransactionSource(strings.ToLower(value))
+			if source != nil && !apiKey.AllowsSource(*source) {
+				WriteErrorResponse(w, http.StatusForbidden, []string{entity.ErrTransactionSourceNotAllowed.Error()})
+				return
+			}
+		}
+
+		// Call the next handler as a normal flow execution, carrying the authenticated key
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, apiKey)))
+	})
+}
+
+// AdminMiddleware is a middleware that restricts the user management routes to the admin API keys.
+// It relies on the key authenticated by the AuthenticationMiddleware.
+type AdminMiddleware struct{}
+
+// NewAdminMiddleware initializes a new AdminMiddleware
+func NewAdminMiddleware() func(next http.Handler) http.Handler {
+	return AdminMiddleware{}.perform
+}
+
+// perform is the middleware handler itself
+func (am AdminMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey := apiKeyFrom(r.Context())
+		if apiKey == nil || !apiKey.Admin {
+			WriteErrorResponse(w, http.StatusForbidden, []string{entity.ErrAdminNotAllowed.Error()})
+			return
+		}
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyFrom returns the API key authenticated by the AuthenticationMiddleware, if any
+func apiKeyFrom(ctx context.Context) *entity.APIKey {
+	apiKey, _ := ctx.Value(apiKeyContextKey{}).(*entity.APIKey)
+	return apiKey
+}
+
+const (
+	// SignatureProviderHeader names the provider whose secret signed the request
+	SignatureProviderHeader = "X-Signature-Provider"
+	// SignatureTimestampHeader is the time of the signature, in Unix seconds
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	// SignatureHeader is the hex encoded HMAC-SHA256 of the request, see Signature
+	SignatureHeader = "X-Signature"
+
+	// maxSignedBodySize bounds the body read into memory to be verified
+	maxSignedBodySize = 1 << 20
+)
+
+// Signature computes the HMAC-SHA256, hex encoded, of the method, path, timestamp and body,
+// each of them followed by a newline
+func Signature(secret []byte, method string, path string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n", method, path, timestamp)
+	mac.Write(body)         //nolint:all
+	mac.Write([]byte("\n")) //nolint:all
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignatureMiddleware is a middleware that verifies the requests signed by the game providers.
+// The timestamp of the signature must be within the window around the current time,
+// so a captured request can not be replayed later on.
+type SignatureMiddleware struct {
+	secrets map[string][]byte
+	window  time.Duration
+	now     func() time.Time
+}
+
+// NewSignatureMiddleware initializes a new SignatureMiddleware
+// with the secret of each provider and the accepted clock skew
+func NewSignatureMiddleware(secrets map[string]string, window time.Duration) func(next http.Handler) http.Handler {
+	sm := SignatureMiddleware{
+		secrets: make(map[string][]byte, len(secrets)),
+		window:  window,
+		now:     time.Now,
+	}
+	for provider, secret := range secrets {
+		sm.secrets[provider] = []byte(secret)
+	}
+	return sm.perform
+}
+
+// perform is the middleware handler itself
+func (sm SignatureMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider := r.Header.Get(SignatureProviderHeader)
+		signature := r.Header.Get(SignatureHeader)
+		if provider == "" || signature == "" || r.Header.Get(SignatureTimestampHeader) == "" {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrMissingSignature.Error()})
+			return
+		}
+
+		secret, found := sm.secrets[provider]
+		if !found {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrUnknownSigningProvider.Error()})
+			return
+		}
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(SignatureTimestampHeader), 10, 64)
+		if err != nil {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrInvalidSignatureTimestamp.Error()})
+			return
+		}
+		if skew := sm.now().Sub(time.Unix(timestamp, 0)); skew > sm.window || skew < -sm.window {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrSignatureExpired.Error()})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodySize))
+		if err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, []string{entity.ErrRequestPayload.Error()})
+			return
+		}
+
+		expected := Signature(secret, r.Method, r.URL.Path, timestamp, body)
+		if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+			WriteErrorResponse(w, http.StatusUnauthorized, []string{entity.ErrInvalidSignature.Error()})
+			return
+		}
+
+		// Hand the body, already consumed, over to the next handler
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RateLimitMiddleware is a middleware that limits the requests of each user, from the {id} path variable,
+// and of each client, identified by its API key or else by its Source-Type.
+// A nil limiter leaves the matching requests unlimited.
+type RateLimitMiddleware struct {
+	perUser   *ratelimit.Limiter
+	perClient *ratelimit.Limiter
+}
+
+// NewRateLimitMiddleware initializes a new RateLimitMiddleware
+func NewRateLimitMiddleware(perUser *ratelimit.Limiter, perClient *ratelimit.Limiter) func(next http.Handler) http.Handler {
+	return RateLimitMiddleware{
+		perUser:   perUser,
+		perClient: perClient,
+	}.perform
+}
+
+// perform is the middleware handler itself
+func (rlm RateLimitMiddleware) perform(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID, found := mux.Vars(r)["id"]; found && rlm.perUser != nil {
+			if allowed, retryAfter := rlm.perUser.Allow("user:" + userID); !allowed {
+				writeRateLimited(w, retryAfter)
+				return
+			}
+		}
+
+		if client := clientKey(r); client != "" && rlm.perClient != nil {
+			if allowed, retryAfter := rlm.perClient.Allow(client); !allowed {
+				writeRateLimited(w, retryAfter)
+				return
+			}
+		}
+
+		// Call the next handler as a normal flow execution
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientKey identifies the caller by its API key, or else by its Source-Type
+func clientKey(r *http.Request) string {
+	if apiKey := apiKeyFrom(r.Context()); apiKey != nil {
+		return fmt.Sprintf("api-key:%d", apiKey.ID)
+	}
+	if source := r.Header.Get("Source-Type"); source != "" {
+		return "source:" + strings.ToLower(source)
+	}
+	return ""
+}
+
+// writeRateLimited answers 429, telling the caller how many seconds to wait before retrying
+func writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	WriteErrorResponse(w, http.StatusTooManyRequests, []string{entity.ErrRateLimited.Error()})
+}
@@ -1,10 +1,29 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/ratelimit"
 	"github.com/ildomm/account-balance-manager/test_helpers"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -66,3 +85,543 @@ func TestLoggingMiddleware(t *testing.T) {
 	assert.Contains(t, logOutput, "202", "log does not contain correct status code")
 	assert.Contains(t, logOutput, "ms", "log does not contain execution time")
 }
+
+// TestRequestIDMiddleware tests that the RequestIDMiddleware generates a request ID or honors the client one.
+func TestRequestIDMiddleware(t *testing.T) {
+	var contextRequestID string
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextRequestID = logging.RequestIDFrom(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testServer := httptest.NewServer(NewRequestIDMiddleware()(testHandler))
+	defer testServer.Close()
+
+	// Generated when missing
+	resp, err := http.Get(testServer.URL)
+	assert.NoError(t, err)
+	generated := resp.Header.Get(logging.RequestIDHeader)
+	assert.NotEmpty(t, generated)
+	assert.Equal(t, generated, contextRequestID)
+
+	// Honored when valid
+	req, _ := http.NewRequest(http.MethodGet, testServer.URL, nil)
+	req.Header.Set(logging.RequestIDHeader, "client-req-1")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "client-req-1", resp.Header.Get(logging.RequestIDHeader))
+	assert.Equal(t, "client-req-1", contextRequestID)
+
+	// Replaced when invalid
+	req, _ = http.NewRequest(http.MethodGet, testServer.URL, nil)
+	req.Header.Set(logging.RequestIDHeader, "not valid!")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "not valid!", resp.Header.Get(logging.RequestIDHeader))
+	assert.NotEmpty(t, resp.Header.Get(logging.RequestIDHeader))
+}
+
+// TestRequestIDInLogsAndErrors tests that the request ID shows up in the logs and in the error responses.
+func TestRequestIDInLogsAndErrors(t *testing.T) {
+	logBuf, restoreLog := test_helpers.CaptureOutput()
+	defer restoreLog()
+
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return(nil, errors.New("server error"))
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, testServer.URL+"/user/1/balance", nil)
+	req.Header.Set(logging.RequestIDHeader, "support-42")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "support-42", resp.Header.Get(logging.RequestIDHeader))
+
+	var errorResponse ErrorResponse
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+	assert.Equal(t, "support-42", errorResponse.RequestID)
+
+	logOutput := logBuf.String()
+	assert.Contains(t, logOutput, `"msg":"internal error"`)
+	assert.Contains(t, logOutput, `"msg":"request served"`)
+	assert.Equal(t, 2, strings.Count(logOutput, `"request_id":"support-42"`))
+}
+
+// TestMetricsMiddleware tests that the MetricsMiddleware counts the requests by route template and status.
+func TestMetricsMiddleware(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return([]entity.Wallet{}, nil)
+	daoMock.On("RetrieveWallets", mock.Anything, 2).Return(nil, entity.ErrUserNotFound)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithMetrics(metrics.NewMetrics())
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	for _, path := range []string{"/user/1/balance", "/user/1/balance", "/user/2/balance"} {
+		resp, err := http.Get(testServer.URL + path)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(testServer.URL + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `abm_http_requests_total{method="GET",route="/user/{id}/balance",status="200"} 2`)
+	assert.Contains(t, string(body), `abm_http_requests_total{method="GET",route="/user/{id}/balance",status="404"} 1`)
+	assert.Contains(t, string(body), `abm_http_request_duration_seconds_count{method="GET",route="/user/{id}/balance",status="200"} 2`)
+}
+
+// TestTracingMiddleware tests that the TracingMiddleware continues the caller trace and hands its span to the handlers.
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	// The handler must receive the request span in its context
+	withSpan := mock.MatchedBy(func(ctx context.Context) bool {
+		return trace.SpanFromContext(ctx).SpanContext().IsValid()
+	})
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", withSpan, 1).Return([]entity.Wallet{}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	req, err := http.NewRequest(http.MethodGet, testServer.URL+"/user/1/balance", nil)
+	assert.NoError(t, err)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "GET /user/{id}/balance", spans[0].Name())
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusOK))
+	daoMock.AssertExpectations(t)
+}
+
+// TestAuthenticationMiddleware tests that the user routes require an API key authorized for the user and the source.
+func TestAuthenticationMiddleware(t *testing.T) {
+	revokedAt := time.Now()
+
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("game-key")).Return(&entity.APIKey{
+		Name:    "game-server",
+		Sources: []entity.TransactionSource{entity.TransactionSourceGame},
+		UserIDs: []int{1},
+	}, nil)
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("revoked-key")).Return(&entity.APIKey{
+		Sources:   []entity.TransactionSource{entity.TransactionSourceGame},
+		RevokedAt: &revokedAt,
+	}, nil)
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("failing-key")).Return(nil, errors.New("database error"))
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, mock.Anything).Return(nil, nil)
+
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return([]entity.Wallet{}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithAPIKeyStore(databaseMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	testCases := []struct {
+		name           string
+		path           string
+		apiKey         string
+		sourceType     string
+		expectedStatus int
+		expectedError  error
+	}{
+		{"Missing key", "/user/1/balance", "", "", http.StatusUnauthorized, entity.ErrMissingAPIKey},
+		{"Unknown key", "/user/1/balance", "unknown-key", "", http.StatusUnauthorized, entity.ErrInvalidAPIKey},
+		{"Revoked key", "/user/1/balance", "revoked-key", "", http.StatusUnauthorized, entity.ErrInvalidAPIKey},
+		{"Store failure", "/user/1/balance", "failing-key", "", http.StatusInternalServerError, entity.ErrServerInternal},
+		{"User not allowed", "/user/2/balance", "game-key", "", http.StatusForbidden, entity.ErrUserNotAllowed},
+		{"Source not allowed", "/user/1/balance", "game-key", "Payment", http.StatusForbidden, entity.ErrTransactionSourceNotAllowed},
+		{"Allowed source", "/user/1/balance", "game-key", "game", http.StatusOK, nil},
+		{"Allowed", "/user/1/balance", "game-key", "", http.StatusOK, nil},
+		{"Health stays open", "/health", "", "", http.StatusOK, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, testServer.URL+tc.path, nil)
+			if tc.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tc.apiKey)
+			}
+			if tc.sourceType != "" {
+				req.Header.Set("Source-Type", tc.sourceType)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			if tc.expectedError != nil {
+				var errorResponse ErrorResponse
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+				assert.Equal(t, []string{tc.expectedError.Error()}, errorResponse.Errors)
+			}
+		})
+	}
+}
+
+// TestAdminMiddleware tests that only the admin API keys can create the users and change their status.
+func TestAdminMiddleware(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("game-key")).Return(&entity.APIKey{
+		Sources: []entity.TransactionSource{entity.TransactionSourceGame},
+	}, nil)
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("server-key")).Return(&entity.APIKey{
+		Sources: []entity.TransactionSource{entity.TransactionSourceGame, entity.TransactionSourceServer, entity.TransactionSourcePayment},
+	}, nil)
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("admin-key")).Return(&entity.APIKey{
+		Sources: []entity.TransactionSource{entity.TransactionSourceServer},
+		Admin:   true,
+	}, nil)
+
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("CreateUser", mock.Anything).Return(&entity.User{ID: 1, Status: entity.UserStatusActive}, nil)
+	daoMock.On("UpdateUserStatus", mock.Anything, 1, entity.UserStatusFrozen).Return(&entity.User{ID: 1, Status: entity.UserStatusFrozen}, nil)
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return([]entity.Wallet{}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithAPIKeyStore(databaseMock)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		apiKey         string
+		expectedStatus int
+	}{
+		{"Create user without admin key", http.MethodPost, "/user", "", "game-key", http.StatusForbidden},
+		{"Update status without admin key", http.MethodPut, "/user/1/status", `{"status":"frozen"}`, "game-key", http.StatusForbidden},
+		{"Create user with unrestricted non-admin key", http.MethodPost, "/user", "", "server-key", http.StatusForbidden},
+		{"Update status with unrestricted non-admin key", http.MethodPut, "/user/1/status", `{"status":"frozen"}`, "server-key", http.StatusForbidden},
+		{"Create user with admin key", http.MethodPost, "/user", "", "admin-key", http.StatusCreated},
+		{"Update status with admin key", http.MethodPut, "/user/1/status", `{"status":"frozen"}`, "admin-key", http.StatusOK},
+		{"Other routes without admin key", http.MethodGet, "/user/1/balance", "", "game-key", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, testServer.URL+tc.path, strings.NewReader(tc.body))
+			req.Header.Set(APIKeyHeader, tc.apiKey)
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			if tc.expectedStatus == http.StatusForbidden {
+				var errorResponse ErrorResponse
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+				assert.Equal(t, []string{entity.ErrAdminNotAllowed.Error()}, errorResponse.Errors)
+			}
+		})
+	}
+}
+
+// TestSignatureMiddleware tests that the SignatureMiddleware verifies the signature and the timestamp window.
+func TestSignatureMiddleware(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	secret := []byte("s3cret")
+	body := []byte(`{"state":"win","amount":"10.00","transactionId":"abc"}`)
+
+	var receivedBody []byte
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	signatureMiddleware := SignatureMiddleware{
+		secrets: map[string][]byte{"acme": secret},
+		window:  DefaultSignatureWindow,
+		now:     func() time.Time { return now },
+	}
+	testServer := httptest.NewServer(signatureMiddleware.perform(testHandler))
+	defer testServer.Close()
+
+	validSignature := Signature(secret, http.MethodPost, "/user/1/transaction", now.Unix(), body)
+
+	testCases := []struct {
+		name           string
+		provider       string
+		timestamp      string
+		signature      string
+		expectedStatus int
+		expectedError  error
+	}{
+		{"Valid", "acme", fmt.Sprint(now.Unix()), validSignature, http.StatusOK, nil},
+		{"Uppercase signature", "acme", fmt.Sprint(now.Unix()), strings.ToUpper(validSignature), http.StatusOK, nil},
+		{"Missing signature", "acme", fmt.Sprint(now.Unix()), "", http.StatusUnauthorized, entity.ErrMissingSignature},
+		{"Missing provider", "", fmt.Sprint(now.Unix()), validSignature, http.StatusUnauthorized, entity.ErrMissingSignature},
+		{"Unknown provider", "other", fmt.Sprint(now.Unix()), validSignature, http.StatusUnauthorized, entity.ErrUnknownSigningProvider},
+		{"Invalid timestamp", "acme", "yesterday", validSignature, http.StatusUnauthorized, entity.ErrInvalidSignatureTimestamp},
+		{"Replayed", "acme", fmt.Sprint(now.Add(-DefaultSignatureWindow - time.Second).Unix()), Signature(secret, http.MethodPost, "/user/1/transaction", now.Add(-DefaultSignatureWindow-time.Second).Unix(), body), http.StatusUnauthorized, entity.ErrSignatureExpired},
+		{"From the future", "acme", fmt.Sprint(now.Add(DefaultSignatureWindow + time.Second).Unix()), validSignature, http.StatusUnauthorized, entity.ErrSignatureExpired},
+		{"Tampered", "acme", fmt.Sprint(now.Unix() + 1), validSignature, http.StatusUnauthorized, entity.ErrInvalidSignature},
+		{"Wrong secret", "acme", fmt.Sprint(now.Unix()), Signature([]byte("other"), http.MethodPost, "/user/1/transaction", now.Unix(), body), http.StatusUnauthorized, entity.ErrInvalidSignature},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			receivedBody = nil
+
+			req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction", bytes.NewReader(body))
+			if tc.provider != "" {
+				req.Header.Set(SignatureProviderHeader, tc.provider)
+			}
+			req.Header.Set(SignatureTimestampHeader, tc.timestamp)
+			if tc.signature != "" {
+				req.Header.Set(SignatureHeader, tc.signature)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.expectedStatus, resp.StatusCode)
+			if tc.expectedError != nil {
+				var errorResponse ErrorResponse
+				assert.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+				assert.Equal(t, []string{tc.expectedError.Error()}, errorResponse.Errors)
+				assert.Nil(t, receivedBody, "the handler must not be called")
+			} else {
+				assert.Equal(t, body, receivedBody, "the handler must read the whole body")
+			}
+		})
+	}
+}
+
+// TestSignedTransactionRoute tests that the transaction route requires a signature, once secrets are set, unlike the read routes.
+func TestSignedTransactionRoute(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("CreateGameResult", mock.Anything, 1, entity.GameStatusWin, entity.Money(1000), entity.DefaultCurrency, entity.TransactionSourceGame, "abc", (*string)(nil)).
+		Return(&entity.GameResult{ID: 1, UserID: 1, TransactionID: "abc"}, nil)
+	daoMock.On("RetrieveWallets", mock.Anything, 1).Return([]entity.Wallet{}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithSigningSecrets(map[string]string{"acme": "s3cret"})
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	body := []byte(`{"state":"win","amount":"10.00","transactionId":"abc"}`)
+	timestamp := time.Now().Unix()
+
+	// Unsigned
+	req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction", bytes.NewReader(body))
+	req.Header.Set("Source-Type", "game")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Signed, the handler decodes the body as usual
+	req, _ = http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction", bytes.NewReader(body))
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set(SignatureProviderHeader, "acme")
+	req.Header.Set(SignatureTimestampHeader, fmt.Sprint(timestamp))
+	req.Header.Set(SignatureHeader, Signature([]byte("s3cret"), http.MethodPost, "/user/1/transaction", timestamp, body))
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Other routes are left unsigned
+	resp, err = http.Get(testServer.URL + "/user/1/balance")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	daoMock.AssertExpectations(t)
+}
+
+// TestSignedReversalRoute tests that the reversal route requires a signature, once secrets are set, as it moves money.
+func TestSignedReversalRoute(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("ReverseGameResult", mock.Anything, 1, "abc", entity.TransactionSourceGame).
+		Return(&entity.GameResult{ID: 2, UserID: 1, TransactionID: entity.ReversalTransactionID("abc")}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithSigningSecrets(map[string]string{"acme": "s3cret"})
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	timestamp := time.Now().Unix()
+
+	// Unsigned
+	req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction/abc/reverse", nil)
+	req.Header.Set("Source-Type", "game")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Signed for another path, eg: replaying the signature of another reversal
+	req, _ = http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction/abc/reverse", nil)
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set(SignatureProviderHeader, "acme")
+	req.Header.Set(SignatureTimestampHeader, fmt.Sprint(timestamp))
+	req.Header.Set(SignatureHeader, Signature([]byte("s3cret"), http.MethodPost, "/user/1/transaction/other/reverse", timestamp, nil))
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Signed
+	req, _ = http.NewRequest(http.MethodPost, testServer.URL+"/user/1/transaction/abc/reverse", nil)
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set(SignatureProviderHeader, "acme")
+	req.Header.Set(SignatureTimestampHeader, fmt.Sprint(timestamp))
+	req.Header.Set(SignatureHeader, Signature([]byte("s3cret"), http.MethodPost, "/user/1/transaction/abc/reverse", timestamp, nil))
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	daoMock.AssertExpectations(t)
+}
+
+// TestSignedHoldRoutes tests that the hold routes changing the available balance require a signature, once secrets are set.
+func TestSignedHoldRoutes(t *testing.T) {
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("ReleaseHold", mock.Anything, 1, 7, entity.TransactionSourceGame).
+		Return(&entity.Hold{ID: 7, UserID: 1, Status: entity.HoldStatusReleased}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithSigningSecrets(map[string]string{"acme": "s3cret"})
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	// Unsigned
+	for _, path := range []string{"/user/1/holds", "/user/1/holds/7/capture", "/user/1/holds/7/release"} {
+		req, _ := http.NewRequest(http.MethodPost, testServer.URL+path, bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Source-Type", "game")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, path)
+	}
+
+	// Signed
+	timestamp := time.Now().Unix()
+	req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/user/1/holds/7/release", nil)
+	req.Header.Set("Source-Type", "game")
+	req.Header.Set(SignatureProviderHeader, "acme")
+	req.Header.Set(SignatureTimestampHeader, fmt.Sprint(timestamp))
+	req.Header.Set(SignatureHeader, Signature([]byte("s3cret"), http.MethodPost, "/user/1/holds/7/release", timestamp, nil))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	daoMock.AssertExpectations(t)
+}
+
+// TestRateLimitMiddleware tests that the requests are limited per user and per client, answering 429 with Retry-After.
+func TestRateLimitMiddleware(t *testing.T) {
+	databaseMock := test_helpers.NewDatabaseMock()
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("key-1")).Return(&entity.APIKey{ID: 1}, nil)
+	databaseMock.On("SelectAPIKeyByHash", mock.Anything, entity.HashAPIKey("key-2")).Return(&entity.APIKey{ID: 2}, nil)
+
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", mock.Anything, mock.Anything).Return([]entity.Wallet{}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+	server.WithAPIKeyStore(databaseMock)
+	server.WithRateLimits(
+		ratelimit.Limit{Requests: 2, Per: time.Hour},
+		ratelimit.Limit{Requests: 2, Per: time.Hour},
+	)
+
+	testServer := httptest.NewServer(server.router())
+	defer testServer.Close()
+
+	get := func(path string, apiKey string) *http.Response {
+		req, _ := http.NewRequest(http.MethodGet, testServer.URL+path, nil)
+		req.Header.Set(APIKeyHeader, apiKey)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// Per user, whatever the client
+	assert.Equal(t, http.StatusOK, get("/user/1/balance", "key-1").StatusCode)
+	assert.Equal(t, http.StatusOK, get("/user/1/balance", "key-2").StatusCode)
+
+	resp := get("/user/1/balance", "key-1")
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1800", resp.Header.Get("Retry-After"))
+
+	// Per client, whatever the user
+	assert.Equal(t, http.StatusOK, get("/user/2/balance", "key-1").StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, get("/user/3/balance", "key-1").StatusCode)
+	assert.Equal(t, http.StatusOK, get("/user/3/balance", "key-2").StatusCode)
+
+	// Health stays unlimited
+	for range 5 {
+		resp, err := http.Get(testServer.URL + "/health")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
+
+// TestRateLimitMiddlewareBySource tests that the clients are told apart by their Source-Type without API keys.
+func TestRateLimitMiddlewareBySource(t *testing.T) {
+	handler := NewRateLimitMiddleware(nil, ratelimit.NewLimiter(ratelimit.Limit{Requests: 1, Per: time.Hour}))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	testServer := httptest.NewServer(handler)
+	defer testServer.Close()
+
+	get := func(source string) int {
+		req, _ := http.NewRequest(http.MethodGet, testServer.URL, nil)
+		req.Header.Set("Source-Type", source)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, get("game"))
+	assert.Equal(t, http.StatusTooManyRequests, get("GAME"))
+	assert.Equal(t, http.StatusOK, get("payment"))
+}
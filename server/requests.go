@@ -2,8 +2,30 @@ package server
 
 import "github.com/ildomm/account-balance-manager/entity"
 
+// CreateGameResultRequest records a game result, optionally within a game round:
+// a lose is the stake of the round and a win its payout.
+// Currency is an ISO 4217 code, entity.DefaultCurrency when empty.
 type CreateGameResultRequest struct {
 	GameStatus    entity.GameStatus `json:"state"`
-	Amount        string            `json:"amount"` // TODO: This could be float64
+	Amount        entity.Money      `json:"amount"`
+	Currency      string            `json:"currency,omitempty"`
 	TransactionID string            `json:"transactionId"`
+	RoundID       *string           `json:"roundId,omitempty"`
+}
+
+type UpdateUserStatusRequest struct {
+	Status entity.UserStatus `json:"status"`
+}
+
+// CreateHoldRequest reserves an amount of the user balance,
+// for ExpiresInSeconds or else entity.DefaultHoldExpiry.
+// Currency is an ISO 4217 code, entity.DefaultCurrency when empty.
+type CreateHoldRequest struct {
+	Amount           entity.Money `json:"amount"`
+	Currency         string       `json:"currency,omitempty"`
+	ExpiresInSeconds *int         `json:"expiresInSeconds,omitempty"`
+}
+
+type CaptureHoldRequest struct {
+	TransactionID string `json:"transactionId"`
 }
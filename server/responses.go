@@ -3,22 +3,159 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"time"
+
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/logging"
 )
 
 // HealthResponse represents the response for the health check.
 type HealthResponse struct {
 	Status  string `json:"status"`
 	Version string `json:"version"`
+	GitSha  string `json:"gitSha,omitempty"`
+}
+
+// ReadyResponse represents the response for the readiness check.
+// Errors lists the reasons why the service is not ready, if any.
+type ReadyResponse struct {
+	Status   string            `json:"status"`
+	Version  string            `json:"version"`
+	GitSha   string            `json:"gitSha,omitempty"`
+	Database DatabaseReadiness `json:"database"`
+	Errors   []string          `json:"errors,omitempty"`
+}
+
+// DatabaseReadiness represents the state of the database connection and schema.
+type DatabaseReadiness struct {
+	Reachable        bool                   `json:"reachable"`
+	MigrationVersion uint                   `json:"migrationVersion"`
+	MigrationDirty   bool                   `json:"migrationDirty"`
+	Pool             ConnectionPoolResponse `json:"pool"`
+}
+
+// ConnectionPoolResponse represents the database connection pool statistics.
+type ConnectionPoolResponse struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitDurationMs     int64 `json:"waitDurationMs"`
 }
 
+// UserResponse represents an account user.
+// The balances are the ones of the wallet in Currency, entity.DefaultCurrency.
+// Balance is the cash balance, BonusBalance only turns into cash once WageringRequirement is wagered.
+// AvailableBalance is the cash and bonus balance left aside by the open holds.
 type UserResponse struct {
-	UserID  int    `json:"userId"`
-	Balance string `json:"balance"`
+	UserID              int               `json:"userId"`
+	Balance             entity.Money      `json:"balance"`
+	BonusBalance        entity.Money      `json:"bonusBalance"`
+	WageringRequirement entity.Money      `json:"wageringRequirement"`
+	AvailableBalance    entity.Money      `json:"availableBalance"`
+	Currency            entity.Currency   `json:"currency"`
+	Status              entity.UserStatus `json:"status"`
+	CreatedAt           time.Time         `json:"createdAt"`
+}
+
+// TransactionResponse represents a single recorded game result.
+// ReversesTransactionID is only present on reversals, referencing the reversed transaction.
+// Reason is only present on manual adjustments.
+// RoundID is only present on the game results of a game round.
+// BonusAmount is the part of Amount drawn from or credited to the bonus balance.
+// Balance and BonusBalance are the user cash and bonus balances in Currency right after the game result was recorded.
+type TransactionResponse struct {
+	ID                    int                      `json:"id"`
+	TransactionID         string                   `json:"transactionId"`
+	GameStatus            entity.GameStatus        `json:"state"`
+	Amount                entity.Money             `json:"amount"`
+	Currency              entity.Currency          `json:"currency"`
+	TransactionSource     entity.TransactionSource `json:"source"`
+	ReversesTransactionID *string                  `json:"reversesTransactionId,omitempty"`
+	Reason                *string                  `json:"reason,omitempty"`
+	RoundID               *string                  `json:"roundId,omitempty"`
+	BonusAmount           entity.Money             `json:"bonusAmount"`
+	Balance               entity.Money             `json:"balance"`
+	BonusBalance          entity.Money             `json:"bonusBalance"`
+	CreatedAt             time.Time                `json:"createdAt"`
+}
+
+// HoldResponse represents an amount reserved on the user balance.
+// TransactionID is only present once captured, referencing the resulting lose transaction.
+type HoldResponse struct {
+	HoldID            int                      `json:"holdId"`
+	UserID            int                      `json:"userId"`
+	Amount            entity.Money             `json:"amount"`
+	Currency          entity.Currency          `json:"currency"`
+	Status            entity.HoldStatus        `json:"status"`
+	TransactionSource entity.TransactionSource `json:"source"`
+	TransactionID     *string                  `json:"transactionId,omitempty"`
+	ExpiresAt         time.Time                `json:"expiresAt"`
+	CreatedAt         time.Time                `json:"createdAt"`
+}
+
+// RoundResponse represents the summary of a game round of the user.
+// Net is what the user won in the round, negative when lost.
+// Transactions are the game results of the round, oldest-first.
+type RoundResponse struct {
+	RoundID      string                `json:"roundId"`
+	UserID       int                   `json:"userId"`
+	Status       entity.RoundStatus    `json:"status"`
+	Currency     entity.Currency       `json:"currency"`
+	Stake        entity.Money          `json:"stake"`
+	Payout       entity.Money          `json:"payout"`
+	Net          entity.Money          `json:"net"`
+	StartedAt    time.Time             `json:"startedAt"`
+	Transactions []TransactionResponse `json:"transactions"`
+}
+
+// WalletResponse represents the balance of the user in a single currency.
+// Balance is the cash balance, BonusBalance only turns into cash once WageringRequirement is wagered.
+// AvailableBalance is the cash and bonus balance left aside by the open holds in that currency.
+type WalletResponse struct {
+	Currency            entity.Currency `json:"currency"`
+	Balance             entity.Money    `json:"balance"`
+	BonusBalance        entity.Money    `json:"bonusBalance"`
+	WageringRequirement entity.Money    `json:"wageringRequirement"`
+	AvailableBalance    entity.Money    `json:"availableBalance"`
+}
+
+// BalancesResponse represents the balances of the user, one per currency.
+// Balance is the cash balance in a single currency, kept for the clients predating the per-currency wallets.
+type BalancesResponse struct {
+	UserID   int              `json:"userId"`
+	Balance  entity.Money     `json:"balance"` // Deprecated: use Balances
+	Balances []WalletResponse `json:"balances"`
+}
+
+// HistoricalBalanceResponse represents the cash and bonus balance of the user in a single currency as of a past time.
+type HistoricalBalanceResponse struct {
+	Currency     entity.Currency `json:"currency"`
+	Balance      entity.Money    `json:"balance"`
+	BonusBalance entity.Money    `json:"bonusBalance"`
+}
+
+// HistoricalBalancesResponse represents the balances of the user as of a past time, one per currency.
+type HistoricalBalancesResponse struct {
+	UserID   int                         `json:"userId"`
+	At       time.Time                   `json:"at"`
+	Balances []HistoricalBalanceResponse `json:"balances"`
+}
+
+// GameResultsResponse represents a page of the user's transaction history.
+// NextCursor must be sent back as the `cursor` query parameter to fetch the next page.
+type GameResultsResponse struct {
+	UserID       int                   `json:"userId"`
+	Transactions []TransactionResponse `json:"transactions"`
+	NextCursor   string                `json:"nextCursor,omitempty"`
 }
 
 // ErrorResponse is the generic error API response container.
+// RequestID identifies the failed request in the server logs.
 type ErrorResponse struct {
-	Errors []string `json:"errors"`
+	Errors    []string `json:"errors"`
+	RequestID string   `json:"requestId,omitempty"`
 }
 
 // WriteInternalError writes a default internal error message as an HTTP response.
@@ -33,7 +170,8 @@ func WriteErrorResponse(w http.ResponseWriter, code int, errors []string) {
 	w.WriteHeader(code)
 
 	errorResponse := ErrorResponse{
-		Errors: errors,
+		Errors:    errors,
+		RequestID: w.Header().Get(logging.RequestIDHeader),
 	}
 
 	bytes, err := json.Marshal(errorResponse)
@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/ildomm/account-balance-manager/dao"
+	"github.com/ildomm/account-balance-manager/database"
+	"github.com/ildomm/account-balance-manager/metrics"
+	"github.com/ildomm/account-balance-manager/ratelimit"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -14,16 +19,36 @@ const (
 	DefaultWriteTimeout      = time.Second * 15
 	DefaultReadTimeout       = time.Second * 15
 	DefaultIdleTimeout       = time.Second * 60
+	DefaultReadyTimeout      = time.Second * 2
+	DefaultSignatureWindow   = time.Minute * 5
+	DefaultVersion           = "unknown"
+	DefaultPageLimit         = 20
+	MaxPageLimit             = 100
 )
 
 // Server manages HTTP requests and dispatches them to the appropriate services.
 type Server struct {
 	listenAddress     int
 	accountManager    dao.DAO
+	healthChecker     database.HealthChecker
+	apiKeyStore       database.APIKeyStore
+	signingSecrets    map[string]string
+	signatureWindow   time.Duration
+	userLimiter       *ratelimit.Limiter
+	clientLimiter     *ratelimit.Limiter
+	metrics           *metrics.Metrics
+	semVer            string
+	gitSha            string
 	readHeaderTimeout time.Duration
 	writeTimeout      time.Duration
 	readTimeout       time.Duration
 	idleTimeout       time.Duration
+	readyTimeout      time.Duration
+
+	// httpServer is the running server, set by Run and drained by Shutdown
+	lock       sync.Mutex
+	httpServer *http.Server
+	shutdown   bool
 }
 
 // NewServer is a factory to instantiate a new Server.
@@ -35,6 +60,10 @@ func NewServer() *Server {
 		writeTimeout:      DefaultWriteTimeout,
 		readTimeout:       DefaultReadTimeout,
 		idleTimeout:       DefaultIdleTimeout,
+		readyTimeout:      DefaultReadyTimeout,
+		signatureWindow:   DefaultSignatureWindow,
+		semVer:            DefaultVersion,
+		gitSha:            DefaultVersion,
 	}
 }
 
@@ -53,37 +82,167 @@ func (s *Server) Run() error {
 		Handler: s.router(),
 	}
 
+	s.lock.Lock()
+	if s.shutdown {
+		s.lock.Unlock()
+		return http.ErrServerClosed
+	}
+	s.httpServer = httpServer
+	s.lock.Unlock()
+
 	return httpServer.ListenAndServe()
 }
 
+// Shutdown stops accepting new requests and waits for the in-flight ones to complete.
+// If the context expires first, the remaining connections are closed and the context error is returned.
+// Once shut down, Run returns http.ErrServerClosed.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	s.shutdown = true
+	httpServer := s.httpServer
+	s.lock.Unlock()
+
+	if httpServer == nil {
+		return nil
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		httpServer.Close() //nolint:all
+		return err
+	}
+	return nil
+}
+
 // router registers all HandlerFunc and middleware for the existing HTTP routes.
 func (s *Server) router() *mux.Router {
 
 	r := mux.NewRouter()
 
 	// Interceptors
+	r.Use(NewRequestIDMiddleware())
 	r.Use(NewRecoverMiddleware())
 	r.Use(NewLoggingMiddleware())
+	r.Use(NewTracingMiddleware())
+	if s.metrics != nil {
+		r.Use(NewMetricsMiddleware(s.metrics))
+		r.Handle("/metrics", s.metrics.Handler()).Methods(http.MethodGet)
+	}
 
 	r.HandleFunc("/health", s.HealthHandler).Methods(http.MethodGet)
+	r.HandleFunc("/ready", s.ReadyHandler).Methods(http.MethodGet)
+
+	// The user routes require an API key, once a key store is set
+	users := r.PathPrefix("/user").Subrouter()
+	if s.apiKeyStore != nil {
+		users.Use(NewAuthenticationMiddleware(s.apiKeyStore))
+	}
+
+	// Then they are rate limited, once limits are set, knowing the API key of the caller
+	if s.userLimiter != nil || s.clientLimiter != nil {
+		users.Use(NewRateLimitMiddleware(s.userLimiter, s.clientLimiter))
+	}
 
 	dh := NewAccountHandler(s.accountManager)
-	r.HandleFunc("/user/{id}/transaction", dh.CreateGameResultFunc).Methods(http.MethodPost)
-	r.HandleFunc("/user/{id}/balance", dh.RetrieveUserFunc).Methods(http.MethodGet)
+	users.Handle("", s.admin(http.HandlerFunc(dh.CreateUserFunc))).Methods(http.MethodPost)
+	users.HandleFunc("/{id}", dh.RetrieveUserFunc).Methods(http.MethodGet)
+	users.Handle("/{id}/status", s.admin(http.HandlerFunc(dh.UpdateUserStatusFunc))).Methods(http.MethodPut)
+	users.Handle("/{id}/transaction", s.signed(http.HandlerFunc(dh.CreateGameResultFunc))).Methods(http.MethodPost)
+	users.Handle("/{id}/transaction/{transactionId}/reverse", s.signed(http.HandlerFunc(dh.ReverseGameResultFunc))).Methods(http.MethodPost)
+	users.HandleFunc("/{id}/balance", dh.RetrieveBalancesFunc).Methods(http.MethodGet)
+	users.HandleFunc("/{id}/transactions", dh.ListGameResultsFunc).Methods(http.MethodGet)
+	users.Handle("/{id}/holds", s.signed(http.HandlerFunc(dh.CreateHoldFunc))).Methods(http.MethodPost)
+	users.HandleFunc("/{id}/holds/{holdId}", dh.RetrieveHoldFunc).Methods(http.MethodGet)
+	users.Handle("/{id}/holds/{holdId}/capture", s.signed(http.HandlerFunc(dh.CaptureHoldFunc))).Methods(http.MethodPost)
+	users.Handle("/{id}/holds/{holdId}/release", s.signed(http.HandlerFunc(dh.ReleaseHoldFunc))).Methods(http.MethodPost)
+	users.HandleFunc("/{id}/rounds/{roundId}", dh.RetrieveRoundFunc).Methods(http.MethodGet)
 
 	return r
 }
 
-// HealthHandler evaluates the health of the service and writes a standardized response.
+// signed verifies the signature of the requests before handing them over to the handler,
+// once signing secrets are set
+func (s *Server) signed(handler http.Handler) http.Handler {
+	if len(s.signingSecrets) == 0 {
+		return handler
+	}
+	return NewSignatureMiddleware(s.signingSecrets, s.signatureWindow)(handler)
+}
+
+// admin restricts the handler to the admin API keys, once a key store is set
+func (s *Server) admin(handler http.Handler) http.Handler {
+	if s.apiKeyStore == nil {
+		return handler
+	}
+	return NewAdminMiddleware()(handler)
+}
+
+// HealthHandler evaluates the liveness of the service and writes a standardized response.
+// It does not depend on the database, see ReadyHandler.
 func (s *Server) HealthHandler(response http.ResponseWriter, request *http.Request) {
 	health := HealthResponse{
 		Status:  "ok",
-		Version: "v1",
+		Version: s.semVer,
+		GitSha:  s.gitSha,
 	}
 
 	WriteAPIResponse(response, http.StatusOK, health)
 }
 
+// ReadyHandler evaluates whether the service can serve requests and writes a standardized response.
+// The service is ready when the database answers within the ready timeout and its schema is not dirty.
+func (s *Server) ReadyHandler(response http.ResponseWriter, request *http.Request) {
+	ready := ReadyResponse{
+		Status:  "ready",
+		Version: s.semVer,
+		GitSha:  s.gitSha,
+	}
+
+	if s.healthChecker == nil {
+		ready.Errors = append(ready.Errors, "database health checker not configured")
+	} else {
+		ctx, cancel := context.WithTimeout(request.Context(), s.readyTimeout)
+		defer cancel()
+
+		if err := s.healthChecker.Ping(ctx); err != nil {
+			ready.Errors = append(ready.Errors, fmt.Sprintf("database unreachable: %s", err))
+		} else {
+			ready.Database.Reachable = true
+
+			migration, err := s.healthChecker.MigrationStatus(ctx)
+			switch {
+			case err != nil:
+				ready.Errors = append(ready.Errors, fmt.Sprintf("reading migration status: %s", err))
+			case migration == nil:
+				ready.Errors = append(ready.Errors, "database schema not migrated")
+			default:
+				ready.Database.MigrationVersion = migration.Version
+				ready.Database.MigrationDirty = migration.Dirty
+				if migration.Dirty {
+					ready.Errors = append(ready.Errors, fmt.Sprintf("database schema dirty at version %d", migration.Version))
+				}
+			}
+		}
+
+		stats := s.healthChecker.Stats()
+		ready.Database.Pool = ConnectionPoolResponse{
+			MaxOpenConnections: stats.MaxOpenConnections,
+			OpenConnections:    stats.OpenConnections,
+			InUse:              stats.InUse,
+			Idle:               stats.Idle,
+			WaitCount:          stats.WaitCount,
+			WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		}
+	}
+
+	if len(ready.Errors) > 0 {
+		ready.Status = "unavailable"
+		WriteAPIResponse(response, http.StatusServiceUnavailable, ready)
+		return
+	}
+
+	WriteAPIResponse(response, http.StatusOK, ready)
+}
+
 func (s *Server) ListenAddress() int {
 	return s.listenAddress
 }
@@ -96,6 +255,44 @@ func (s *Server) WithAccountManager(accountManager dao.DAO) {
 	s.accountManager = accountManager
 }
 
+func (s *Server) WithHealthChecker(healthChecker database.HealthChecker) {
+	s.healthChecker = healthChecker
+}
+
+// WithAPIKeyStore requires an API key on the user routes, looked up in the given store
+func (s *Server) WithAPIKeyStore(apiKeyStore database.APIKeyStore) {
+	s.apiKeyStore = apiKeyStore
+}
+
+// WithSigningSecrets requires the transactions to be signed by one of the providers, see SignatureMiddleware
+func (s *Server) WithSigningSecrets(signingSecrets map[string]string) {
+	s.signingSecrets = signingSecrets
+}
+
+func (s *Server) WithSignatureWindow(signatureWindow time.Duration) {
+	s.signatureWindow = signatureWindow
+}
+
+// WithRateLimits limits the requests of each user and of each client, disabled limits are left unlimited
+func (s *Server) WithRateLimits(perUser ratelimit.Limit, perClient ratelimit.Limit) {
+	s.userLimiter, s.clientLimiter = nil, nil
+	if perUser.Enabled() {
+		s.userLimiter = ratelimit.NewLimiter(perUser)
+	}
+	if perClient.Enabled() {
+		s.clientLimiter = ratelimit.NewLimiter(perClient)
+	}
+}
+
+func (s *Server) WithMetrics(metrics *metrics.Metrics) {
+	s.metrics = metrics
+}
+
+func (s *Server) WithVersion(semVer string, gitSha string) {
+	s.semVer = semVer
+	s.gitSha = gitSha
+}
+
 func (s *Server) WithReadHeaderTimeout(readHeaderTimeout time.Duration) {
 	s.readHeaderTimeout = readHeaderTimeout
 }
@@ -111,3 +308,7 @@ func (s *Server) WithReadTimeout(readTimeout time.Duration) {
 func (s *Server) WithIdleTimeout(idleTimeout time.Duration) {
 	s.idleTimeout = idleTimeout
 }
+
+func (s *Server) WithReadyTimeout(readyTimeout time.Duration) {
+	s.readyTimeout = readyTimeout
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -8,7 +10,10 @@ import (
 
 	"net"
 
+	"github.com/ildomm/account-balance-manager/entity"
+	"github.com/ildomm/account-balance-manager/test_helpers"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 // TestNewServer tests the NewServer factory function.
@@ -67,6 +72,59 @@ func TestServerRun(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.StatusCode, "unexpected status code from health check")
 }
 
+// TestServerShutdown tests that Shutdown drains the in-flight requests before stopping the server.
+func TestServerShutdown(t *testing.T) {
+	// Keep the request in-flight for a while
+	daoMock := test_helpers.NewDAOMock()
+	daoMock.On("RetrieveWallets", mock.Anything, 1).
+		Run(func(args mock.Arguments) { time.Sleep(500 * time.Millisecond) }).
+		Return([]entity.Wallet{{UserID: 1, Currency: entity.DefaultCurrency, Balance: entity.Money(10000)}}, nil)
+
+	server := NewServer()
+	server.WithAccountManager(daoMock)
+
+	port, err := getAvailablePort(8100)
+	assert.NoError(t, err)
+	server.WithListenAddress(port)
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- server.Run()
+	}()
+	time.Sleep(1 * time.Second) // Wait a moment for the server to start
+
+	inFlight := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://localhost:%d/user/1/balance", port))
+		assert.NoError(t, err)
+		if err == nil {
+			resp.Body.Close()
+			inFlight <- resp.StatusCode
+		}
+		close(inFlight)
+	}()
+	time.Sleep(100 * time.Millisecond) // Wait a moment for the request to reach the handler
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, server.Shutdown(ctx))
+
+	assert.Equal(t, http.StatusOK, <-inFlight)
+	assert.True(t, errors.Is(<-runErr, http.ErrServerClosed))
+
+	// No new requests are accepted
+	_, err = http.Get(fmt.Sprintf("http://localhost:%d/health", port))
+	assert.Error(t, err)
+}
+
+// TestServerShutdownBeforeRun tests that a server shut down before running does not start.
+func TestServerShutdownBeforeRun(t *testing.T) {
+	server := NewServer()
+
+	assert.NoError(t, server.Shutdown(context.Background()))
+	assert.True(t, errors.Is(server.Run(), http.ErrServerClosed))
+}
+
 // getAvailablePort finds an available port starting from the given port
 func getAvailablePort(startPort int) (int, error) {
 	maxAttempts := 100 // Try up to 100 ports
@@ -5,6 +5,9 @@ BASE_URL="http://localhost:8080"
 POST_URL_TEMPLATE="$BASE_URL/user/{USER_ID}/transaction"  # Template for different users
 GET_URL_TEMPLATE="$BASE_URL/user/{USER_ID}/balance"  # Template for getting balance of specific user
 
+# API key authorized for the game source, eg: created by `abmctl apikey create -name stress -sources game`
+API_KEY=${API_KEY:?"API_KEY must be set"}
+
 # User IDs for balance check
 USER_IDS=(1 2 3 4 5 6 7 8 9 10 11 12)
 
@@ -27,17 +30,18 @@ post_transaction() {
   curl -X POST "$POST_URL" \
     -H "Content-Type: application/json" \
     -H "Source-Type: game" \
+    -H "X-API-Key: $API_KEY" \
     -d "{\"state\": \"$state\", \"amount\": \"$amount\", \"transactionId\": \"$txn_id\"}" \
     -s -o /dev/null -w "%{http_code}"
 }
 
-# Function to GET user balance
+# Function to GET user balance, in EUR as the transactions
 get_balance() {
   user_id=$1
   # Substitute user_id into the GET_URL
   GET_URL=${GET_URL_TEMPLATE//\{USER_ID\}/$user_id}
 
-  curl -X GET "$GET_URL" -s | jq '.balance'
+  curl -X GET "$GET_URL?currency=EUR" -H "X-API-Key: $API_KEY" -s | jq -r '.balances[0].balance'
 }
 
 # Load test function to apply transactions concurrently
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/ildomm/account-balance-manager/entity"
 	"github.com/stretchr/testify/mock"
+	"time"
 )
 
 // DAOMock is a mock type for the DAO type
@@ -20,11 +21,13 @@ func (m *DAOMock) CreateGameResult(
 	ctx context.Context,
 	userID int,
 	gameStatus entity.GameStatus,
-	amount float64,
+	amount entity.Money,
+	currency entity.Currency,
 	transactionSource entity.TransactionSource,
-	transactionID string) (*entity.GameResult, error) {
+	transactionID string,
+	roundID *string) (*entity.GameResult, error) {
 
-	args := m.Called(ctx, userID, gameStatus, amount, transactionSource, transactionID)
+	args := m.Called(ctx, userID, gameStatus, amount, currency, transactionSource, transactionID, roundID)
 
 	if len(args) > 0 {
 		if arg := args.Get(0); arg != nil {
@@ -37,6 +40,42 @@ func (m *DAOMock) CreateGameResult(
 	return nil, args.Error(1)
 }
 
+func (m *DAOMock) CreateAdjustment(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, reason string) (*entity.GameResult, error) {
+	args := m.Called(ctx, userID, amount, currency, reason)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.GameResult), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) GrantBonus(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, wageringMultiplier int, reason string) (*entity.GameResult, error) {
+	args := m.Called(ctx, userID, amount, currency, wageringMultiplier, reason)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.GameResult), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) CreateUser(ctx context.Context) (*entity.User, error) {
+	args := m.Called(ctx)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.User), nil
+		}
+		if args.Get(1) != nil {
+			return nil, args.Error(1)
+		}
+	}
+	return nil, args.Error(1)
+}
+
 func (m *DAOMock) RetrieveUser(ctx context.Context, userID int) (*entity.User, error) {
 	args := m.Called(ctx, userID)
 
@@ -50,3 +89,191 @@ func (m *DAOMock) RetrieveUser(ctx context.Context, userID int) (*entity.User, e
 	}
 	return nil, args.Error(1)
 }
+
+func (m *DAOMock) RetrieveWallets(ctx context.Context, userID int) ([]entity.Wallet, error) {
+	args := m.Called(ctx, userID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.Wallet), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) RetrieveWallet(ctx context.Context, userID int, currency entity.Currency) (*entity.Wallet, error) {
+	args := m.Called(ctx, userID, currency)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Wallet), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) ListGameResults(ctx context.Context, filter entity.GameResultFilter) (*entity.GameResultPage, error) {
+	args := m.Called(ctx, filter)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.GameResultPage), nil
+		}
+		if args.Get(1) != nil {
+			return nil, args.Error(1)
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) ReverseGameResult(
+	ctx context.Context,
+	userID int,
+	transactionID string,
+	transactionSource entity.TransactionSource) (*entity.GameResult, error) {
+
+	args := m.Called(ctx, userID, transactionID, transactionSource)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.GameResult), nil
+		}
+		if args.Get(1) != nil {
+			return nil, args.Error(1)
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) UpdateUserStatus(ctx context.Context, userID int, status entity.UserStatus) (*entity.User, error) {
+	args := m.Called(ctx, userID, status)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.User), nil
+		}
+		if args.Get(1) != nil {
+			return nil, args.Error(1)
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) CreateHold(ctx context.Context, userID int, amount entity.Money, currency entity.Currency, transactionSource entity.TransactionSource, expiresIn time.Duration) (*entity.Hold, error) {
+	args := m.Called(ctx, userID, amount, currency, transactionSource, expiresIn)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Hold), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) RetrieveHold(ctx context.Context, userID int, holdID int) (*entity.Hold, error) {
+	args := m.Called(ctx, userID, holdID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Hold), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) CaptureHold(ctx context.Context, userID int, holdID int, transactionID string, transactionSource entity.TransactionSource) (*entity.GameResult, error) {
+	args := m.Called(ctx, userID, holdID, transactionID, transactionSource)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.GameResult), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) ReleaseHold(ctx context.Context, userID int, holdID int, transactionSource entity.TransactionSource) (*entity.Hold, error) {
+	args := m.Called(ctx, userID, holdID, transactionSource)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Hold), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) RetrieveRound(ctx context.Context, userID int, roundID string) (*entity.Round, error) {
+	args := m.Called(ctx, userID, roundID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Round), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) VerifyLedger(ctx context.Context, userID int) ([]entity.LedgerMismatch, error) {
+	args := m.Called(ctx, userID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.LedgerMismatch), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) Reconcile(ctx context.Context) (*entity.Reconciliation, error) {
+	args := m.Called(ctx)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Reconciliation), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) RetrieveBalancesAt(ctx context.Context, userID int, at time.Time) ([]entity.HistoricalBalance, error) {
+	args := m.Called(ctx, userID, at)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.HistoricalBalance), nil
+		}
+	}
+	return nil, args.Error(1)
+}
+
+func (m *DAOMock) TakeLedgerSnapshots(ctx context.Context, takenAt time.Time) (int, error) {
+	args := m.Called(ctx, takenAt)
+
+	if len(args) > 0 {
+		return args.Int(0), args.Error(1)
+	}
+	return 0, nil
+}
+
+func (m *DAOMock) CreateAPIKey(ctx context.Context, name string, sources []entity.TransactionSource, userIDs []int, admin bool) (string, *entity.APIKey, error) {
+	args := m.Called(ctx, name, sources, userIDs, admin)
+
+	if len(args) > 0 {
+		if arg := args.Get(1); arg != nil {
+			return args.String(0), arg.(*entity.APIKey), nil
+		}
+	}
+	return "", nil, args.Error(2)
+}
+
+func (m *DAOMock) RevokeAPIKey(ctx context.Context, keyID int) (*entity.APIKey, error) {
+	args := m.Called(ctx, keyID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.APIKey), nil
+		}
+	}
+	return nil, args.Error(1)
+}
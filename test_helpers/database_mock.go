@@ -2,12 +2,15 @@ package test_helpers
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"github.com/ildomm/account-balance-manager/entity"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/mock"
 	"math/rand"
+	"sort"
 	"sync"
+	"time"
 )
 
 // DatabaseMock is a mock type for the Querier type
@@ -15,6 +18,10 @@ type DatabaseMock struct {
 	mock.Mock
 	lock sync.Mutex
 
+	// txnLock emulates the row locking of a real database,
+	// serializing the db transactions performed over the mock
+	txnLock sync.Mutex
+
 	keys      map[string]map[string]interface{}
 	gameCount int
 }
@@ -28,6 +35,10 @@ func NewDatabaseMock() *DatabaseMock {
 	mocked.keys["game_results"] = make(map[string]interface{})
 	mocked.gameCount = int(0)
 	mocked.keys["user_balance"] = make(map[string]interface{})
+	mocked.keys["holds"] = make(map[string]interface{})
+	mocked.keys["wallets"] = make(map[string]interface{})
+	mocked.keys["ledger_postings"] = make(map[string]interface{})
+	mocked.keys["api_keys"] = make(map[string]interface{})
 
 	return mocked
 }
@@ -36,6 +47,44 @@ func (m *DatabaseMock) Close() {
 	m.Called()
 }
 
+func (m *DatabaseMock) Ping(ctx context.Context) error {
+	args := m.Called(ctx)
+	if len(args) > 0 {
+		return args.Error(0)
+	}
+	return nil
+}
+
+func (m *DatabaseMock) MigrationStatus(ctx context.Context) (*entity.MigrationStatus, error) {
+	args := m.Called(ctx)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.MigrationStatus), nil
+		}
+		return nil, args.Error(1)
+	}
+	return nil, nil
+}
+
+func (m *DatabaseMock) SelectAPIKeyByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.APIKey), nil
+		}
+		return nil, args.Error(1)
+	}
+	return nil, nil
+}
+
+func (m *DatabaseMock) Stats() sql.DBStats {
+	args := m.Called()
+	if len(args) > 0 {
+		return args.Get(0).(sql.DBStats)
+	}
+	return sql.DBStats{}
+}
+
 func (m *DatabaseMock) GameCount() int {
 	return m.gameCount
 }
@@ -43,6 +92,9 @@ func (m *DatabaseMock) GameCount() int {
 func (m *DatabaseMock) WithTransaction(ctx context.Context, fn func(*sqlx.Tx) error) (err error) {
 	m.Called(ctx, fn)
 
+	m.txnLock.Lock()
+	defer m.txnLock.Unlock()
+
 	txn := new(sqlx.Tx)
 	err = fn(txn)
 
@@ -68,6 +120,22 @@ func (m *DatabaseMock) InsertGameResult(ctx context.Context, txn sqlx.Tx, gameRe
 	}
 }
 
+func (m *DatabaseMock) InsertUser(ctx context.Context, user entity.User) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, user)
+	if len(args) > 0 {
+		return args.Int(0), args.Error(1)
+	}
+
+	id := len(m.keys["user_balance"]) + 1
+	user.ID = id
+	m.keys["user_balance"][fmt.Sprint(id)] = user
+
+	return id, nil
+}
+
 func (m *DatabaseMock) SelectUser(ctx context.Context, userID int) (*entity.User, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -87,6 +155,11 @@ func (m *DatabaseMock) SelectUser(ctx context.Context, userID int) (*entity.User
 	for _, user := range m.keys["user_balance"] {
 		if user.(entity.User).ID == userID {
 			_user := user.(entity.User)
+			wallet := m.wallet(userID, entity.DefaultCurrency)
+			_user.Balance = wallet.Balance
+			_user.BonusBalance = wallet.BonusBalance
+			_user.WageringRequirement = wallet.WageringRequirement
+			_user.HeldBalance = m.heldBalance(userID, entity.DefaultCurrency)
 			return &_user, nil
 		}
 	}
@@ -94,6 +167,35 @@ func (m *DatabaseMock) SelectUser(ctx context.Context, userID int) (*entity.User
 	return nil, nil
 }
 
+func (m *DatabaseMock) SelectUserForUpdate(ctx context.Context, txn sqlx.Tx, userID int) (*entity.User, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, userID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.User), nil
+		}
+
+		if arg := args.Get(1); arg != nil {
+			return nil, args.Error(1)
+		}
+	}
+
+	if user, ok := m.keys["user_balance"][fmt.Sprint(userID)]; ok {
+		_user := user.(entity.User)
+		wallet := m.wallet(userID, entity.DefaultCurrency)
+		_user.Balance = wallet.Balance
+		_user.BonusBalance = wallet.BonusBalance
+		_user.WageringRequirement = wallet.WageringRequirement
+		_user.HeldBalance = m.heldBalance(userID, entity.DefaultCurrency)
+		return &_user, nil
+	}
+
+	return nil, nil
+}
+
 func (m *DatabaseMock) TransactionIDExist(ctx context.Context, transactionId string) (bool, error) {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -106,18 +208,25 @@ func (m *DatabaseMock) TransactionIDExist(ctx context.Context, transactionId str
 	}
 }
 
-func (m *DatabaseMock) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userID int, balance float64) error {
+// UpdateWallet stores the wallet, creating the user when missing
+func (m *DatabaseMock) UpdateWallet(ctx context.Context, txn sqlx.Tx, wallet entity.Wallet) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	user := entity.User{
-		ID:      userID,
-		Balance: balance,
+	if _, ok := m.keys["user_balance"][fmt.Sprint(wallet.UserID)]; !ok {
+		m.keys["user_balance"][fmt.Sprint(wallet.UserID)] = entity.User{ID: wallet.UserID}
 	}
 
-	m.keys["user_balance"][fmt.Sprint(userID)] = user
+	now := time.Now()
+	wallet.HeldBalance = 0
+	wallet.CreatedAt = now
+	wallet.UpdatedAt = now
+	if existing, ok := m.keys["wallets"][walletKey(wallet.UserID, wallet.Currency)]; ok {
+		wallet.CreatedAt = existing.(entity.Wallet).CreatedAt
+	}
+	m.keys["wallets"][walletKey(wallet.UserID, wallet.Currency)] = wallet
 
-	args := m.Called(ctx, txn, userID, balance)
+	args := m.Called(ctx, txn, wallet)
 
 	if len(args) > 0 {
 		return args.Error(0)
@@ -125,3 +234,497 @@ func (m *DatabaseMock) UpdateUserBalance(ctx context.Context, txn sqlx.Tx, userI
 		return nil
 	}
 }
+
+func (m *DatabaseMock) SelectWallets(ctx context.Context, userID int) ([]entity.Wallet, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, userID)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.Wallet), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	wallets := []entity.Wallet{}
+	for _, wallet := range m.keys["wallets"] {
+		if _wallet := wallet.(entity.Wallet); _wallet.UserID == userID {
+			_wallet.HeldBalance = m.heldBalance(userID, _wallet.Currency)
+			wallets = append(wallets, _wallet)
+		}
+	}
+
+	sort.Slice(wallets, func(i, j int) bool {
+		return wallets[i].Currency < wallets[j].Currency
+	})
+
+	return wallets, nil
+}
+
+func (m *DatabaseMock) SelectWalletForUpdate(ctx context.Context, txn sqlx.Tx, userID int, currency entity.Currency) (*entity.Wallet, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, userID, currency)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Wallet), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	if wallet, ok := m.keys["wallets"][walletKey(userID, currency)]; ok {
+		_wallet := wallet.(entity.Wallet)
+		_wallet.HeldBalance = m.heldBalance(userID, currency)
+		return &_wallet, nil
+	}
+
+	return nil, nil
+}
+
+func walletKey(userID int, currency entity.Currency) string {
+	return fmt.Sprintf("%d:%s", userID, currency)
+}
+
+// wallet returns the user wallet, empty when missing
+func (m *DatabaseMock) wallet(userID int, currency entity.Currency) entity.Wallet {
+	if wallet, ok := m.keys["wallets"][walletKey(userID, currency)]; ok {
+		return wallet.(entity.Wallet)
+	}
+	return entity.Wallet{}
+}
+
+func (m *DatabaseMock) UpdateUserStatus(ctx context.Context, txn sqlx.Tx, userID int, status entity.UserStatus) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, userID, status)
+	if len(args) > 0 {
+		return args.Error(0)
+	}
+
+	if existing, ok := m.keys["user_balance"][fmt.Sprint(userID)]; ok {
+		user := existing.(entity.User)
+		user.Status = status
+		m.keys["user_balance"][fmt.Sprint(userID)] = user
+	}
+
+	return nil
+}
+
+func (m *DatabaseMock) SelectGameResults(ctx context.Context, filter entity.GameResultFilter) ([]entity.GameResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, filter)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.GameResult), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	gameResults := []entity.GameResult{}
+	for _, gameResult := range m.keys["game_results"] {
+		if gameResult.(entity.GameResult).UserID == filter.UserID {
+			gameResults = append(gameResults, gameResult.(entity.GameResult))
+		}
+	}
+
+	// Newest-first, limited to the requested page size
+	sort.Slice(gameResults, func(i, j int) bool {
+		return gameResults[i].ID > gameResults[j].ID
+	})
+	if filter.Limit > 0 && len(gameResults) > filter.Limit {
+		gameResults = gameResults[:filter.Limit]
+	}
+
+	return gameResults, nil
+}
+
+func (m *DatabaseMock) SelectGameResultByTransactionID(ctx context.Context, txn sqlx.Tx, transactionID string) (*entity.GameResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, transactionID)
+
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.GameResult), nil
+		}
+
+		if arg := args.Get(1); arg != nil {
+			return nil, args.Error(1)
+		}
+	}
+
+	for _, gameResult := range m.keys["game_results"] {
+		if gameResult.(entity.GameResult).TransactionID == transactionID {
+			_gameResult := gameResult.(entity.GameResult)
+			return &_gameResult, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (m *DatabaseMock) SelectGameResultsByRoundID(ctx context.Context, txn sqlx.Tx, userID int, roundID string) ([]entity.GameResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, userID, roundID)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.GameResult), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	return m.roundGameResults(userID, roundID), nil
+}
+
+func (m *DatabaseMock) SelectRoundGameResults(ctx context.Context, userID int, roundID string) ([]entity.GameResult, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, userID, roundID)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.GameResult), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	return m.roundGameResults(userID, roundID), nil
+}
+
+// roundGameResults returns the stored game results of the round of the user
+func (m *DatabaseMock) roundGameResults(userID int, roundID string) []entity.GameResult {
+	gameResults := []entity.GameResult{}
+	for _, gameResult := range m.keys["game_results"] {
+		_gameResult := gameResult.(entity.GameResult)
+		if _gameResult.UserID == userID && _gameResult.RoundID != nil && *_gameResult.RoundID == roundID {
+			gameResults = append(gameResults, _gameResult)
+		}
+	}
+
+	// Oldest-first
+	sort.Slice(gameResults, func(i, j int) bool {
+		return gameResults[i].ID < gameResults[j].ID
+	})
+
+	return gameResults
+}
+
+func (m *DatabaseMock) InsertHold(ctx context.Context, txn sqlx.Tx, hold entity.Hold) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, hold)
+	if len(args) > 0 {
+		return args.Int(0), args.Error(1)
+	}
+
+	id := len(m.keys["holds"]) + 1
+	hold.ID = id
+	m.keys["holds"][fmt.Sprint(id)] = hold
+
+	return id, nil
+}
+
+func (m *DatabaseMock) SelectHold(ctx context.Context, holdID int) (*entity.Hold, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, holdID)
+	return m.selectHold(args, holdID)
+}
+
+func (m *DatabaseMock) SelectHoldForUpdate(ctx context.Context, txn sqlx.Tx, holdID int) (*entity.Hold, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, holdID)
+	return m.selectHold(args, holdID)
+}
+
+func (m *DatabaseMock) selectHold(args mock.Arguments, holdID int) (*entity.Hold, error) {
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.Hold), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	if hold, ok := m.keys["holds"][fmt.Sprint(holdID)]; ok {
+		_hold := hold.(entity.Hold)
+		return &_hold, nil
+	}
+
+	return nil, nil
+}
+
+func (m *DatabaseMock) UpdateHold(ctx context.Context, txn sqlx.Tx, hold entity.Hold) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, hold)
+	if len(args) > 0 {
+		return args.Error(0)
+	}
+
+	m.keys["holds"][fmt.Sprint(hold.ID)] = hold
+
+	return nil
+}
+
+// InsertLedgerPostings stores the postings, rejecting them when they do not sum to zero as the database does
+func (m *DatabaseMock) InsertLedgerPostings(ctx context.Context, txn sqlx.Tx, postings []entity.LedgerPosting) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, txn, postings)
+	if len(args) > 0 {
+		return args.Error(0)
+	}
+
+	sum := entity.Money(0)
+	for _, posting := range postings {
+		sum += posting.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("unbalanced ledger postings: %s", sum)
+	}
+
+	for _, posting := range postings {
+		posting.ID = len(m.keys["ledger_postings"]) + 1
+		m.keys["ledger_postings"][fmt.Sprint(posting.ID)] = posting
+	}
+
+	return nil
+}
+
+func (m *DatabaseMock) SelectLedgerBalances(ctx context.Context, userID int) ([]entity.LedgerBalance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, userID)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.LedgerBalance), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	sums := make(map[string]*entity.LedgerBalance)
+	for _, posting := range m.keys["ledger_postings"] {
+		_posting := posting.(entity.LedgerPosting)
+		if _posting.UserID == nil || *_posting.UserID != userID {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s", _posting.Currency, _posting.Type)
+		if _, ok := sums[key]; !ok {
+			sums[key] = &entity.LedgerBalance{LedgerAccount: _posting.LedgerAccount}
+		}
+		sums[key].Balance += _posting.Amount
+	}
+
+	balances := []entity.LedgerBalance{}
+	for _, balance := range sums {
+		balances = append(balances, *balance)
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Currency != balances[j].Currency {
+			return balances[i].Currency < balances[j].Currency
+		}
+		return balances[i].Type < balances[j].Type
+	})
+
+	return balances, nil
+}
+
+// heldBalance sums the open holds of the user in the currency, not yet expired, as the user and wallet queries do
+func (m *DatabaseMock) heldBalance(userID int, currency entity.Currency) entity.Money {
+	held := entity.Money(0)
+	now := time.Now()
+	for _, hold := range m.keys["holds"] {
+		if _hold := hold.(entity.Hold); _hold.UserID == userID && _hold.Currency == currency && _hold.StatusAt(now) == entity.HoldStatusOpen {
+			held += _hold.Amount
+		}
+	}
+	return held
+}
+
+func (m *DatabaseMock) CountWallets(ctx context.Context) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx)
+	if len(args) > 0 {
+		return args.Int(0), args.Error(1)
+	}
+
+	return len(m.keys["wallets"]), nil
+}
+
+// SelectBalanceDiscrepancies compares the wallets with the sum of their game results, as the database does
+func (m *DatabaseMock) SelectBalanceDiscrepancies(ctx context.Context) ([]entity.BalanceDiscrepancy, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.BalanceDiscrepancy), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	compared := make(map[string]*entity.BalanceDiscrepancy)
+	discrepancy := func(userID int, currency entity.Currency) *entity.BalanceDiscrepancy {
+		key := fmt.Sprintf("%d:%s", userID, currency)
+		if _, ok := compared[key]; !ok {
+			compared[key] = &entity.BalanceDiscrepancy{UserID: userID, Currency: currency}
+		}
+		return compared[key]
+	}
+
+	for _, wallet := range m.keys["wallets"] {
+		_wallet := wallet.(entity.Wallet)
+		discrepancy(_wallet.UserID, _wallet.Currency).Balance = _wallet.Balance + _wallet.BonusBalance
+	}
+	for _, gameResult := range m.keys["game_results"] {
+		_gameResult := gameResult.(entity.GameResult)
+		amount := _gameResult.Amount
+		if _gameResult.GameStatus == entity.GameStatusLose {
+			amount = -amount
+		}
+		discrepancy(_gameResult.UserID, _gameResult.Currency).ExpectedBalance += amount
+	}
+
+	discrepancies := []entity.BalanceDiscrepancy{}
+	for _, d := range compared {
+		if d.Difference() != 0 {
+			discrepancies = append(discrepancies, *d)
+		}
+	}
+
+	sort.Slice(discrepancies, func(i, j int) bool {
+		if discrepancies[i].UserID != discrepancies[j].UserID {
+			return discrepancies[i].UserID < discrepancies[j].UserID
+		}
+		return discrepancies[i].Currency < discrepancies[j].Currency
+	})
+
+	return discrepancies, nil
+}
+
+// SelectLedgerBalancesAt sums the postings of the user accounts made at or before the given time,
+// as the database does from the snapshots
+func (m *DatabaseMock) SelectLedgerBalancesAt(ctx context.Context, userID int, at time.Time) ([]entity.LedgerBalance, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, userID, at)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.([]entity.LedgerBalance), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	sums := make(map[string]*entity.LedgerBalance)
+	for _, posting := range m.keys["ledger_postings"] {
+		_posting := posting.(entity.LedgerPosting)
+		if _posting.UserID == nil || *_posting.UserID != userID || _posting.CreatedAt.After(at) {
+			continue
+		}
+
+		key := fmt.Sprintf("%s:%s", _posting.Currency, _posting.Type)
+		if _, ok := sums[key]; !ok {
+			sums[key] = &entity.LedgerBalance{LedgerAccount: _posting.LedgerAccount}
+		}
+		sums[key].Balance += _posting.Amount
+	}
+
+	balances := []entity.LedgerBalance{}
+	for _, balance := range sums {
+		balances = append(balances, *balance)
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		if balances[i].Currency != balances[j].Currency {
+			return balances[i].Currency < balances[j].Currency
+		}
+		return balances[i].Type < balances[j].Type
+	})
+
+	return balances, nil
+}
+
+// InsertLedgerSnapshots returns the number of ledger accounts, the mock summing the postings without snapshots
+func (m *DatabaseMock) InsertLedgerSnapshots(ctx context.Context, takenAt time.Time) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, takenAt)
+	if len(args) > 0 {
+		return args.Int(0), args.Error(1)
+	}
+
+	accounts := make(map[string]bool)
+	for _, posting := range m.keys["ledger_postings"] {
+		_posting := posting.(entity.LedgerPosting)
+		userID := 0
+		if _posting.UserID != nil {
+			userID = *_posting.UserID
+		}
+		accounts[fmt.Sprintf("%s:%d:%s", _posting.Type, userID, _posting.Currency)] = true
+	}
+
+	return len(accounts), nil
+}
+
+func (m *DatabaseMock) InsertAPIKey(ctx context.Context, apiKey entity.APIKey) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, apiKey)
+	if len(args) > 0 {
+		return args.Int(0), args.Error(1)
+	}
+
+	id := len(m.keys["api_keys"]) + 1
+	apiKey.ID = id
+	m.keys["api_keys"][fmt.Sprint(id)] = apiKey
+
+	return id, nil
+}
+
+func (m *DatabaseMock) RevokeAPIKey(ctx context.Context, keyID int, revokedAt time.Time) (*entity.APIKey, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	args := m.Called(ctx, keyID, revokedAt)
+	if len(args) > 0 {
+		if arg := args.Get(0); arg != nil {
+			return arg.(*entity.APIKey), nil
+		}
+		return nil, args.Error(1)
+	}
+
+	apiKey, found := m.keys["api_keys"][fmt.Sprint(keyID)]
+	if !found {
+		return nil, nil
+	}
+	_apiKey := apiKey.(entity.APIKey)
+	if _apiKey.RevokedAt == nil {
+		_apiKey.RevokedAt = &revokedAt
+	}
+	m.keys["api_keys"][fmt.Sprint(keyID)] = _apiKey
+
+	return &_apiKey, nil
+}
@@ -3,14 +3,23 @@ package test_helpers
 import (
 	"bytes"
 	"log"
+	"log/slog"
+
+	"github.com/ildomm/account-balance-manager/logging"
 )
 
-// CaptureOutput redirects the log output to a buffer and returns a function to restore the original state and the buffer.
+// CaptureOutput redirects the log output, both slog and the standard log, to a buffer
+// and returns a function to restore the original state and the buffer.
 func CaptureOutput() (*bytes.Buffer, func()) {
-	originalOutput := log.Writer() // Store the original output
+	originalLogger := slog.Default() // Store the original output
+	originalOutput := log.Writer()
+	originalFlags := log.Flags()
+
 	buf := new(bytes.Buffer)
-	log.SetOutput(buf)
+	logging.Setup(buf)
 	return buf, func() {
-		log.SetOutput(originalOutput) // Restore the original output
+		slog.SetDefault(originalLogger) // Restore the original output
+		log.SetOutput(originalOutput)
+		log.SetFlags(originalFlags)
 	}
 }
@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ExporterNone disables the tracing, spans are still created but never exported
+	ExporterNone = "none"
+	// ExporterStdout writes the spans to the standard output
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends the spans over OTLP/HTTP to a collector,
+	// configured through the standard OTEL_EXPORTER_OTLP_* environment variables,
+	// defaulting to localhost:4318
+	ExporterOTLP = "otlp"
+)
+
+const (
+	ServiceName = "account-balance-manager"
+	tracerName  = "github.com/ildomm/account-balance-manager"
+)
+
+// ShutdownFunc flushes the pending spans and stops the exporter
+type ShutdownFunc func(ctx context.Context) error
+
+// Setup installs the global tracer provider, exporting the spans through the given exporter,
+// along with the W3C trace context propagator
+func Setup(ctx context.Context, exporter string, serviceVersion string) (ShutdownFunc, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case ExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s tracing exporter: %w", exporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName),
+			semconv.ServiceVersion(serviceVersion),
+		)),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer of the service, from the global tracer provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start starts a span of an internal operation
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// StartDBSpan starts a span around a database statement
+func StartDBSpan(ctx context.Context, name string, statement string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBQueryText(statement),
+		))
+}
+
+// End ends the span, recording the error, if any
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// UserID is the span attribute holding the ID of the user an operation applies to
+func UserID(userID int) attribute.KeyValue {
+	return attribute.Int("abm.user_id", userID)
+}
+
+// TransactionID is the span attribute holding the transaction ID given by the client
+func TransactionID(transactionID string) attribute.KeyValue {
+	return attribute.String("abm.transaction_id", transactionID)
+}
+
+// HoldID is the span attribute holding the ID of the hold an operation applies to
+func HoldID(holdID int) attribute.KeyValue {
+	return attribute.Int("abm.hold_id", holdID)
+}
+
+// RoundID is the span attribute holding the game round ID given by the client
+func RoundID(roundID string) attribute.KeyValue {
+	return attribute.String("abm.round_id", roundID)
+}